@@ -0,0 +1,45 @@
+package api
+
+import (
+	"github.com/AidChen0509/oosa_ketosdk/keto"
+	"github.com/gin-gonic/gin"
+)
+
+// ActorFunc 返回發起請求的已認證調用者，無法確定時返回空字符串
+type ActorFunc func(c *gin.Context) string
+
+// WithActor 設置確定調用者的方式，調用者會寫入 Keto 客戶端的審計記錄
+// 默認使用認證中間件 (例如 gin.BasicAuth) 設置的 gin.AuthUserKey
+func WithActor(fn ActorFunc) ServerOption {
+	return func(o *serverOptions) {
+		o.actor = fn
+	}
+}
+
+// ActorFromHeader 返回從請求頭 name 讀取調用者的 ActorFunc
+// 只能在前面有可信的反向代理 (例如認證網關) 設置並覆蓋此請求頭時使用，否則客戶端可以偽造調用者
+func ActorFromHeader(name string) ActorFunc {
+	return func(c *gin.Context) string {
+		return c.GetHeader(name)
+	}
+}
+
+// authUser 返回 gin 認證中間件設置的用戶
+func authUser(c *gin.Context) string {
+	return c.GetString(gin.AuthUserKey)
+}
+
+// actorMiddleware 將調用者傳遞給 Keto 客戶端
+// 必須放在認證中間件之後
+func actorMiddleware(fn ActorFunc) gin.HandlerFunc {
+	if fn == nil {
+		fn = authUser
+	}
+
+	return func(c *gin.Context) {
+		if actor := fn(c); actor != "" {
+			c.Request = c.Request.WithContext(keto.ContextWithActor(c.Request.Context(), actor))
+		}
+		c.Next()
+	}
+}
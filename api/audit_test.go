@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AidChen0509/oosa_ketosdk/keto"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// actorRecordingClient 記錄 DeletePhotoEventRelation 收到的調用者
+type actorRecordingClient struct {
+	*MockKetoClient
+	actor string
+}
+
+func (c *actorRecordingClient) DeletePhotoEventRelation(ctx context.Context, photoID, eventID, relationType string) error {
+	c.actor = keto.ActorFromContext(ctx)
+	return nil
+}
+
+// 測試認證中間件設置的用戶作為審計調用者傳遞給 Keto 客戶端
+func TestActorFromBasicAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	client := &actorRecordingClient{MockKetoClient: new(MockKetoClient)}
+	server, err := NewServer(client, WithMiddleware(gin.BasicAuth(gin.Accounts{"alice": "secret"})))
+	require.NoError(t, err)
+
+	req, _ := http.NewRequest("DELETE", "/api/photos/photo1/events/event1/reference", nil)
+	req.SetBasicAuth("alice", "secret")
+	response := httptest.NewRecorder()
+	server.router.ServeHTTP(response, req)
+
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "alice", client.actor)
+
+	// 未認證的請求不會到達 Keto 客戶端
+	client.actor = ""
+	req, _ = http.NewRequest("DELETE", "/api/photos/photo1/events/event1/reference", nil)
+	response = httptest.NewRecorder()
+	server.router.ServeHTTP(response, req)
+
+	assert.Equal(t, http.StatusUnauthorized, response.Code)
+	assert.Empty(t, client.actor)
+}
+
+// 測試自定義的調用者
+func TestWithActor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	client := &actorRecordingClient{MockKetoClient: new(MockKetoClient)}
+	server, err := NewServer(client, WithActor(func(c *gin.Context) string {
+		return c.GetHeader("X-User")
+	}))
+	require.NoError(t, err)
+
+	req, _ := http.NewRequest("DELETE", "/api/photos/photo1/events/event1/reference", nil)
+	req.Header.Set("X-User", "bob")
+	response := httptest.NewRecorder()
+	server.router.ServeHTTP(response, req)
+
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "bob", client.actor)
+}
+
+// 測試從可信代理設置的請求頭讀取調用者
+func TestActorFromHeader(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	client := &actorRecordingClient{MockKetoClient: new(MockKetoClient)}
+	server, err := NewServer(client, WithActor(ActorFromHeader("X-Forwarded-User")))
+	require.NoError(t, err)
+
+	req, _ := http.NewRequest("DELETE", "/api/photos/photo1/events/event1/reference", nil)
+	req.Header.Set("X-Forwarded-User", "carol")
+	response := httptest.NewRecorder()
+	server.router.ServeHTTP(response, req)
+
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "carol", client.actor)
+
+	// 沒有請求頭時沒有調用者
+	client.actor = "unset"
+	req, _ = http.NewRequest("DELETE", "/api/photos/photo1/events/event1/reference", nil)
+	response = httptest.NewRecorder()
+	server.router.ServeHTTP(response, req)
+
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Empty(t, client.actor)
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/AidChen0509/oosa_ketosdk/keto"
+	"github.com/gin-gonic/gin"
+)
+
+// dryRunParam 試運行的查詢參數，例如 POST /api/photos/reference/batch?dry_run=true
+const dryRunParam = "dry_run"
+
+// DryRunClient 由支持試運行的 Keto 客戶端實現，例如 *keto.Client
+// 使用 ContextWithDryRun 返回的 context 寫入和刪除時，客戶端只計算變更並記錄到 DryRun，不能寫入 Keto
+// 未實現此接口的客戶端收到 ?dry_run=true 時返回 501，避免忽略試運行的實現真的寫入
+type DryRunClient interface {
+	ContextWithDryRun(ctx context.Context) (context.Context, *keto.DryRun)
+}
+
+// dryRunContext 返回寫入使用的 context
+// 請求帶有 ?dry_run=true 時返回試運行的 context 和收集變更的 DryRun，否則 DryRun 為 nil
+// 參數無效時寫入 400 響應，客戶端不支持試運行時寫入 501 響應，並返回 false
+func (s *Server) dryRunContext(c *gin.Context) (context.Context, *keto.DryRun, bool) {
+	value, ok := c.GetQuery(dryRunParam)
+	if !ok {
+		return c.Request.Context(), nil, true
+	}
+
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "dry_run 必須為 true 或 false"})
+		return nil, nil, false
+	}
+	if !enabled {
+		return c.Request.Context(), nil, true
+	}
+
+	client, ok := s.ketoClient.(DryRunClient)
+	if !ok {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "Keto 客戶端不支持試運行"})
+		return nil, nil, false
+	}
+	ctx, plan := client.ContextWithDryRun(c.Request.Context())
+	return ctx, plan, true
+}
+
+// respondDryRun 返回試運行計算出的變更
+func respondDryRun(c *gin.Context, plan *keto.DryRun) {
+	c.JSON(http.StatusOK, gin.H{
+		"message": "試運行，沒有寫入任何關係",
+		"dry_run": true,
+		"deltas":  plan.Deltas(),
+	})
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AidChen0509/oosa_ketosdk/keto"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// *keto.Client 支持試運行
+var _ DryRunClient = (*keto.Client)(nil)
+
+// dryRunClient 試運行時記錄變更，否則調用 MockKetoClient
+type dryRunClient struct {
+	*MockKetoClient
+}
+
+func (c *dryRunClient) ContextWithDryRun(ctx context.Context) (context.Context, *keto.DryRun) {
+	return keto.ContextWithDryRun(ctx)
+}
+
+func (c *dryRunClient) BatchCreatePhotoEventReferences(ctx context.Context, relations []keto.PhotoEventRelation) error {
+	d := keto.DryRunFromContext(ctx)
+	if d == nil {
+		return c.MockKetoClient.BatchCreatePhotoEventReferences(ctx, relations)
+	}
+	for _, rel := range relations {
+		d.Add(keto.Delta{
+			Action:  keto.AuditActionInsert,
+			Tuple:   keto.RelationTuple{Namespace: keto.NamespacePhoto, Object: rel.PhotoID, Relation: keto.RelationReference, Subject: keto.SubjectSetOf(keto.NamespaceEvent, rel.EventID, "")},
+			Changed: true,
+		})
+	}
+	return nil
+}
+
+func (c *dryRunClient) DeletePhotoEventRelation(ctx context.Context, photoID, eventID, relationType string) error {
+	if d := keto.DryRunFromContext(ctx); d != nil {
+		d.Add(keto.Delta{Action: keto.AuditActionDelete, Tuple: keto.RelationTuple{Namespace: keto.NamespacePhoto, Object: photoID, Relation: relationType, Subject: keto.SubjectSetOf(keto.NamespaceEvent, eventID, "")}})
+	}
+	return nil
+}
+
+func TestDryRun_Batch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockClient := new(MockKetoClient)
+	server := &Server{router: gin.New(), ketoClient: &dryRunClient{mockClient}, requests: newTestRequestValidator()}
+	server.router.POST("/api/photos/reference/batch", server.batchCreatePhotoEventReferences)
+
+	body, _ := json.Marshal(BatchPhotoEventReferenceRequest{Relations: []PhotoEventRelation{
+		{PhotoID: "photo1", EventID: "event1"},
+		{PhotoID: "photo2", EventID: "event1"},
+	}})
+	req, _ := http.NewRequest("POST", "/api/photos/reference/batch?dry_run=true", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+
+	server.router.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var response struct {
+		DryRun bool `json:"dry_run"`
+		Deltas []struct {
+			Action  string `json:"action"`
+			Tuple   string `json:"tuple"`
+			Changed bool   `json:"changed"`
+		} `json:"deltas"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.True(t, response.DryRun)
+	require.Len(t, response.Deltas, 2)
+	assert.Equal(t, "insert", response.Deltas[0].Action)
+	assert.Equal(t, "Photo:photo1#reference@(Event:event1#)", response.Deltas[0].Tuple)
+	assert.True(t, response.Deltas[0].Changed)
+
+	// 沒有真正寫入
+	mockClient.AssertNotCalled(t, "BatchCreatePhotoEventReferences", mock.Anything)
+}
+
+func TestDryRun_Delete(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	server := &Server{router: gin.New(), ketoClient: &dryRunClient{new(MockKetoClient)}, requests: newTestRequestValidator()}
+	server.router.DELETE("/api/photos/:photoId/events/:eventId/:relationType", server.deletePhotoEventRelation)
+
+	req, _ := http.NewRequest("DELETE", "/api/photos/photo1/events/event1/polaroid?dry_run=1", nil)
+	recorder := httptest.NewRecorder()
+
+	server.router.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"tuple":"Photo:photo1#polaroid@(Event:event1#)"`)
+	assert.Contains(t, recorder.Body.String(), `"action":"delete"`)
+}
+
+func TestDryRun_Disabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockClient := new(MockKetoClient)
+	mockClient.On("BatchCreatePhotoEventReferences", mock.Anything).Return(nil)
+	server := &Server{router: gin.New(), ketoClient: &dryRunClient{mockClient}, requests: newTestRequestValidator()}
+	server.router.POST("/api/photos/reference/batch", server.batchCreatePhotoEventReferences)
+
+	body, _ := json.Marshal(BatchPhotoEventReferenceRequest{Relations: []PhotoEventRelation{{PhotoID: "photo1", EventID: "event1"}}})
+	req, _ := http.NewRequest("POST", "/api/photos/reference/batch?dry_run=false", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+
+	server.router.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NotContains(t, recorder.Body.String(), "dry_run")
+	mockClient.AssertExpectations(t)
+}
+
+func TestDryRun_InvalidParam(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	server, mockClient := setupTestServer()
+	server.router.DELETE("/api/photos/:photoId/events/:eventId/:relationType", server.deletePhotoEventRelation)
+
+	req, _ := http.NewRequest("DELETE", "/api/photos/photo1/events/event1/reference?dry_run=maybe", nil)
+	recorder := httptest.NewRecorder()
+
+	server.router.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "dry_run")
+	mockClient.AssertNotCalled(t, "DeletePhotoEventRelation", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDryRun_Unsupported(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// MockKetoClient 沒有實現 DryRunClient，試運行的請求不能發送到客戶端
+	server, mockClient := setupTestServer()
+	server.router.DELETE("/api/photos/:photoId/events/:eventId/:relationType", server.deletePhotoEventRelation)
+
+	req, _ := http.NewRequest("DELETE", "/api/photos/photo1/events/event1/reference?dry_run=true", nil)
+	recorder := httptest.NewRecorder()
+
+	server.router.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusNotImplemented, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "不支持試運行")
+	mockClient.AssertNotCalled(t, "DeletePhotoEventRelation", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -9,51 +9,62 @@ import (
 
 // PhotoEventReferenceRequest 建立照片和事件 reference 關係的請求
 type PhotoEventReferenceRequest struct {
-	PhotoID string `json:"photo_id" binding:"required"`
-	EventID string `json:"event_id" binding:"required"`
+	PhotoID string `json:"photo_id" binding:"required,ketoid"`
+	EventID string `json:"event_id" binding:"required,ketoid"`
 }
 
 // PhotoEventPolaroidRequest 建立照片和事件 polaroid 關係的請求
 type PhotoEventPolaroidRequest struct {
-	PhotoID string `json:"photo_id" binding:"required"`
-	EventID string `json:"event_id" binding:"required"`
+	PhotoID string `json:"photo_id" binding:"required,ketoid"`
+	EventID string `json:"event_id" binding:"required,ketoid"`
 }
 
 // PermissionCheckRequest 權限檢查的請求
 type PermissionCheckRequest struct {
-	Namespace string `form:"namespace" binding:"required"`
-	Object    string `form:"object" binding:"required"`
-	Relation  string `form:"relation" binding:"required"`
-	Subject   string `form:"subject" binding:"required"`
+	Namespace string `form:"namespace" binding:"required,ketoname"`
+	Object    string `form:"object" binding:"required,ketoid"`
+	Relation  string `form:"relation" binding:"required,ketoname"`
+	Subject   string `form:"subject" binding:"required,ketoid"`
 }
 
 // BatchPhotoEventReferenceRequest 批量建立照片和事件 reference 關係的請求
 type BatchPhotoEventReferenceRequest struct {
-	Relations []PhotoEventRelation `json:"relations" binding:"required,dive"`
+	Relations []PhotoEventRelation `json:"relations" binding:"required,min=1,dive"`
 }
 
 // BatchPhotoEventPolaroidRequest 批量建立照片和事件 polaroid 關係的請求
 type BatchPhotoEventPolaroidRequest struct {
-	Relations []PhotoEventRelation `json:"relations" binding:"required,dive"`
+	Relations []PhotoEventRelation `json:"relations" binding:"required,min=1,dive"`
 }
 
 // PhotoEventRelation 照片和事件關係
 type PhotoEventRelation struct {
-	PhotoID string `json:"photo_id" binding:"required"`
-	EventID string `json:"event_id" binding:"required"`
+	PhotoID string `json:"photo_id" binding:"required,ketoid"`
+	EventID string `json:"event_id" binding:"required,ketoid"`
 }
 
 // 創建照片和事件的 reference 關係
 func (s *Server) createPhotoEventReference(c *gin.Context) {
 	var req PhotoEventReferenceRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := s.requests.bindJSON(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": s.requests.errorMessage(err)})
 		return
 	}
 
-	err := s.ketoClient.CreatePhotoEventReference(req.PhotoID, req.EventID)
+	ctx, plan, ok := s.dryRunContext(c)
+	if !ok {
+		return
+	}
+
+	err := s.ketoClient.CreatePhotoEventReference(ctx, req.PhotoID, req.EventID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if plan != nil {
+		respondDryRun(c, plan)
 		return
 	}
 
@@ -63,14 +74,25 @@ func (s *Server) createPhotoEventReference(c *gin.Context) {
 // 創建照片和事件的 polaroid 關係
 func (s *Server) createPhotoEventPolaroid(c *gin.Context) {
 	var req PhotoEventPolaroidRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := s.requests.bindJSON(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": s.requests.errorMessage(err)})
+		return
+	}
+
+	ctx, plan, ok := s.dryRunContext(c)
+	if !ok {
 		return
 	}
 
-	err := s.ketoClient.CreatePhotoEventPolaroid(req.PhotoID, req.EventID)
+	err := s.ketoClient.CreatePhotoEventPolaroid(ctx, req.PhotoID, req.EventID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if plan != nil {
+		respondDryRun(c, plan)
 		return
 	}
 
@@ -80,14 +102,15 @@ func (s *Server) createPhotoEventPolaroid(c *gin.Context) {
 // 檢查權限
 func (s *Server) checkPermission(c *gin.Context) {
 	var req PermissionCheckRequest
-	if err := c.ShouldBindQuery(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := s.requests.bindQuery(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": s.requests.errorMessage(err)})
 		return
 	}
 
-	allowed, err := s.ketoClient.CheckPermission(req.Namespace, req.Object, req.Relation, req.Subject)
+	allowed, err := s.ketoClient.CheckPermission(c.Request.Context(), req.Namespace, req.Object, req.Relation, req.Subject)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -105,11 +128,13 @@ func (s *Server) checkPermission(c *gin.Context) {
 // 批量創建照片和事件的 reference 關係
 func (s *Server) batchCreatePhotoEventReferences(c *gin.Context) {
 	var req BatchPhotoEventReferenceRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := s.requests.bindJSON(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": s.requests.errorMessage(err)})
 		return
 	}
 
+	s.metrics.observeBatchSize(c, len(req.Relations))
+
 	// 轉換為內部數據結構
 	relations := make([]keto.PhotoEventRelation, len(req.Relations))
 	for i, rel := range req.Relations {
@@ -119,9 +144,20 @@ func (s *Server) batchCreatePhotoEventReferences(c *gin.Context) {
 		}
 	}
 
-	err := s.ketoClient.BatchCreatePhotoEventReferences(relations)
+	ctx, plan, ok := s.dryRunContext(c)
+	if !ok {
+		return
+	}
+
+	err := s.ketoClient.BatchCreatePhotoEventReferences(ctx, relations)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if plan != nil {
+		respondDryRun(c, plan)
 		return
 	}
 
@@ -134,11 +170,13 @@ func (s *Server) batchCreatePhotoEventReferences(c *gin.Context) {
 // 批量創建照片和事件的 polaroid 關係
 func (s *Server) batchCreatePhotoEventPolaroids(c *gin.Context) {
 	var req BatchPhotoEventPolaroidRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := s.requests.bindJSON(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": s.requests.errorMessage(err)})
 		return
 	}
 
+	s.metrics.observeBatchSize(c, len(req.Relations))
+
 	// 轉換為內部數據結構
 	relations := make([]keto.PhotoEventRelation, len(req.Relations))
 	for i, rel := range req.Relations {
@@ -148,9 +186,20 @@ func (s *Server) batchCreatePhotoEventPolaroids(c *gin.Context) {
 		}
 	}
 
-	err := s.ketoClient.BatchCreatePhotoEventPolaroids(relations)
+	ctx, plan, ok := s.dryRunContext(c)
+	if !ok {
+		return
+	}
+
+	err := s.ketoClient.BatchCreatePhotoEventPolaroids(ctx, relations)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if plan != nil {
+		respondDryRun(c, plan)
 		return
 	}
 
@@ -168,9 +217,10 @@ func (s *Server) getEventReferencePhotos(c *gin.Context) {
 		return
 	}
 
-	photos, err := s.ketoClient.GetEventReferencePhotos(eventID)
+	photos, err := s.ketoClient.GetEventReferencePhotos(c.Request.Context(), eventID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法獲取照片: " + err.Error()})
+		c.Error(err)
+		c.JSON(errorStatus(err), gin.H{"error": "無法獲取照片: " + err.Error()})
 		return
 	}
 
@@ -185,9 +235,10 @@ func (s *Server) getEventPolaroidPhotos(c *gin.Context) {
 		return
 	}
 
-	photos, err := s.ketoClient.GetEventPolaroidPhotos(eventID)
+	photos, err := s.ketoClient.GetEventPolaroidPhotos(c.Request.Context(), eventID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法獲取照片: " + err.Error()})
+		c.Error(err)
+		c.JSON(errorStatus(err), gin.H{"error": "無法獲取照片: " + err.Error()})
 		return
 	}
 
@@ -202,9 +253,10 @@ func (s *Server) getPhotoEvents(c *gin.Context) {
 		return
 	}
 
-	events, err := s.ketoClient.GetPhotoEvents(photoID)
+	events, err := s.ketoClient.GetPhotoEvents(c.Request.Context(), photoID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法獲取事件: " + err.Error()})
+		c.Error(err)
+		c.JSON(errorStatus(err), gin.H{"error": "無法獲取事件: " + err.Error()})
 		return
 	}
 
@@ -227,9 +279,20 @@ func (s *Server) deletePhotoEventRelation(c *gin.Context) {
 		return
 	}
 
-	err := s.ketoClient.DeletePhotoEventRelation(photoID, eventID, relationType)
+	ctx, plan, ok := s.dryRunContext(c)
+	if !ok {
+		return
+	}
+
+	err := s.ketoClient.DeletePhotoEventRelation(ctx, photoID, eventID, relationType)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "無法刪除關係: " + err.Error()})
+		c.Error(err)
+		c.JSON(errorStatus(err), gin.H{"error": "無法刪除關係: " + err.Error()})
+		return
+	}
+
+	if plan != nil {
+		respondDryRun(c, plan)
 		return
 	}
 
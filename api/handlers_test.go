@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -18,53 +19,63 @@ type MockKetoClient struct {
 	mock.Mock
 }
 
-func (m *MockKetoClient) CreatePhotoEventReference(photoID, eventID string) error {
+func (m *MockKetoClient) CreatePhotoEventReference(ctx context.Context, photoID, eventID string) error {
 	args := m.Called(photoID, eventID)
 	return args.Error(0)
 }
 
-func (m *MockKetoClient) CreatePhotoEventPolaroid(photoID, eventID string) error {
+func (m *MockKetoClient) CreatePhotoEventPolaroid(ctx context.Context, photoID, eventID string) error {
 	args := m.Called(photoID, eventID)
 	return args.Error(0)
 }
 
-func (m *MockKetoClient) CheckPermission(namespace, object, relation, subject string) (bool, error) {
+func (m *MockKetoClient) CheckPermission(ctx context.Context, namespace, object, relation, subject string) (bool, error) {
 	args := m.Called(namespace, object, relation, subject)
 	return args.Bool(0), args.Error(1)
 }
 
-func (m *MockKetoClient) BatchCreatePhotoEventReferences(relations []keto.PhotoEventRelation) error {
+func (m *MockKetoClient) BatchCreatePhotoEventReferences(ctx context.Context, relations []keto.PhotoEventRelation) error {
 	args := m.Called(relations)
 	return args.Error(0)
 }
 
-func (m *MockKetoClient) BatchCreatePhotoEventPolaroids(relations []keto.PhotoEventRelation) error {
+func (m *MockKetoClient) BatchCreatePhotoEventPolaroids(ctx context.Context, relations []keto.PhotoEventRelation) error {
 	args := m.Called(relations)
 	return args.Error(0)
 }
 
-func (m *MockKetoClient) GetEventReferencePhotos(eventID string) ([]string, error) {
+func (m *MockKetoClient) GetEventReferencePhotos(ctx context.Context, eventID string) ([]string, error) {
 	args := m.Called(eventID)
 	return args.Get(0).([]string), args.Error(1)
 }
 
-func (m *MockKetoClient) GetEventPolaroidPhotos(eventID string) ([]string, error) {
+func (m *MockKetoClient) GetEventPolaroidPhotos(ctx context.Context, eventID string) ([]string, error) {
 	args := m.Called(eventID)
 	return args.Get(0).([]string), args.Error(1)
 }
 
-func (m *MockKetoClient) GetPhotoEvents(photoID string) (map[string][]string, error) {
+func (m *MockKetoClient) GetPhotoEvents(ctx context.Context, photoID string) (map[string][]string, error) {
 	args := m.Called(photoID)
 	return args.Get(0).(map[string][]string), args.Error(1)
 }
 
-func (m *MockKetoClient) DeletePhotoEventRelation(photoID, eventID, relationType string) error {
+func (m *MockKetoClient) DeletePhotoEventRelation(ctx context.Context, photoID, eventID, relationType string) error {
 	args := m.Called(photoID, eventID, relationType)
 	return args.Error(0)
 }
 
-func (m *MockKetoClient) Close() {
-	m.Called()
+func (m *MockKetoClient) Close(ctx context.Context) error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+// newTestRequestValidator 創建使用默認 ID 規則的請求驗證器
+func newTestRequestValidator() *requestValidator {
+	requests, err := newRequestValidator(keto.IDValidator{})
+	if err != nil {
+		panic(err)
+	}
+	return requests
 }
 
 // 創建測試用的 Server 實例
@@ -79,6 +90,7 @@ func setupTestServer() (*Server, *MockKetoClient) {
 	server := &Server{
 		router:     gin.New(),
 		ketoClient: mockClient,
+		requests:   newTestRequestValidator(),
 	}
 
 	return server, mockClient
@@ -899,6 +911,30 @@ func TestBatchCreatePhotoEventReferences_Error(t *testing.T) {
 	})
 }
 
+// 測試空的批量請求返回 400，不會發送到 Keto
+func TestBatchCreate_EmptyRelations(t *testing.T) {
+	server, mockClient := setupTestServer()
+
+	photos := server.router.Group("/api/photos")
+	photos.POST("/reference/batch", server.batchCreatePhotoEventReferences)
+	photos.POST("/polaroid/batch", server.batchCreatePhotoEventPolaroids)
+
+	for _, path := range []string{"/api/photos/reference/batch", "/api/photos/polaroid/batch"} {
+		req, _ := http.NewRequest("POST", path, bytes.NewBufferString(`{"relations": []}`))
+		req.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, path)
+		var response map[string]string
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, "relations 至少需要 1 項", response["error"], path)
+	}
+
+	mockClient.AssertNotCalled(t, "BatchCreatePhotoEventReferences", mock.Anything)
+	mockClient.AssertNotCalled(t, "BatchCreatePhotoEventPolaroids", mock.Anything)
+}
+
 // 測試批量創建照片和事件的 polaroid 關係
 func TestBatchCreatePhotoEventPolaroids_Error(t *testing.T) {
 	server, mockClient := setupTestServer()
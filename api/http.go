@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HTTPTimeouts HTTP 服務器的超時，0 表示不限制
+type HTTPTimeouts struct {
+	ReadHeader time.Duration // 讀取請求頭的時間
+	Read       time.Duration // 讀取整個請求 (包括請求體) 的時間
+	Write      time.Duration // 從讀完請求頭到寫完響應的時間
+	Idle       time.Duration // keep-alive 連接等待下一個請求的時間
+}
+
+// DefaultHTTPTimeouts 未使用 WithHTTPTimeouts 時的超時
+var DefaultHTTPTimeouts = HTTPTimeouts{
+	ReadHeader: 10 * time.Second,
+	Read:       30 * time.Second,
+	Write:      60 * time.Second,
+	Idle:       2 * time.Minute,
+}
+
+// DefaultKetoCloseTimeout 未使用 WithKetoCloseTimeout 時，Shutdown 等待關閉 Keto 客戶端的時間
+const DefaultKetoCloseTimeout = 10 * time.Second
+
+// WithHTTPTimeouts 設置 HTTP 服務器的超時
+// 默認使用 DefaultHTTPTimeouts
+func WithHTTPTimeouts(t HTTPTimeouts) ServerOption {
+	return func(o *serverOptions) {
+		o.httpTimeouts = t
+	}
+}
+
+// WithKetoCloseTimeout 設置 Shutdown 關閉 Keto 客戶端時等待進行中調用的時間，0 表示不限制
+// 在等待 HTTP 請求完成之後單獨計時；默認為 DefaultKetoCloseTimeout
+func WithKetoCloseTimeout(d time.Duration) ServerOption {
+	return func(o *serverOptions) {
+		o.ketoCloseTimeout = d
+	}
+}
+
+// newHTTPServer 創建處理 router 的 http.Server
+func newHTTPServer(router *gin.Engine, t HTTPTimeouts) *http.Server {
+	return &http.Server{
+		Handler:           router,
+		ReadHeaderTimeout: t.ReadHeader,
+		ReadTimeout:       t.Read,
+		WriteTimeout:      t.Write,
+		IdleTimeout:       t.Idle,
+	}
+}
+
+// Run 在 addr 上啟動 API 服務器，直到 Shutdown 被調用
+// Shutdown 後返回 nil，其他情況返回監聽或服務的錯誤
+func (s *Server) Run(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("api: 無法監聽 %s: %w", addr, err)
+	}
+	return s.Serve(l)
+}
+
+// RunTLS 與 Run 相同，但使用 TLS 證書和私鑰提供 HTTPS
+func (s *Server) RunTLS(addr, certFile, keyFile string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("api: 無法監聽 %s: %w", addr, err)
+	}
+	return ignoreClosed(s.http.ServeTLS(l, certFile, keyFile))
+}
+
+// Serve 在 l 上接受連接，直到 Shutdown 被調用，返回時 l 已關閉
+func (s *Server) Serve(l net.Listener) error {
+	return ignoreClosed(s.http.Serve(l))
+}
+
+// Shutdown 優雅地關閉服務器：停止接受新連接，等待進行中的請求完成，然後關閉 Keto 客戶端
+// ctx 結束時強制關閉剩餘的連接；即使服務器沒有啟動也會關閉 Keto 客戶端
+// Server 擁有傳給 NewServer 的 Keto 客戶端，調用方不應再自行關閉它
+//
+// 參數:
+//   - ctx: 限制等待進行中請求的時間；關閉 Keto 客戶端使用 WithKetoCloseTimeout 設置的單獨截止時間
+//
+// 返回:
+//   - error: 等待超時或關閉 Keto 客戶端失敗時返回的所有錯誤
+func (s *Server) Shutdown(ctx context.Context) error {
+	var errs []error
+	if err := s.http.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("api: 等待進行中的請求時中止: %w", err))
+		errs = append(errs, s.http.Close())
+	}
+
+	// 請求耗盡 ctx 後，Keto 客戶端仍有自己的時間等待進行中的調用並關閉連接
+	closeCtx := context.WithoutCancel(ctx)
+	if s.ketoCloseTimeout > 0 {
+		var cancel context.CancelFunc
+		closeCtx, cancel = context.WithTimeout(closeCtx, s.ketoCloseTimeout)
+		defer cancel()
+	}
+	if err := s.ketoClient.Close(closeCtx); err != nil {
+		errs = append(errs, fmt.Errorf("api: 關閉 Keto 客戶端失敗: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// ignoreClosed 將 Shutdown 導致的 http.ErrServerClosed 視為正常結束
+func ignoreClosed(err error) error {
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// startBlockingServer 啟動服務器，GetEventReferencePhotos 在 release 關閉前不返回
+func startBlockingServer(t *testing.T) (s *Server, client *MockKetoClient, url string, started, release chan struct{}, served chan error) {
+	gin.SetMode(gin.TestMode)
+	client = new(MockKetoClient)
+	started, release = make(chan struct{}), make(chan struct{})
+	client.On("GetEventReferencePhotos", "event1").Run(func(mock.Arguments) {
+		close(started)
+		<-release
+	}).Return([]string{"photo1"}, nil)
+
+	s, err := NewServer(client)
+	require.NoError(t, err)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	served = make(chan error, 1)
+	go func() { served <- s.Serve(l) }()
+	return s, client, "http://" + l.Addr().String(), started, release, served
+}
+
+func TestServer_ShutdownDrainsRequests(t *testing.T) {
+	s, client, url, started, release, served := startBlockingServer(t)
+
+	client.On("Close").Run(func(mock.Arguments) {
+		select {
+		case <-release:
+		default:
+			t.Error("Keto 客戶端應在進行中的請求完成後關閉")
+		}
+	}).Return(nil)
+
+	status := make(chan int, 1)
+	go func() {
+		resp, err := http.Get(url + "/api/events/event1/photos/reference")
+		if !assert.NoError(t, err) {
+			status <- 0
+			return
+		}
+		resp.Body.Close()
+		status <- resp.StatusCode
+	}()
+	<-started
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- s.Shutdown(context.Background()) }()
+
+	// 進行中的請求完成前 Shutdown 不返回，也不接受新的連接
+	require.Eventually(t, func() bool {
+		_, err := net.Dial("tcp", url[len("http://"):])
+		return err != nil
+	}, time.Second, 10*time.Millisecond)
+	select {
+	case <-shutdown:
+		t.Fatal("Shutdown 應等待進行中的請求")
+	default:
+	}
+
+	close(release)
+	assert.Equal(t, http.StatusOK, <-status)
+	assert.NoError(t, <-shutdown)
+	assert.NoError(t, <-served)
+	client.AssertCalled(t, "Close")
+}
+
+func TestServer_ShutdownTimeout(t *testing.T) {
+	s, client, url, started, release, served := startBlockingServer(t)
+	defer close(release)
+	client.On("Close").Return(nil)
+
+	go http.Get(url + "/api/events/event1/photos/reference")
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := s.Shutdown(ctx)
+	assert.ErrorContains(t, err, "等待進行中的請求時中止")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.NoError(t, <-served)
+	client.AssertCalled(t, "Close")
+}
+
+// closeRecorder 記錄 Close 收到的 context
+type closeRecorder struct {
+	*MockKetoClient
+	ctx context.Context
+	err error // Close 時 ctx 的狀態
+}
+
+func (c *closeRecorder) Close(ctx context.Context) error {
+	c.ctx, c.err = ctx, ctx.Err()
+	return c.MockKetoClient.Close(ctx)
+}
+
+func TestServer_ShutdownClosesKetoWithOwnDeadline(t *testing.T) {
+	mockClient := new(MockKetoClient)
+	mockClient.On("Close").Return(nil)
+	client := &closeRecorder{MockKetoClient: mockClient}
+	s, err := NewServer(client, WithKetoCloseTimeout(time.Minute))
+	require.NoError(t, err)
+
+	// 等待請求的 ctx 已經結束，關閉 Keto 客戶端仍有自己的截止時間
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.Shutdown(ctx)
+
+	require.NotNil(t, client.ctx)
+	assert.NoError(t, client.err)
+	deadline, ok := client.ctx.Deadline()
+	require.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, 5*time.Second)
+
+	// 0 表示不限制
+	client = &closeRecorder{MockKetoClient: mockClient}
+	s, err = NewServer(client, WithKetoCloseTimeout(0))
+	require.NoError(t, err)
+	assert.NoError(t, s.Shutdown(context.Background()))
+	_, ok = client.ctx.Deadline()
+	assert.False(t, ok)
+}
+
+func TestServer_ShutdownWithoutRun(t *testing.T) {
+	client := new(MockKetoClient)
+	client.On("Close").Return(nil)
+	s, err := NewServer(client)
+	require.NoError(t, err)
+
+	assert.NoError(t, s.Shutdown(context.Background()))
+	client.AssertCalled(t, "Close")
+}
+
+func TestServer_Run_ListenError(t *testing.T) {
+	s, err := NewServer(new(MockKetoClient))
+	require.NoError(t, err)
+	assert.ErrorContains(t, s.Run("invalid-address"), "無法監聽")
+}
+
+func TestWithHTTPTimeouts(t *testing.T) {
+	s, err := NewServer(new(MockKetoClient))
+	require.NoError(t, err)
+	assert.Equal(t, DefaultHTTPTimeouts.ReadHeader, s.http.ReadHeaderTimeout)
+	assert.Equal(t, DefaultHTTPTimeouts.Write, s.http.WriteTimeout)
+
+	timeouts := HTTPTimeouts{ReadHeader: time.Second, Read: 2 * time.Second, Write: 3 * time.Second, Idle: 4 * time.Second}
+	s, err = NewServer(new(MockKetoClient), WithHTTPTimeouts(timeouts))
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, s.http.ReadHeaderTimeout)
+	assert.Equal(t, 2*time.Second, s.http.ReadTimeout)
+	assert.Equal(t, 3*time.Second, s.http.WriteTimeout)
+	assert.Equal(t, 4*time.Second, s.http.IdleTimeout)
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"time"
+
+	"github.com/AidChen0509/oosa_ketosdk/keto"
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader 請求 ID 的 HTTP 頭
+// 請求帶有此頭時沿用其值，否則生成新的 ID，並在響應中返回
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDKey gin context 中請求 ID 的鍵
+const requestIDKey = "request_id"
+
+// WithLogger 設置 API 服務器使用的結構化日誌
+// 每個請求以 info 級別記錄，5xx 響應以 error 級別記錄，默認使用 slog.Default()
+func WithLogger(logger *slog.Logger) ServerOption {
+	return func(o *serverOptions) {
+		o.logger = logger
+	}
+}
+
+// RequestID 返回當前請求的 ID
+func RequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+// requestIDMiddleware 為每個請求分配 ID，並傳遞給 Keto 客戶端的日誌
+func requestIDMiddleware(c *gin.Context) {
+	id := c.GetHeader(RequestIDHeader)
+	if id == "" || len(id) > 128 {
+		id = newRequestID()
+	}
+
+	c.Set(requestIDKey, id)
+	c.Header(RequestIDHeader, id)
+	c.Request = c.Request.WithContext(keto.ContextWithRequestID(c.Request.Context(), id))
+	c.Next()
+}
+
+// newRequestID 生成隨機的請求 ID
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// loggingMiddleware 返回記錄每個請求的中間件，取代 gin 默認的文本日誌
+func loggingMiddleware(logger *slog.Logger) gin.HandlerFunc {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+			slog.String("request_id", RequestID(c)),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.Any("errors", c.Errors.Errors()))
+		}
+
+		level := slog.LevelInfo
+		if status >= 500 {
+			level = slog.LevelError
+		}
+		logger.LogAttrs(c.Request.Context(), level, "HTTP 請求", attrs...)
+	}
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AidChen0509/oosa_ketosdk/keto"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// requestIDRecordingClient 記錄 CheckPermission 收到的請求 ID
+type requestIDRecordingClient struct {
+	*MockKetoClient
+	requestID string
+}
+
+func (c *requestIDRecordingClient) CheckPermission(ctx context.Context, namespace, object, relation, subject string) (bool, error) {
+	c.requestID = keto.RequestIDFromContext(ctx)
+	return true, nil
+}
+
+// 測試請求 ID 沿用請求頭並傳遞到 Keto 客戶端
+func TestRequestIDMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	client := &requestIDRecordingClient{MockKetoClient: new(MockKetoClient)}
+	server, err := NewServer(client, WithLogger(slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))))
+	require.NoError(t, err)
+
+	req, _ := http.NewRequest("GET", "/api/photos/check?namespace=Photo&object=photo1&relation=reference&subject=event1", nil)
+	req.Header.Set(RequestIDHeader, "req-42")
+	response := httptest.NewRecorder()
+	server.router.ServeHTTP(response, req)
+
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "req-42", response.Header().Get(RequestIDHeader))
+	assert.Equal(t, "req-42", client.requestID)
+
+	// 沒有請求頭時生成新的 ID
+	req, _ = http.NewRequest("GET", "/api/photos/check?namespace=Photo&object=photo1&relation=reference&subject=event1", nil)
+	response = httptest.NewRecorder()
+	server.router.ServeHTTP(response, req)
+
+	assert.Len(t, response.Header().Get(RequestIDHeader), 32)
+	assert.Equal(t, response.Header().Get(RequestIDHeader), client.requestID)
+}
+
+// 測試每個請求以結構化日誌記錄，失敗的請求包含錯誤
+func TestLoggingMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var buf bytes.Buffer
+	mockClient := new(MockKetoClient)
+	mockClient.On("GetPhotoEvents", "photo1").Return(map[string][]string(nil), errors.New("unavailable"))
+
+	server, err := NewServer(mockClient, WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))))
+	require.NoError(t, err)
+
+	req, _ := http.NewRequest("GET", "/api/photos/photo1/events", nil)
+	req.Header.Set(RequestIDHeader, "req-7")
+	response := httptest.NewRecorder()
+	server.router.ServeHTTP(response, req)
+
+	assert.Equal(t, http.StatusInternalServerError, response.Code)
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "ERROR", record["level"])
+	assert.Equal(t, "GET", record["method"])
+	assert.Equal(t, "/api/photos/photo1/events", record["path"])
+	assert.Equal(t, "/api/photos/:photoId/events", record["route"])
+	assert.Equal(t, float64(http.StatusInternalServerError), record["status"])
+	assert.Equal(t, "req-7", record["request_id"])
+	assert.Equal(t, []any{"unavailable"}, record["errors"])
+	mockClient.AssertExpectations(t)
+}
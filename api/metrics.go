@@ -0,0 +1,88 @@
+package api
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// MetricsPath 指標端點的路徑
+const MetricsPath = "/metrics"
+
+// WithMetrics 將 API 的 Prometheus 指標註冊到 reg，並在 /metrics 上提供 reg 中的所有指標
+// 將同一個 reg 傳給 keto.WithMetrics，即可在同一個端點上提供 Keto 客戶端的指標
+func WithMetrics(reg *prometheus.Registry) ServerOption {
+	return func(o *serverOptions) {
+		o.metricsRegistry = reg
+	}
+}
+
+// serverMetrics API 服務器的 Prometheus 指標
+type serverMetrics struct {
+	requests  *prometheus.CounterVec   // 請求數，按方法、路由和狀態碼分類
+	duration  *prometheus.HistogramVec // 請求延遲，按方法和路由分類
+	batchSize *prometheus.HistogramVec // 批量端點每個請求的關係數，按路由分類
+}
+
+// newServerMetrics 創建並註冊 API 指標
+func newServerMetrics(reg prometheus.Registerer) (*serverMetrics, error) {
+	m := &serverMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "HTTP 請求數",
+		}, []string{"method", "route", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "api",
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP 請求的處理時間 (秒)",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		batchSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "api",
+			Name:      "batch_size",
+			Help:      "批量端點每個請求包含的關係數",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+		}, []string{"route"}),
+	}
+
+	for _, c := range []prometheus.Collector{m.requests, m.duration, m.batchSize} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// middleware 記錄每個請求的狀態碼和處理時間
+// 未匹配任何路由的請求以 "unmatched" 作為路由標籤，避免標籤數量無限增長
+func (m *serverMetrics) middleware(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	m.requests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+	m.duration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+}
+
+// observeBatchSize 記錄批量請求的大小
+// 未啟用指標時不做任何事
+func (m *serverMetrics) observeBatchSize(c *gin.Context, size int) {
+	if m == nil {
+		return
+	}
+	m.batchSize.WithLabelValues(c.FullPath()).Observe(float64(size))
+}
+
+// metricsHandler 返回提供 reg 中所有指標的處理函數
+func metricsHandler(reg *prometheus.Registry) gin.HandlerFunc {
+	return gin.WrapH(promhttp.HandlerFor(reg, promhttp.HandlerOpts{Registry: reg}))
+}
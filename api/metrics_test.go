@@ -0,0 +1,79 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// 測試啟用指標後 /metrics 提供請求數、延遲和批量大小
+func TestServerMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockClient := new(MockKetoClient)
+	mockClient.On("BatchCreatePhotoEventReferences", mock.Anything).Return(nil)
+
+	reg := prometheus.NewRegistry()
+	server, err := NewServer(mockClient, WithMetrics(reg))
+	require.NoError(t, err)
+
+	jsonBody, _ := json.Marshal(BatchPhotoEventReferenceRequest{
+		Relations: []PhotoEventRelation{
+			{PhotoID: "photo1", EventID: "event1"},
+			{PhotoID: "photo2", EventID: "event1"},
+			{PhotoID: "photo3", EventID: "event1"},
+		},
+	})
+	req, _ := http.NewRequest("POST", "/api/photos/reference/batch", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	server.router.ServeHTTP(httptest.NewRecorder(), req)
+
+	req, _ = http.NewRequest("GET", "/api/photos/check?namespace=Photo", nil)
+	server.router.ServeHTTP(httptest.NewRecorder(), req)
+
+	req, _ = http.NewRequest("GET", MetricsPath, nil)
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	body := recorder.Body.String()
+	assert.Contains(t, body, `api_http_requests_total{method="POST",route="/api/photos/reference/batch",status="200"} 1`)
+	assert.Contains(t, body, `api_http_requests_total{method="GET",route="/api/photos/check",status="400"} 1`)
+	assert.Contains(t, body, `api_http_request_duration_seconds_count{method="POST",route="/api/photos/reference/batch"} 1`)
+	assert.Contains(t, body, `api_batch_size_sum{route="/api/photos/reference/batch"} 3`)
+	mockClient.AssertExpectations(t)
+}
+
+// 測試同一個 Registry 不能註冊兩個服務器
+func TestServerMetrics_DuplicateRegistration(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	reg := prometheus.NewRegistry()
+
+	_, err := NewServer(new(MockKetoClient), WithMetrics(reg))
+	require.NoError(t, err)
+
+	_, err = NewServer(new(MockKetoClient), WithMetrics(reg))
+	assert.Error(t, err)
+}
+
+// 測試未啟用指標時沒有 /metrics 端點
+func TestServerMetrics_Disabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	server, err := NewServer(new(MockKetoClient))
+	require.NoError(t, err)
+
+	req, _ := http.NewRequest("GET", MetricsPath, nil)
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
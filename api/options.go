@@ -0,0 +1,60 @@
+package api
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/AidChen0509/oosa_ketosdk/keto"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName 服務名稱，用於追蹤
+const ServiceName = "oosa-ketosdk"
+
+// ServerOption 配置 Server 的選項
+type ServerOption func(*serverOptions)
+
+// serverOptions NewServer 使用的配置
+type serverOptions struct {
+	tracerProvider trace.TracerProvider
+	propagator     propagation.TextMapPropagator
+
+	metricsRegistry *prometheus.Registry
+
+	logger *slog.Logger
+
+	actor       ActorFunc
+	middlewares []gin.HandlerFunc
+
+	httpTimeouts     HTTPTimeouts
+	ketoCloseTimeout time.Duration
+
+	ids keto.IDValidator
+}
+
+// WithTracerProvider 設置請求追蹤使用的 OpenTelemetry TracerProvider
+// 默認使用全局的 otel.GetTracerProvider()
+func WithTracerProvider(tp trace.TracerProvider) ServerOption {
+	return func(o *serverOptions) {
+		o.tracerProvider = tp
+	}
+}
+
+// WithPropagator 設置從請求中提取追蹤上下文的格式
+// 默認使用 W3C Trace Context (traceparent/tracestate)
+func WithPropagator(p propagation.TextMapPropagator) ServerOption {
+	return func(o *serverOptions) {
+		o.propagator = p
+	}
+}
+
+// WithMiddleware 在所有 API 路由之前添加中間件，例如認證中間件
+// 中間件在請求 ID、日誌和追蹤之後、確定審計調用者之前執行
+func WithMiddleware(handlers ...gin.HandlerFunc) ServerOption {
+	return func(o *serverOptions) {
+		o.middlewares = append(o.middlewares, handlers...)
+	}
+}
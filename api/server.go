@@ -1,44 +1,79 @@
 package api
 
 import (
+	"context"
+	"net/http"
+	"time"
+
 	"github.com/AidChen0509/oosa_ketosdk/keto"
 	"github.com/gin-gonic/gin"
 )
 
 // KetoClientInterface 定義 Keto 客戶端接口
 type KetoClientInterface interface {
-	CreatePhotoEventReference(photoID, eventID string) error
-	CreatePhotoEventPolaroid(photoID, eventID string) error
-	CheckPermission(namespace, object, relation, subject string) (bool, error)
-	BatchCreatePhotoEventReferences(relations []keto.PhotoEventRelation) error
-	BatchCreatePhotoEventPolaroids(relations []keto.PhotoEventRelation) error
-	GetEventReferencePhotos(eventID string) ([]string, error)
-	GetEventPolaroidPhotos(eventID string) ([]string, error)
-	GetPhotoEvents(photoID string) (map[string][]string, error)
-	DeletePhotoEventRelation(photoID, eventID, relationType string) error
-	Close()
+	CreatePhotoEventReference(ctx context.Context, photoID, eventID string) error
+	CreatePhotoEventPolaroid(ctx context.Context, photoID, eventID string) error
+	CheckPermission(ctx context.Context, namespace, object, relation, subject string) (bool, error)
+	BatchCreatePhotoEventReferences(ctx context.Context, relations []keto.PhotoEventRelation) error
+	BatchCreatePhotoEventPolaroids(ctx context.Context, relations []keto.PhotoEventRelation) error
+	GetEventReferencePhotos(ctx context.Context, eventID string) ([]string, error)
+	GetEventPolaroidPhotos(ctx context.Context, eventID string) ([]string, error)
+	GetPhotoEvents(ctx context.Context, photoID string) (map[string][]string, error)
+	DeletePhotoEventRelation(ctx context.Context, photoID, eventID, relationType string) error
+	Close(ctx context.Context) error
 }
 
 // Server 封裝 API 服務器
 type Server struct {
 	router     *gin.Engine
 	ketoClient KetoClientInterface
+	metrics    *serverMetrics
+	http       *http.Server
+	requests   *requestValidator
+
+	ketoCloseTimeout time.Duration
 }
 
 // NewServer 創建新的 API 服務器
-func NewServer(ketoClient KetoClientInterface) *Server {
-	router := gin.Default()
+// 服務器擁有 ketoClient 的生命週期：Shutdown 會關閉它，調用方不應再調用 ketoClient.Close；
+// 只有 NewServer 返回錯誤時才由調用方關閉
+// 如請求驗證器或指標註冊失敗則返回錯誤
+func NewServer(ketoClient KetoClientInterface, opts ...ServerOption) (*Server, error) {
+	o := serverOptions{httpTimeouts: DefaultHTTPTimeouts, ketoCloseTimeout: DefaultKetoCloseTimeout}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	requests, err := newRequestValidator(o.ids)
+	if err != nil {
+		return nil, err
+	}
+
+	router := gin.New()
+	router.Use(gin.Recovery(), requestIDMiddleware, loggingMiddleware(o.logger), tracingMiddleware(&o))
 	server := &Server{
 		router:     router,
 		ketoClient: ketoClient,
+		http:       newHTTPServer(router, o.httpTimeouts),
+		requests:   requests,
+
+		ketoCloseTimeout: o.ketoCloseTimeout,
 	}
-	server.setupRoutes()
-	return server
-}
 
-// Run 啟動 API 服務器
-func (s *Server) Run(addr string) error {
-	return s.router.Run(addr)
+	if o.metricsRegistry != nil {
+		metrics, err := newServerMetrics(o.metricsRegistry)
+		if err != nil {
+			return nil, err
+		}
+		server.metrics = metrics
+		router.Use(metrics.middleware)
+		router.GET(MetricsPath, metricsHandler(o.metricsRegistry))
+	}
+
+	router.Use(o.middlewares...)
+	router.Use(actorMiddleware(o.actor))
+	server.setupRoutes()
+	return server, nil
 }
 
 // SetupRouter 設置所有路由並返回 gin.Engine
@@ -0,0 +1,22 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// tracingMiddleware 為每個請求創建 OpenTelemetry span
+// span 保存在 c.Request.Context() 中，處理函數將其傳給 Keto 客戶端以串聯追蹤
+func tracingMiddleware(o *serverOptions) gin.HandlerFunc {
+	propagator := o.propagator
+	if propagator == nil {
+		propagator = propagation.TraceContext{}
+	}
+
+	opts := []otelgin.Option{otelgin.WithPropagators(propagator)}
+	if o.tracerProvider != nil {
+		opts = append(opts, otelgin.WithTracerProvider(o.tracerProvider))
+	}
+	return otelgin.Middleware(ServiceName, opts...)
+}
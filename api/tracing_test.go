@@ -0,0 +1,54 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// spanRecordingClient 記錄 CheckPermission 收到的 span
+type spanRecordingClient struct {
+	*MockKetoClient
+	spanContext trace.SpanContext
+}
+
+func (c *spanRecordingClient) CheckPermission(ctx context.Context, namespace, object, relation, subject string) (bool, error) {
+	c.spanContext = trace.SpanContextFromContext(ctx)
+	return true, nil
+}
+
+// 測試請求的 span 傳遞到 Keto 客戶端，並延續上游的追蹤
+func TestTracingMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	client := &spanRecordingClient{MockKetoClient: new(MockKetoClient)}
+
+	server := &Server{router: gin.New(), ketoClient: client, requests: newTestRequestValidator()}
+	server.router.Use(tracingMiddleware(&serverOptions{tracerProvider: tp}))
+	server.router.GET("/api/photos/check", server.checkPermission)
+
+	req, _ := http.NewRequest("GET", "/api/photos/check?namespace=Photo&object=photo1&relation=reference&subject=event1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	response := httptest.NewRecorder()
+
+	server.router.ServeHTTP(response, req)
+
+	assert.Equal(t, http.StatusOK, response.Code)
+	require.Len(t, recorder.Ended(), 1)
+
+	span := recorder.Ended()[0]
+	assert.Equal(t, "/api/photos/check", span.Name())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+	assert.Equal(t, span.SpanContext().SpanID(), client.spanContext.SpanID())
+}
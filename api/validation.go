@@ -0,0 +1,134 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/AidChen0509/oosa_ketosdk/keto"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// 請求綁定中使用的驗證標籤
+const (
+	ketoIDTag   = "ketoid"   // 對象和主體 ID，使用 WithIDValidator 設置的規則
+	ketoNameTag = "ketoname" // 命名空間和關係，與 keto.Client 相同使用默認規則
+)
+
+// WithIDValidator 設置驗證請求中對象和主體 ID 的規則
+// 應與傳給 keto.WithIDValidator 的規則相同，使無效的 ID 在 API 層就返回 400；默認為 keto.IDValidator{}
+func WithIDValidator(v keto.IDValidator) ServerOption {
+	return func(o *serverOptions) {
+		o.ids = v
+	}
+}
+
+// requestValidator 綁定並驗證請求
+// 每個 Server 使用自己的驗證器，不修改 gin 全局的 binding.Validator
+type requestValidator struct {
+	validate *validator.Validate
+	ids      keto.IDValidator
+}
+
+// newRequestValidator 創建使用 ids 驗證 ID 的請求驗證器
+func newRequestValidator(ids keto.IDValidator) (*requestValidator, error) {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.SetTagName("binding")
+	// 錯誤信息中使用 JSON 或查詢參數的字段名稱
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "form"} {
+			if name, _, _ := strings.Cut(field.Tag.Get(tag), ","); name != "" && name != "-" {
+				return name
+			}
+		}
+		return field.Name
+	})
+
+	r := &requestValidator{validate: v, ids: ids}
+	for _, tag := range []string{ketoIDTag, ketoNameTag} {
+		ids := r.idValidator(tag)
+		err := v.RegisterValidation(tag, func(fl validator.FieldLevel) bool {
+			return ids.Validate(fl.FieldName(), fl.Field().String()) == nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("api: 無法註冊驗證標籤 %s: %w", tag, err)
+		}
+	}
+	return r, nil
+}
+
+// idValidator 返回驗證標籤使用的 ID 規則
+func (r *requestValidator) idValidator(tag string) keto.IDValidator {
+	if tag == ketoNameTag {
+		return keto.IDValidator{}
+	}
+	return r.ids
+}
+
+// bindJSON 解析 JSON 請求體並驗證
+func (r *requestValidator) bindJSON(c *gin.Context, obj any) error {
+	if c.Request.Body == nil {
+		return errors.New("請求體不能為空")
+	}
+	if err := json.NewDecoder(c.Request.Body).Decode(obj); err != nil {
+		return err
+	}
+	return r.validate.Struct(obj)
+}
+
+// bindQuery 解析查詢參數並驗證
+func (r *requestValidator) bindQuery(c *gin.Context, obj any) error {
+	if err := binding.MapFormWithTag(obj, c.Request.URL.Query(), "form"); err != nil {
+		return err
+	}
+	return r.validate.Struct(obj)
+}
+
+// errorMessage 將請求綁定錯誤轉換為易讀的錯誤信息
+func (r *requestValidator) errorMessage(err error) string {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return err.Error()
+	}
+
+	messages := make([]string, 0, len(verrs))
+	for _, fe := range verrs {
+		field := fieldPath(fe)
+		switch fe.Tag() {
+		case "required":
+			messages = append(messages, field+" 不能為空")
+		case "min":
+			messages = append(messages, field+" 至少需要 "+fe.Param()+" 項")
+		case ketoIDTag, ketoNameTag:
+			messages = append(messages, r.idValidator(fe.Tag()).Validate(field, fe.Value().(string)).Error())
+		default:
+			messages = append(messages, fe.Error())
+		}
+	}
+	return strings.Join(messages, "; ")
+}
+
+// fieldPath 返回不含請求類型名稱的字段路徑，例如 relations[0].photo_id
+func fieldPath(fe validator.FieldError) string {
+	if _, path, ok := strings.Cut(fe.Namespace(), "."); ok {
+		return path
+	}
+	return fe.Field()
+}
+
+// errorStatus 返回 Keto 客戶端錯誤對應的 HTTP 狀態碼
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, keto.ErrInvalidID):
+		return http.StatusBadRequest
+	case errors.Is(err, keto.ErrDeadlineExceeded):
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
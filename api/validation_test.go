@@ -0,0 +1,156 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/AidChen0509/oosa_ketosdk/keto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// 測試請求綁定拒絕無效的 ID
+func TestRequestBinding_RejectsInvalidIDs(t *testing.T) {
+	server, mockClient := setupTestServer()
+
+	photos := server.router.Group("/api/photos")
+	photos.POST("/reference", server.createPhotoEventReference)
+	photos.POST("/reference/batch", server.batchCreatePhotoEventReferences)
+	photos.GET("/check", server.checkPermission)
+
+	t.Run("ReservedCharacter", func(t *testing.T) {
+		jsonBody, _ := json.Marshal(PhotoEventReferenceRequest{PhotoID: "photo:1", EventID: "event1"})
+		req, _ := http.NewRequest("POST", "/api/photos/reference", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+
+		server.router.ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		var response map[string]string
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Contains(t, response["error"], "photo_id 不能包含保留字符 ':'")
+	})
+
+	t.Run("BatchItem", func(t *testing.T) {
+		jsonBody, _ := json.Marshal(BatchPhotoEventReferenceRequest{
+			Relations: []PhotoEventRelation{
+				{PhotoID: "photo1", EventID: "event1"},
+				{PhotoID: "photo2", EventID: "event 1"},
+			},
+		})
+		req, _ := http.NewRequest("POST", "/api/photos/reference/batch", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+
+		server.router.ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		var response map[string]string
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Contains(t, response["error"], "relations[1].event_id 不能包含空白字符")
+	})
+
+	t.Run("QueryParameter", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/photos/check?namespace=Photo&object=photo1&relation=reference&subject=a%23b", nil)
+		recorder := httptest.NewRecorder()
+
+		server.router.ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		var response map[string]string
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Contains(t, response["error"], "subject 不能包含保留字符 '#'")
+	})
+
+	t.Run("MissingField", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/photos/check?namespace=Photo&object=photo1&relation=reference", nil)
+		recorder := httptest.NewRecorder()
+
+		server.router.ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		var response map[string]string
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, "subject 不能為空", response["error"])
+	})
+
+	mockClient.AssertNotCalled(t, "CreatePhotoEventReference", mock.Anything, mock.Anything)
+	mockClient.AssertNotCalled(t, "BatchCreatePhotoEventReferences", mock.Anything)
+	mockClient.AssertNotCalled(t, "CheckPermission", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// 測試客戶端返回的 ID 驗證錯誤轉換為 400
+func TestInvalidIDFromClient_ReturnsBadRequest(t *testing.T) {
+	server, mockClient := setupTestServer()
+
+	invalid := fmt.Errorf("%w: event_id 不符合格式", keto.ErrInvalidID)
+	mockClient.On("GetEventReferencePhotos", "event1").Return([]string(nil), invalid)
+
+	events := server.router.Group("/api/events")
+	events.GET("/:eventId/photos/reference", server.getEventReferencePhotos)
+
+	req, _ := http.NewRequest("GET", "/api/events/event1/photos/reference", nil)
+	recorder := httptest.NewRecorder()
+
+	server.router.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	mockClient.AssertExpectations(t)
+}
+
+// 測試客戶端返回的超時錯誤轉換為 504
+func TestTimeoutFromClient_ReturnsGatewayTimeout(t *testing.T) {
+	server, mockClient := setupTestServer()
+
+	timeout := &keto.TimeoutError{Op: "check", Timeout: time.Second, Err: context.DeadlineExceeded}
+	mockClient.On("CheckPermission", "Photo", "photo1", "reference", "event1").Return(false, timeout)
+
+	server.router.GET("/api/photos/check", server.checkPermission)
+
+	req, _ := http.NewRequest("GET", "/api/photos/check?namespace=Photo&object=photo1&relation=reference&subject=event1", nil)
+	recorder := httptest.NewRecorder()
+
+	server.router.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusGatewayTimeout, recorder.Code)
+	mockClient.AssertExpectations(t)
+}
+
+// 測試 WithIDValidator 只影響設置它的服務器
+func TestWithIDValidator(t *testing.T) {
+	const id = "0b5c3c9e-7f0a-4b8e-9d2a-3c1f5e6a7b8c"
+	check := func(s *Server, object string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/api/photos/check?namespace=Photo&object="+object+"&relation=reference&subject="+id, nil)
+		recorder := httptest.NewRecorder()
+		s.router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	strictClient := new(MockKetoClient)
+	strictClient.On("CheckPermission", "Photo", id, "reference", id).Return(true, nil)
+	strict, err := NewServer(strictClient, WithIDValidator(keto.IDValidator{Pattern: keto.UUIDPattern}))
+	assert.NoError(t, err)
+
+	defaultClient := new(MockKetoClient)
+	defaultClient.On("CheckPermission", "Photo", "photo1", "reference", id).Return(true, nil)
+	lenient, err := NewServer(defaultClient)
+	assert.NoError(t, err)
+
+	// 命名空間和關係不受 ID 格式限制
+	assert.Equal(t, http.StatusOK, check(strict, id).Code)
+
+	recorder := check(strict, "photo1")
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "object")
+	strictClient.AssertNumberOfCalls(t, "CheckPermission", 1)
+
+	assert.Equal(t, http.StatusOK, check(lenient, "photo1").Code)
+	defaultClient.AssertExpectations(t)
+}
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// 支持的操作
+const (
+	opCreate = "create"
+	opList   = "list"
+	opCheck  = "check"
+	opDelete = "delete"
+)
+
+// operations 按報告順序排列的操作
+var operations = []string{opCreate, opList, opCheck, opDelete}
+
+// target 壓測的對象
+type target interface {
+	create(ctx context.Context, photoID, eventID string) error
+	list(ctx context.Context, eventID string) error
+	check(ctx context.Context, photoID, eventID string) error
+	delete(ctx context.Context, photoID, eventID string) error
+}
+
+// mix 各操作的權重
+type mix map[string]int
+
+// parseMix 解析 "create=1,list=2,check=6,delete=1" 格式的權重
+func parseMix(s string) (mix, error) {
+	m := mix{}
+	for _, part := range strings.Split(s, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("無效的操作權重 %q，格式應為 操作=權重", part)
+		}
+		if !slices.Contains(operations, name) {
+			return nil, fmt.Errorf("未知的操作 %q，可用: %s", name, strings.Join(operations, "、"))
+		}
+		weight, err := strconv.Atoi(value)
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("操作 %s 的權重 %q 無效", name, value)
+		}
+		m[name] = weight
+	}
+
+	total := 0
+	for _, weight := range m {
+		total += weight
+	}
+	if total == 0 {
+		return nil, errors.New("操作權重總和必須大於 0")
+	}
+	return m, nil
+}
+
+// pick 按權重隨機選擇操作
+func (m mix) pick(r *rand.Rand) string {
+	total := 0
+	for _, op := range operations {
+		total += m[op]
+	}
+	n := r.IntN(total)
+	for _, op := range operations {
+		if n < m[op] {
+			return op
+		}
+		n -= m[op]
+	}
+	panic("unreachable")
+}
+
+// config 壓測的參數
+type config struct {
+	mix         mix
+	concurrency int
+	duration    time.Duration
+	timeout     time.Duration // 單次操作的超時
+	photos      int           // 使用的照片 ID 數量
+	events      int           // 使用的事件 ID 數量
+}
+
+// photoID 和 eventID 生成壓測使用的 ID
+func photoID(i int) string { return "bench-photo-" + strconv.Itoa(i) }
+func eventID(i int) string { return "bench-event-" + strconv.Itoa(i) }
+
+// 直方圖的精度：每個 2 的冪區間分為 histSubBuckets 個桶，相對誤差不超過 1/histSubBuckets
+const (
+	histSubBits    = 6
+	histSubBuckets = 1 << histSubBits
+	histBuckets    = (64 - histSubBits) * histSubBuckets
+)
+
+// histogram 延遲的對數線性直方圖
+// 內存用量固定，與記錄的次數無關，長時間壓測也不會持續佔用內存
+type histogram struct {
+	counts [histBuckets]uint64
+	count  int
+	max    time.Duration
+}
+
+// histBucket 返回延遲所在的桶
+func histBucket(d time.Duration) int {
+	v := uint64(max(d, 0))
+	if v < histSubBuckets {
+		return int(v)
+	}
+	shift := bits.Len64(v) - histSubBits - 1
+	return (shift+1)*histSubBuckets + int(v>>shift) - histSubBuckets
+}
+
+// histUpper 返回桶內的最大延遲
+func histUpper(bucket int) time.Duration {
+	if bucket < histSubBuckets {
+		return time.Duration(bucket)
+	}
+	shift := bucket/histSubBuckets - 1
+	sub := uint64(bucket%histSubBuckets + histSubBuckets)
+	return time.Duration((sub+1)<<shift - 1)
+}
+
+// record 記錄一次延遲
+func (h *histogram) record(d time.Duration) {
+	h.counts[histBucket(d)]++
+	h.count++
+	h.max = max(h.max, d)
+}
+
+// merge 合併另一個直方圖
+func (h *histogram) merge(other *histogram) {
+	for i, n := range other.counts {
+		h.counts[i] += n
+	}
+	h.count += other.count
+	h.max = max(h.max, other.max)
+}
+
+// percentile 返回第 p 百分位數 (nearest-rank)，結果為所在桶的上界，不超過最大延遲
+func (h *histogram) percentile(p float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	rank := max(1, min(uint64(p/100*float64(h.count)+0.999999), uint64(h.count)))
+	var seen uint64
+	for i, n := range h.counts {
+		if seen += n; seen >= rank {
+			return min(histUpper(i), h.max)
+		}
+	}
+	return h.max
+}
+
+// opStats 一種操作的結果
+type opStats struct {
+	latencies histogram
+	errors    int
+	lastErr   error
+}
+
+// result 一次壓測的結果
+type result struct {
+	elapsed time.Duration
+	ops     map[string]*opStats
+}
+
+// benchmark 以 cfg.concurrency 個 worker 對 t 施壓，直到 cfg.duration 結束或 ctx 取消
+// 結束時已開始的操作會完成並計入結果
+func benchmark(ctx context.Context, t target, cfg config) *result {
+	ctx, cancel := context.WithTimeout(ctx, cfg.duration)
+	defer cancel()
+
+	workers := make([]map[string]*opStats, cfg.concurrency)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for w := range workers {
+		stats := map[string]*opStats{}
+		workers[w] = stats
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := rand.New(rand.NewPCG(uint64(start.UnixNano()), uint64(w)))
+			for ctx.Err() == nil {
+				op := cfg.mix.pick(r)
+				photo, event := photoID(r.IntN(cfg.photos)), eventID(r.IntN(cfg.events))
+
+				s := stats[op]
+				if s == nil {
+					s = &opStats{}
+					stats[op] = s
+				}
+				began := time.Now()
+				err := execute(t, op, photo, event, cfg.timeout)
+				s.latencies.record(time.Since(began))
+				if err != nil {
+					s.errors++
+					s.lastErr = err
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	res := &result{elapsed: time.Since(start), ops: map[string]*opStats{}}
+	for _, stats := range workers {
+		for op, s := range stats {
+			merged := res.ops[op]
+			if merged == nil {
+				merged = &opStats{}
+				res.ops[op] = merged
+			}
+			merged.latencies.merge(&s.latencies)
+			merged.errors += s.errors
+			if s.lastErr != nil {
+				merged.lastErr = s.lastErr
+			}
+		}
+	}
+	return res
+}
+
+// execute 執行一次操作，不受壓測時長的 context 影響
+func execute(t target, op, photo, event string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	switch op {
+	case opCreate:
+		return t.create(ctx, photo, event)
+	case opList:
+		return t.list(ctx, event)
+	case opCheck:
+		return t.check(ctx, photo, event)
+	default:
+		return t.delete(ctx, photo, event)
+	}
+}
+
+// prefill 在壓測前寫入 n 個隨機關係，使 list 和 check 有數據可讀
+func prefill(t target, cfg config, n int) error {
+	r := rand.New(rand.NewPCG(1, 2))
+	for range n {
+		photo, event := photoID(r.IntN(cfg.photos)), eventID(r.IntN(cfg.events))
+		if err := execute(t, opCreate, photo, event, cfg.timeout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// summary 一種操作或全部操作的統計
+type summary struct {
+	Operation  string  `json:"operation"`
+	Count      int     `json:"count"`
+	Errors     int     `json:"errors"`
+	Throughput float64 `json:"throughput"` // 每秒操作數
+	P50        float64 `json:"p50_ms"`
+	P90        float64 `json:"p90_ms"`
+	P99        float64 `json:"p99_ms"`
+	Max        float64 `json:"max_ms"`
+	LastError  string  `json:"last_error,omitempty"`
+}
+
+// summaries 返回每種操作和全部操作 (total) 的統計
+func (r *result) summaries() []summary {
+	var all histogram
+	total := summary{Operation: "total"}
+	var out []summary
+	for _, op := range operations {
+		s := r.ops[op]
+		if s == nil {
+			continue
+		}
+		sum := r.summarize(op, &s.latencies, s.errors)
+		if s.lastErr != nil {
+			sum.LastError = s.lastErr.Error()
+		}
+		out = append(out, sum)
+		all.merge(&s.latencies)
+		total.Errors += s.errors
+	}
+	total = r.summarize(total.Operation, &all, total.Errors)
+	return append(out, total)
+}
+
+// summarize 計算一組延遲的統計
+func (r *result) summarize(op string, latencies *histogram, errors int) summary {
+	ms := func(d time.Duration) float64 { return float64(d.Microseconds()) / 1000 }
+	s := summary{Operation: op, Count: latencies.count, Errors: errors}
+	if r.elapsed > 0 {
+		s.Throughput = float64(latencies.count) / r.elapsed.Seconds()
+	}
+	if latencies.count > 0 {
+		s.P50 = ms(latencies.percentile(50))
+		s.P90 = ms(latencies.percentile(90))
+		s.P99 = ms(latencies.percentile(99))
+		s.Max = ms(latencies.max)
+	}
+	return s
+}
+
+// writeText 以表格輸出結果，表頭使用 ASCII 以便 tabwriter 對齊
+func writeText(w io.Writer, r *result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "op\tcount\terrors\tops/s\tp50(ms)\tp90(ms)\tp99(ms)\tmax(ms)\t\n")
+	var lastErrors []string
+	for _, s := range r.summaries() {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f\t%.2f\t%.2f\t%.2f\t%.2f\t\n",
+			s.Operation, s.Count, s.Errors, s.Throughput, s.P50, s.P90, s.P99, s.Max)
+		if s.LastError != "" {
+			lastErrors = append(lastErrors, fmt.Sprintf("%s 最後的錯誤: %s", s.Operation, s.LastError))
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "\n耗時 %s\n", r.elapsed.Round(time.Millisecond))
+	for _, line := range lastErrors {
+		fmt.Fprintln(w, line)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingTarget 記錄每種操作的調用次數，check 總是失敗
+type countingTarget struct {
+	creates, lists, checks, deletes atomic.Int64
+}
+
+func (t *countingTarget) create(context.Context, string, string) error { t.creates.Add(1); return nil }
+func (t *countingTarget) list(context.Context, string) error           { t.lists.Add(1); return nil }
+func (t *countingTarget) check(context.Context, string, string) error {
+	t.checks.Add(1)
+	return errors.New("denied")
+}
+func (t *countingTarget) delete(context.Context, string, string) error { t.deletes.Add(1); return nil }
+
+func TestParseMix(t *testing.T) {
+	m, err := parseMix("create=1, check=3")
+	require.NoError(t, err)
+	assert.Equal(t, mix{opCreate: 1, opCheck: 3}, m)
+
+	for _, invalid := range []string{"create", "update=1", "check=-1", "check=x", "check=0"} {
+		_, err := parseMix(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestMix_Pick(t *testing.T) {
+	m := mix{opList: 1, opCheck: 3}
+	r := rand.New(rand.NewPCG(1, 2))
+	counts := map[string]int{}
+	for range 4000 {
+		counts[m.pick(r)]++
+	}
+	assert.Len(t, counts, 2)
+	assert.InDelta(t, 3000, counts[opCheck], 150)
+}
+
+func TestHistogram(t *testing.T) {
+	var h histogram
+	assert.Zero(t, h.percentile(50))
+
+	for i := 1; i <= 100; i++ {
+		h.record(time.Duration(i) * time.Millisecond)
+	}
+	assert.Equal(t, 100, h.count)
+	assert.InEpsilon(t, float64(50*time.Millisecond), float64(h.percentile(50)), 1.0/histSubBuckets)
+	assert.InEpsilon(t, float64(99*time.Millisecond), float64(h.percentile(99)), 1.0/histSubBuckets)
+	assert.Equal(t, 100*time.Millisecond, h.percentile(100), "最大值是精確的")
+	assert.InEpsilon(t, float64(time.Millisecond), float64(h.percentile(0)), 1.0/histSubBuckets)
+
+	// 小於 histSubBuckets 納秒的延遲是精確的，合併後保留所有記錄
+	var small histogram
+	for i := range histSubBuckets {
+		small.record(time.Duration(i))
+	}
+	assert.Equal(t, time.Duration(31), small.percentile(50))
+	h.merge(&small)
+	assert.Equal(t, 100+histSubBuckets, h.count)
+	assert.Equal(t, 100*time.Millisecond, h.max)
+}
+
+func TestHistogram_Buckets(t *testing.T) {
+	// 每個延遲都不超過所在桶的上界，且大於前一個桶的上界
+	for _, d := range []time.Duration{0, 63, 64, 127, 128, 1000, time.Millisecond, time.Hour, 1<<63 - 1} {
+		b := histBucket(d)
+		require.Less(t, b, histBuckets, d)
+		assert.LessOrEqual(t, d, histUpper(b), d)
+		if b > 0 {
+			assert.Greater(t, d, histUpper(b-1), d)
+		}
+	}
+}
+
+func TestBenchmark(t *testing.T) {
+	target := &countingTarget{}
+	cfg := config{
+		mix:         mix{opCreate: 1, opList: 1, opCheck: 1, opDelete: 1},
+		concurrency: 4,
+		duration:    50 * time.Millisecond,
+		timeout:     time.Second,
+		photos:      10,
+		events:      2,
+	}
+	res := benchmark(context.Background(), target, cfg)
+
+	summaries := res.summaries()
+	require.Len(t, summaries, 5)
+	total := summaries[len(summaries)-1]
+	assert.Equal(t, "total", total.Operation)
+	assert.EqualValues(t, target.creates.Load()+target.lists.Load()+target.checks.Load()+target.deletes.Load(), total.Count)
+	assert.EqualValues(t, target.checks.Load(), total.Errors)
+	assert.Positive(t, total.Throughput)
+
+	var out strings.Builder
+	require.NoError(t, writeText(&out, res))
+	assert.Contains(t, out.String(), "check 最後的錯誤: denied")
+}
@@ -0,0 +1,142 @@
+// ketobench 對 Keto 或 REST API 服務器施加負載，並報告吞吐量和延遲百分位數
+//
+// 用法:
+//
+//	go run ./cmd/ketobench -target keto -mix create=1,list=2,check=6,delete=1 -concurrency 32 -duration 30s
+//	go run ./cmd/ketobench -target api -api-url http://127.0.0.1:8080
+//	go run ./cmd/ketobench -standalone
+//
+// 壓測使用 bench-photo-N 和 bench-event-N 格式的 ID，建議在專用的 Keto 或租戶上運行
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/AidChen0509/oosa_ketosdk/keto"
+	"github.com/AidChen0509/oosa_ketosdk/keto/embedded"
+)
+
+// 壓測對象
+const (
+	targetKeto = "keto"
+	targetAPI  = "api"
+)
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run 解析參數並執行壓測，返回前關閉創建的客戶端
+func run() error {
+	targetName := flag.String("target", targetKeto, "壓測對象: keto (通過 keto.Client) 或 api (REST API 服務器)")
+	writeAddr := flag.String("keto-write", "127.0.0.1:4467", "Keto 寫入地址")
+	readAddr := flag.String("keto-read", "127.0.0.1:4466", "Keto 讀取地址")
+	transport := flag.String("keto-transport", string(keto.TransportGRPC), "與 Keto 通信的方式: grpc 或 rest")
+	tenant := flag.String("tenant", "", "使用此租戶隔離壓測數據，為空時不使用租戶")
+	standalone := flag.Bool("standalone", false, "使用內嵌的內存元組存儲代替 Keto，用於測量 SDK 本身的開銷")
+	apiURL := flag.String("api-url", "http://127.0.0.1:8080", "target 為 api 時的服務器地址")
+	mixFlag := flag.String("mix", "create=1,list=2,check=6,delete=1", "各操作的權重")
+	concurrency := flag.Int("concurrency", 16, "並發的 worker 數")
+	duration := flag.Duration("duration", 30*time.Second, "壓測時長")
+	timeout := flag.Duration("timeout", 5*time.Second, "單次操作的超時")
+	photos := flag.Int("photos", 1000, "使用的照片 ID 數量")
+	events := flag.Int("events", 100, "使用的事件 ID 數量")
+	prefillCount := flag.Int("prefill", 0, "壓測前寫入的關係數量")
+	jsonOutput := flag.Bool("json", false, "以 JSON 輸出結果")
+	flag.Parse()
+
+	m, err := parseMix(*mixFlag)
+	if err != nil {
+		return fmt.Errorf("無效的 -mix: %w", err)
+	}
+	if *concurrency < 1 || *duration <= 0 || *timeout <= 0 || *photos < 1 || *events < 1 {
+		return errors.New("-concurrency、-photos 和 -events 必須大於 0，-duration 和 -timeout 必須為正數")
+	}
+	cfg := config{mix: m, concurrency: *concurrency, duration: *duration, timeout: *timeout, photos: *photos, events: *events}
+
+	var t target
+	switch *targetName {
+	case targetKeto:
+		client, closeClient, err := newKetoClient(*writeAddr, *readAddr, keto.Transport(*transport), *standalone)
+		if err != nil {
+			return fmt.Errorf("無法創建 Keto 客戶端: %w", err)
+		}
+		defer closeClient()
+		if *tenant != "" {
+			if client, err = client.ForTenant(*tenant); err != nil {
+				return fmt.Errorf("無效的 -tenant: %w", err)
+			}
+		}
+		t = ketoTarget{client: client}
+	case targetAPI:
+		t = apiTarget{base: *apiURL, client: &http.Client{Transport: &http.Transport{MaxIdleConnsPerHost: *concurrency}}}
+	default:
+		return fmt.Errorf("未知的 -target %q，可用: %s、%s", *targetName, targetKeto, targetAPI)
+	}
+
+	if *prefillCount > 0 {
+		log.Printf("寫入 %d 個關係", *prefillCount)
+		if err := prefill(t, cfg, *prefillCount); err != nil {
+			return fmt.Errorf("預先寫入失敗: %w", err)
+		}
+	}
+
+	// Ctrl-C 提前結束壓測並輸出已有的結果
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	log.Printf("對 %s 施壓 %s，並發 %d", *targetName, cfg.duration, cfg.concurrency)
+	res := benchmark(ctx, t, cfg)
+
+	if *jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(res.summaries())
+	} else {
+		err = writeText(os.Stdout, res)
+	}
+	if err != nil {
+		return fmt.Errorf("輸出結果失敗: %w", err)
+	}
+	return nil
+}
+
+// newKetoClient 創建壓測使用的客戶端，standalone 時連接內嵌的存儲
+func newKetoClient(writeAddr, readAddr string, transport keto.Transport, standalone bool) (*keto.Client, func(), error) {
+	opts := []keto.Option{keto.WithTransport(transport)}
+	var store *embedded.Server
+	if standalone {
+		store = embedded.NewServer(embedded.WithSchema(keto.Schema()))
+		writeAddr, readAddr = store.Addr(), store.Addr()
+		opts = []keto.Option{keto.WithDialOptions(store.DialOptions()...)}
+	}
+
+	client, err := keto.NewClient(writeAddr, readAddr, opts...)
+	if err != nil {
+		if store != nil {
+			store.Close()
+		}
+		return nil, nil, err
+	}
+	return client, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := client.Close(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "關閉 Keto 客戶端失敗: %v\n", err)
+		}
+		if store != nil {
+			store.Close()
+		}
+	}, nil
+}
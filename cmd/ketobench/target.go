@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/AidChen0509/oosa_ketosdk/api"
+	"github.com/AidChen0509/oosa_ketosdk/keto"
+)
+
+// ketoTarget 通過 keto.Client 直接調用 Keto
+type ketoTarget struct {
+	client *keto.Client
+}
+
+func (t ketoTarget) create(ctx context.Context, photoID, eventID string) error {
+	return t.client.CreatePhotoEventReference(ctx, photoID, eventID)
+}
+
+func (t ketoTarget) list(ctx context.Context, eventID string) error {
+	_, err := t.client.GetEventReferencePhotos(ctx, eventID)
+	return err
+}
+
+func (t ketoTarget) check(ctx context.Context, photoID, eventID string) error {
+	_, err := t.client.CheckPermission(ctx, keto.NamespacePhoto, photoID, keto.RelationReference, eventID)
+	return err
+}
+
+func (t ketoTarget) delete(ctx context.Context, photoID, eventID string) error {
+	return t.client.DeletePhotoEventRelation(ctx, photoID, eventID, keto.RelationReference)
+}
+
+// apiTarget 調用 cmd/server 提供的 REST API
+type apiTarget struct {
+	base   string
+	client *http.Client
+}
+
+func (t apiTarget) create(ctx context.Context, photoID, eventID string) error {
+	body, err := json.Marshal(api.PhotoEventReferenceRequest{PhotoID: photoID, EventID: eventID})
+	if err != nil {
+		return err
+	}
+	return t.do(ctx, http.MethodPost, "/api/photos/reference", body)
+}
+
+func (t apiTarget) list(ctx context.Context, eventID string) error {
+	return t.do(ctx, http.MethodGet, "/api/events/"+url.PathEscape(eventID)+"/photos/reference", nil)
+}
+
+func (t apiTarget) check(ctx context.Context, photoID, eventID string) error {
+	query := url.Values{
+		"namespace": {keto.NamespacePhoto},
+		"object":    {photoID},
+		"relation":  {keto.RelationReference},
+		"subject":   {eventID},
+	}
+	return t.do(ctx, http.MethodGet, "/api/photos/check?"+query.Encode(), nil)
+}
+
+func (t apiTarget) delete(ctx context.Context, photoID, eventID string) error {
+	path := fmt.Sprintf("/api/photos/%s/events/%s/%s", url.PathEscape(photoID), url.PathEscape(eventID), keto.RelationReference)
+	return t.do(ctx, http.MethodDelete, path, nil)
+}
+
+// do 發送請求，非 2xx 響應視為錯誤
+func (t apiTarget) do(ctx context.Context, method, path string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(t.base, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s 返回 %d: %s", method, path, resp.StatusCode, bytes.TrimSpace(data))
+	}
+	return nil
+}
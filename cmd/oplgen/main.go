@@ -0,0 +1,38 @@
+// oplgen 根據 SDK 的命名空間定義生成 Keto 的 OPL 配置文件
+//
+// 用法:
+//
+//	go run ./cmd/oplgen -o config/namespaces.keto.ts
+package main
+
+import (
+	"bytes"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/AidChen0509/oosa_ketosdk/keto"
+	"github.com/AidChen0509/oosa_ketosdk/opl"
+)
+
+func main() {
+	output := flag.String("o", "config/namespaces.keto.ts", "輸出文件路徑，使用 - 表示標準輸出")
+	flag.Parse()
+
+	var buf bytes.Buffer
+	if err := opl.Generate(&buf, keto.Schema()); err != nil {
+		log.Fatalf("生成 OPL 失敗: %v", err)
+	}
+
+	if *output == "-" {
+		if _, err := os.Stdout.Write(buf.Bytes()); err != nil {
+			log.Fatalf("寫入標準輸出失敗: %v", err)
+		}
+		return
+	}
+
+	if err := os.WriteFile(*output, buf.Bytes(), 0o644); err != nil {
+		log.Fatalf("寫入 %s 失敗: %v", *output, err)
+	}
+	log.Printf("已生成 %s", *output)
+}
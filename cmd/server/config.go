@@ -0,0 +1,376 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/AidChen0509/oosa_ketosdk/api"
+	"github.com/AidChen0509/oosa_ketosdk/keto"
+	"github.com/AidChen0509/oosa_ketosdk/telemetry"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"gopkg.in/yaml.v3"
+)
+
+// envPrefix 環境變量的前綴，環境變量名由參數名轉換而來，例如 -keto-write 對應 KETOSDK_KETO_WRITE
+const envPrefix = "KETOSDK_"
+
+// errUsage 命令行參數無效，錯誤和用法已輸出
+var errUsage = errors.New("命令行參數無效")
+
+// config 服務器的配置
+//
+// 優先級從高到低: 命令行參數、環境變量、YAML 配置文件、默認值
+type config struct {
+	Listen  string          `yaml:"listen"`   // API 服務器的監聽地址
+	GinMode string          `yaml:"gin_mode"` // gin 的運行模式: debug、release 或 test
+	TLS     serverTLSConfig `yaml:"tls"`
+	HTTP    httpConfig      `yaml:"http"`
+	Log     logConfig       `yaml:"log"`
+	Tracing tracingConfig   `yaml:"tracing"`
+	Keto    ketoConfig      `yaml:"keto"`
+
+	MigrateEventSubjects bool `yaml:"-"` // 改寫舊格式的照片-事件元組後退出，不啟動服務器
+}
+
+// serverTLSConfig API 服務器的 TLS 配置，兩個文件都設置時啟用 HTTPS
+type serverTLSConfig struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+}
+
+// httpConfig API 服務器的超時，除 ShutdownTimeout 外 0 表示不限制
+type httpConfig struct {
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout"` // 收到停止信號後等待進行中請求的時間
+}
+
+// timeouts 返回 api.WithHTTPTimeouts 使用的超時
+func (h httpConfig) timeouts() api.HTTPTimeouts {
+	return api.HTTPTimeouts{
+		ReadHeader: h.ReadHeaderTimeout,
+		Read:       h.ReadTimeout,
+		Write:      h.WriteTimeout,
+		Idle:       h.IdleTimeout,
+	}
+}
+
+// logConfig 日誌配置
+type logConfig struct {
+	Level  string `yaml:"level"`  // debug、info、warn 或 error
+	Format string `yaml:"format"` // text 或 json
+}
+
+// tracingConfig 追蹤配置，對應 telemetry.TracingConfig
+type tracingConfig struct {
+	Exporter     string `yaml:"exporter"`
+	File         string `yaml:"file"`
+	OTLPEndpoint string `yaml:"otlp_endpoint"`
+	OTLPInsecure bool   `yaml:"otlp_insecure"`
+}
+
+// ketoConfig Keto 客戶端的配置
+type ketoConfig struct {
+	WriteAddr   string        `yaml:"write_addr"`
+	ReadAddr    string        `yaml:"read_addr"`
+	Transport   string        `yaml:"transport"` // grpc 或 rest
+	Timeouts    ketoTimeouts  `yaml:"timeouts"`
+	TLS         ketoTLSConfig `yaml:"tls"`
+	AuditFile   string        `yaml:"audit_file"`
+	ActorHeader string        `yaml:"actor_header"` // 審計記錄的調用者所在的請求頭，由可信的反向代理設置
+	RecordFile  string        `yaml:"record_file"`
+	ReplayFile  string        `yaml:"replay_file"`
+	Standalone  bool          `yaml:"standalone"`
+	DataFile    string        `yaml:"data_file"`
+}
+
+// ketoTimeouts Keto 調用的默認超時，0 表示不限制
+type ketoTimeouts struct {
+	Connect    time.Duration `yaml:"connect"` // 大於 0 時啟動時等待連接就緒
+	Check      time.Duration `yaml:"check"`
+	List       time.Duration `yaml:"list"`
+	Write      time.Duration `yaml:"write"`
+	BatchWrite time.Duration `yaml:"batch_write"`
+	Close      time.Duration `yaml:"close"` // 停止時等待並關閉客戶端的時間，在等待 HTTP 請求之後單獨計時
+}
+
+// ketoTLSConfig 連接 Keto 的 TLS 配置
+type ketoTLSConfig struct {
+	Enabled    bool   `yaml:"enabled"`
+	CAFile     string `yaml:"ca_file"`     // 為空時使用系統根證書
+	CertFile   string `yaml:"cert_file"`   // 客戶端證書，用於 mTLS
+	KeyFile    string `yaml:"key_file"`    // 客戶端私鑰，用於 mTLS
+	ServerName string `yaml:"server_name"` // 為空時使用地址中的主機名
+}
+
+// defaultConfig 返回默認配置
+func defaultConfig() config {
+	return config{
+		Listen:  ":8080",
+		GinMode: gin.DebugMode,
+		HTTP: httpConfig{
+			ReadHeaderTimeout: api.DefaultHTTPTimeouts.ReadHeader,
+			ReadTimeout:       api.DefaultHTTPTimeouts.Read,
+			WriteTimeout:      api.DefaultHTTPTimeouts.Write,
+			IdleTimeout:       api.DefaultHTTPTimeouts.Idle,
+			ShutdownTimeout:   30 * time.Second,
+		},
+		Log: logConfig{Level: "info", Format: "text"},
+		Tracing: tracingConfig{
+			Exporter:     telemetry.ExporterNone,
+			File:         "traces.jsonl",
+			OTLPEndpoint: "127.0.0.1:4317",
+			OTLPInsecure: true,
+		},
+		Keto: ketoConfig{
+			WriteAddr: "127.0.0.1:4467",
+			ReadAddr:  "127.0.0.1:4466",
+			Transport: string(keto.TransportGRPC),
+			Timeouts:  ketoTimeouts{Close: api.DefaultKetoCloseTimeout},
+			DataFile:  "keto-data.json",
+		},
+	}
+}
+
+// bindFlags 定義與 cfg 字段綁定的參數，參數的默認值為 cfg 當前的值
+func bindFlags(fs *flag.FlagSet, cfg *config, configFile *string) {
+	fs.StringVar(configFile, "config", *configFile, "YAML 配置文件路徑，為空時不讀取")
+
+	fs.StringVar(&cfg.Listen, "listen", cfg.Listen, "API 服務器的監聽地址")
+	fs.BoolVar(&cfg.MigrateEventSubjects, "migrate-event-subjects", cfg.MigrateEventSubjects, "將舊版本以事件 ID 為主體的元組改寫為事件主體集合後退出，不啟動服務器")
+	fs.StringVar(&cfg.GinMode, "gin-mode", cfg.GinMode, "gin 的運行模式: debug、release 或 test")
+	fs.StringVar(&cfg.TLS.CertFile, "tls-cert", cfg.TLS.CertFile, "API 服務器的 TLS 證書，與 -tls-key 一起設置時啟用 HTTPS")
+	fs.StringVar(&cfg.TLS.KeyFile, "tls-key", cfg.TLS.KeyFile, "API 服務器的 TLS 私鑰")
+	fs.DurationVar(&cfg.HTTP.ReadHeaderTimeout, "http-read-header-timeout", cfg.HTTP.ReadHeaderTimeout, "讀取請求頭的超時，0 表示不限制")
+	fs.DurationVar(&cfg.HTTP.ReadTimeout, "http-read-timeout", cfg.HTTP.ReadTimeout, "讀取整個請求的超時，0 表示不限制")
+	fs.DurationVar(&cfg.HTTP.WriteTimeout, "http-write-timeout", cfg.HTTP.WriteTimeout, "寫入響應的超時，0 表示不限制")
+	fs.DurationVar(&cfg.HTTP.IdleTimeout, "http-idle-timeout", cfg.HTTP.IdleTimeout, "keep-alive 連接的空閒超時，0 表示不限制")
+	fs.DurationVar(&cfg.HTTP.ShutdownTimeout, "shutdown-timeout", cfg.HTTP.ShutdownTimeout, "收到 SIGINT 或 SIGTERM 後等待進行中請求完成的時間")
+
+	fs.StringVar(&cfg.Log.Level, "log-level", cfg.Log.Level, "日誌級別: debug、info、warn 或 error")
+	fs.StringVar(&cfg.Log.Format, "log-format", cfg.Log.Format, "日誌格式: text 或 json")
+
+	fs.StringVar(&cfg.Tracing.Exporter, "trace-exporter", cfg.Tracing.Exporter, "追蹤導出器: none、stdout、file 或 otlp")
+	fs.StringVar(&cfg.Tracing.File, "trace-file", cfg.Tracing.File, "trace-exporter 為 file 時的輸出路徑")
+	fs.StringVar(&cfg.Tracing.OTLPEndpoint, "otlp-endpoint", cfg.Tracing.OTLPEndpoint, "trace-exporter 為 otlp 時的 collector 地址")
+	fs.BoolVar(&cfg.Tracing.OTLPInsecure, "otlp-insecure", cfg.Tracing.OTLPInsecure, "連接 collector 時不使用 TLS")
+
+	fs.StringVar(&cfg.Keto.WriteAddr, "keto-write", cfg.Keto.WriteAddr, "Keto 寫入地址")
+	fs.StringVar(&cfg.Keto.ReadAddr, "keto-read", cfg.Keto.ReadAddr, "Keto 讀取地址")
+	fs.StringVar(&cfg.Keto.Transport, "keto-transport", cfg.Keto.Transport, "與 Keto 通信的方式: grpc 或 rest")
+	fs.DurationVar(&cfg.Keto.Timeouts.Connect, "keto-connect-timeout", cfg.Keto.Timeouts.Connect, "啟動時等待 Keto 連接就緒的時間，0 表示不等待")
+	fs.DurationVar(&cfg.Keto.Timeouts.Check, "keto-check-timeout", cfg.Keto.Timeouts.Check, "權限檢查的默認超時，0 表示不限制")
+	fs.DurationVar(&cfg.Keto.Timeouts.List, "keto-list-timeout", cfg.Keto.Timeouts.List, "查詢元組的默認超時，0 表示不限制")
+	fs.DurationVar(&cfg.Keto.Timeouts.Write, "keto-write-timeout", cfg.Keto.Timeouts.Write, "寫入或刪除單個元組的默認超時，0 表示不限制")
+	fs.DurationVar(&cfg.Keto.Timeouts.BatchWrite, "keto-batch-write-timeout", cfg.Keto.Timeouts.BatchWrite, "批量寫入的默認超時，0 表示不限制")
+	fs.DurationVar(&cfg.Keto.Timeouts.Close, "keto-close-timeout", cfg.Keto.Timeouts.Close, "停止時等待進行中的 Keto 調用並關閉客戶端的時間，在 -shutdown-timeout 之後計時，0 表示不限制")
+	fs.BoolVar(&cfg.Keto.TLS.Enabled, "keto-tls", cfg.Keto.TLS.Enabled, "使用 TLS 連接 Keto")
+	fs.StringVar(&cfg.Keto.TLS.CAFile, "keto-tls-ca", cfg.Keto.TLS.CAFile, "驗證 Keto 證書的 CA 文件，為空時使用系統根證書")
+	fs.StringVar(&cfg.Keto.TLS.CertFile, "keto-tls-cert", cfg.Keto.TLS.CertFile, "連接 Keto 的客戶端證書 (mTLS)")
+	fs.StringVar(&cfg.Keto.TLS.KeyFile, "keto-tls-key", cfg.Keto.TLS.KeyFile, "連接 Keto 的客戶端私鑰 (mTLS)")
+	fs.StringVar(&cfg.Keto.TLS.ServerName, "keto-tls-server-name", cfg.Keto.TLS.ServerName, "驗證 Keto 證書使用的主機名")
+	fs.StringVar(&cfg.Keto.AuditFile, "audit-file", cfg.Keto.AuditFile, "以 JSON Lines 格式寫入審計記錄的文件，為空時不記錄")
+	fs.StringVar(&cfg.Keto.ActorHeader, "audit-actor-header", cfg.Keto.ActorHeader, "從此請求頭讀取審計記錄的調用者 (例如 X-Forwarded-User)，只能在可信的認證代理之後使用；為空時審計記錄沒有調用者")
+	fs.StringVar(&cfg.Keto.RecordFile, "keto-record", cfg.Keto.RecordFile, "將 Keto 的 gRPC 請求和響應記錄到此文件，為空時不記錄")
+	fs.StringVar(&cfg.Keto.ReplayFile, "keto-replay", cfg.Keto.ReplayFile, "使用此文件中的記錄回應 Keto 請求，不連接 Keto")
+	fs.BoolVar(&cfg.Keto.Standalone, "standalone", cfg.Keto.Standalone, "不連接 Keto，使用內嵌並保存到 -data-file 的元組存儲")
+	fs.StringVar(&cfg.Keto.DataFile, "data-file", cfg.Keto.DataFile, "standalone 模式下保存元組的文件，每次寫入都會重寫整個文件")
+}
+
+// envName 返回參數對應的環境變量名
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// loadConfig 按優先級合併默認值、配置文件、環境變量和命令行參數，並驗證結果
+//
+// 參數:
+//   - args: 命令行參數 (不含程序名)
+//   - lookupEnv: 讀取環境變量的函數，通常為 os.LookupEnv
+//   - output: 參數錯誤和幫助信息的輸出
+//
+// 返回:
+//   - config: 合併後的配置
+//   - error: 使用 -h 時返回 flag.ErrHelp；參數無效時返回 errUsage
+func loadConfig(args []string, lookupEnv func(string) (string, bool), output io.Writer) (config, error) {
+	// 第一遍只確定配置文件，其他參數在讀取配置文件後重新解析
+	probe := defaultConfig()
+	configFile, _ := lookupEnv(envName("config"))
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	fs.SetOutput(output)
+	bindFlags(fs, &probe, &configFile)
+	fs.Usage = func() {
+		fmt.Fprintf(output, "用法: server [參數]\n\n每個參數也可以通過環境變量設置，例如 -keto-write 對應 %s。\n優先級: 命令行參數 > 環境變量 > -config 指定的 YAML 文件 > 默認值。\n\n", envName("keto-write"))
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return config{}, err
+		}
+		return config{}, fmt.Errorf("%w: %w", errUsage, err)
+	}
+
+	cfg := defaultConfig()
+	if configFile != "" {
+		if err := readConfigFile(configFile, &cfg); err != nil {
+			return config{}, err
+		}
+	}
+
+	fs = flag.NewFlagSet("server", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	bindFlags(fs, &cfg, &configFile)
+	var errs []error
+	fs.VisitAll(func(f *flag.Flag) {
+		name := envName(f.Name)
+		if value, ok := lookupEnv(name); ok {
+			if err := fs.Set(f.Name, value); err != nil {
+				errs = append(errs, fmt.Errorf("環境變量 %s 無效: %w", name, err))
+			}
+		}
+	})
+	if err := errors.Join(errs...); err != nil {
+		return config{}, err
+	}
+	if err := fs.Parse(args); err != nil {
+		return config{}, err
+	}
+
+	return cfg, cfg.validate()
+}
+
+// readConfigFile 讀取 YAML 配置文件，未知的字段視為錯誤
+func readConfigFile(path string, cfg *config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("無法讀取配置文件: %w", err)
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("配置文件 %s 無效: %w", path, err)
+	}
+	return nil
+}
+
+// validate 檢查配置，返回所有問題
+func (c config) validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Listen != "", "listen 不能為空")
+	check(c.GinMode == gin.DebugMode || c.GinMode == gin.ReleaseMode || c.GinMode == gin.TestMode,
+		"gin_mode 必須為 debug、release 或 test，而不是 %q", c.GinMode)
+	check((c.TLS.CertFile == "") == (c.TLS.KeyFile == ""), "tls.cert_file 和 tls.key_file 必須同時設置")
+	h := c.HTTP
+	check(h.ReadHeaderTimeout >= 0 && h.ReadTimeout >= 0 && h.WriteTimeout >= 0 && h.IdleTimeout >= 0, "http 的超時不能為負數")
+	check(h.ShutdownTimeout > 0, "http.shutdown_timeout 必須大於 0")
+
+	var level slog.Level
+	check(level.UnmarshalText([]byte(c.Log.Level)) == nil, "無效的日誌級別 %q", c.Log.Level)
+	check(c.Log.Format == "text" || c.Log.Format == "json", "無效的日誌格式 %q", c.Log.Format)
+
+	switch c.Tracing.Exporter {
+	case telemetry.ExporterNone, telemetry.ExporterStdout, telemetry.ExporterFile, telemetry.ExporterOTLP:
+	default:
+		errs = append(errs, fmt.Errorf("無效的追蹤導出器 %q", c.Tracing.Exporter))
+	}
+
+	k := c.Keto
+	check(k.Transport == string(keto.TransportGRPC) || k.Transport == string(keto.TransportREST),
+		"keto.transport 必須為 grpc 或 rest，而不是 %q", k.Transport)
+	if k.Standalone {
+		check(k.DataFile != "", "standalone 模式需要 keto.data_file")
+		check(!k.TLS.Enabled, "standalone 模式不能使用 keto.tls")
+		check(k.ReplayFile == "", "standalone 模式不能使用 keto.replay_file")
+	} else {
+		check(k.WriteAddr != "" && k.ReadAddr != "", "keto.write_addr 和 keto.read_addr 不能為空")
+	}
+	check(k.ReplayFile == "" || k.Timeouts.Connect == 0, "回放時不能設置 keto.timeouts.connect")
+	t := k.Timeouts
+	check(t.Connect >= 0 && t.Check >= 0 && t.List >= 0 && t.Write >= 0 && t.BatchWrite >= 0 && t.Close >= 0, "keto.timeouts 不能為負數")
+	if !k.TLS.Enabled {
+		check(k.TLS.CAFile == "" && k.TLS.CertFile == "" && k.TLS.KeyFile == "" && k.TLS.ServerName == "",
+			"設置了 keto.tls 的文件但沒有啟用 keto.tls.enabled")
+	}
+	check((k.TLS.CertFile == "") == (k.TLS.KeyFile == ""), "keto.tls.cert_file 和 keto.tls.key_file 必須同時設置")
+	if k.TLS.Enabled && k.Transport == string(keto.TransportREST) {
+		// 沒有 scheme 的地址會使用 http://，TLS 設置不會生效
+		https := func(addr string) bool { return strings.HasPrefix(addr, "https://") }
+		check(https(k.WriteAddr) && https(k.ReadAddr),
+			"keto.tls 與 rest 傳輸一起使用時 keto.write_addr 和 keto.read_addr 必須以 https:// 開頭")
+	}
+
+	return errors.Join(errs...)
+}
+
+// ketoOptions 返回由配置決定的 Keto 客戶端選項 (傳輸、超時和 TLS)
+func (k ketoConfig) ketoOptions() ([]keto.Option, error) {
+	opts := []keto.Option{
+		keto.WithTransport(keto.Transport(k.Transport)),
+		keto.WithCheckTimeout(k.Timeouts.Check),
+		keto.WithListTimeout(k.Timeouts.List),
+		keto.WithWriteTimeout(k.Timeouts.Write),
+		keto.WithBatchWriteTimeout(k.Timeouts.BatchWrite),
+	}
+	if k.Timeouts.Connect > 0 {
+		opts = append(opts, keto.WithEagerConnect(k.Timeouts.Connect))
+	}
+	if !k.TLS.Enabled {
+		return opts, nil
+	}
+
+	tlsConfig, err := k.TLS.clientConfig()
+	if err != nil {
+		return nil, err
+	}
+	if k.Transport == string(keto.TransportREST) {
+		// validate 已確保 REST 的地址使用 https://
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		return append(opts, keto.WithHTTPClient(&http.Client{Transport: transport})), nil
+	}
+	return append(opts, keto.WithDialOptions(grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))), nil
+}
+
+// clientConfig 創建連接 Keto 使用的 tls.Config
+func (t ketoTLSConfig) clientConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12, ServerName: t.ServerName}
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("無法讀取 Keto 的 CA 文件: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("Keto 的 CA 文件 %s 中沒有有效的證書", t.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if t.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("無法載入 Keto 的客戶端證書: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
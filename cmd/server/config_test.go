@@ -0,0 +1,161 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AidChen0509/oosa_ketosdk/keto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// env 返回從 map 讀取環境變量的函數
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	}
+}
+
+// writeConfig 將 YAML 寫入臨時文件並返回路徑
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "server.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadConfig_Defaults(t *testing.T) {
+	cfg, err := loadConfig(nil, env(nil), io.Discard)
+	require.NoError(t, err)
+	assert.Equal(t, defaultConfig(), cfg)
+}
+
+func TestLoadConfig_Precedence(t *testing.T) {
+	path := writeConfig(t, `
+listen: ":9000"
+gin_mode: release
+log:
+  level: warn
+keto:
+  write_addr: keto-write.yaml:4467
+  read_addr: keto-read.yaml:4466
+  timeouts:
+    check: 2s
+`)
+
+	// 只有配置文件
+	cfg, err := loadConfig([]string{"-config", path}, env(nil), io.Discard)
+	require.NoError(t, err)
+	assert.Equal(t, ":9000", cfg.Listen)
+	assert.Equal(t, "release", cfg.GinMode)
+	assert.Equal(t, "warn", cfg.Log.Level)
+	assert.Equal(t, "text", cfg.Log.Format, "配置文件沒有設置的字段保持默認值")
+	assert.Equal(t, "keto-write.yaml:4467", cfg.Keto.WriteAddr)
+	assert.Equal(t, 2*time.Second, cfg.Keto.Timeouts.Check)
+
+	// 環境變量覆蓋配置文件，配置文件也可以通過環境變量指定
+	vars := map[string]string{
+		"KETOSDK_CONFIG":     path,
+		"KETOSDK_LISTEN":     ":9100",
+		"KETOSDK_KETO_WRITE": "keto-write.env:4467",
+	}
+	cfg, err = loadConfig(nil, env(vars), io.Discard)
+	require.NoError(t, err)
+	assert.Equal(t, ":9100", cfg.Listen)
+	assert.Equal(t, "keto-write.env:4467", cfg.Keto.WriteAddr)
+	assert.Equal(t, "keto-read.yaml:4466", cfg.Keto.ReadAddr)
+
+	// 命令行參數覆蓋環境變量
+	cfg, err = loadConfig([]string{"-listen", ":9200", "-keto-check-timeout", "500ms"}, env(vars), io.Discard)
+	require.NoError(t, err)
+	assert.Equal(t, ":9200", cfg.Listen)
+	assert.Equal(t, "keto-write.env:4467", cfg.Keto.WriteAddr)
+	assert.Equal(t, 500*time.Millisecond, cfg.Keto.Timeouts.Check)
+}
+
+func TestLoadConfig_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		yaml    string
+		wantErr string
+	}{
+		{name: "未知的配置字段", yaml: "keto:\n  write: x\n", wantErr: "field write not found"},
+		{name: "無效的環境變量", env: map[string]string{"KETOSDK_KETO_CHECK_TIMEOUT": "soon"}, wantErr: "KETOSDK_KETO_CHECK_TIMEOUT"},
+		{name: "無效的 gin 模式", args: []string{"-gin-mode", "prod"}, wantErr: "gin_mode"},
+		{name: "TLS 只設置證書", args: []string{"-tls-cert", "cert.pem"}, wantErr: "tls.cert_file"},
+		{name: "無效的傳輸方式", args: []string{"-keto-transport", "http"}, wantErr: "keto.transport"},
+		{name: "負數的超時", args: []string{"-keto-list-timeout", "-1s"}, wantErr: "keto.timeouts"},
+		{name: "未啟用 Keto TLS", args: []string{"-keto-tls-ca", "ca.pem"}, wantErr: "keto.tls.enabled"},
+		{name: "Keto TLS 使用 http 的 REST 地址", args: []string{"-keto-tls", "-keto-transport", "rest"}, wantErr: "https://"},
+		{name: "Keto TLS 只有一個 https 地址", args: []string{"-keto-tls", "-keto-transport", "rest", "-keto-write", "https://keto:4467", "-keto-read", "http://keto:4466"}, wantErr: "https://"},
+		{name: "空的 Keto 地址", args: []string{"-keto-write", ""}, wantErr: "keto.write_addr"},
+		{name: "standalone 使用回放", args: []string{"-standalone", "-keto-replay", "r.jsonl"}, wantErr: "replay_file"},
+		{name: "關閉超時為 0", args: []string{"-shutdown-timeout", "0"}, wantErr: "http.shutdown_timeout"},
+		{name: "負數的 HTTP 超時", yaml: "http:\n  idle_timeout: -1s\n", wantErr: "http 的超時"},
+		{name: "無效的日誌級別", args: []string{"-log-level", "loud"}, wantErr: "日誌級別"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.args
+			if tt.yaml != "" {
+				args = append([]string{"-config", writeConfig(t, tt.yaml)}, args...)
+			}
+			_, err := loadConfig(args, env(tt.env), io.Discard)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+// 測試 REST 傳輸使用 TLS 時接受 https:// 地址
+func TestLoadConfig_KetoTLSWithREST(t *testing.T) {
+	cfg, err := loadConfig([]string{"-keto-tls", "-keto-transport", "rest",
+		"-keto-write", "https://keto:4467", "-keto-read", "https://keto:4466"}, env(nil), io.Discard)
+	require.NoError(t, err)
+	assert.True(t, cfg.Keto.TLS.Enabled)
+
+	// gRPC 傳輸不使用 scheme
+	_, err = loadConfig([]string{"-keto-tls"}, env(nil), io.Discard)
+	assert.NoError(t, err)
+}
+
+func TestLoadConfig_ReportsAllProblems(t *testing.T) {
+	_, err := loadConfig([]string{"-listen", "", "-log-format", "xml"}, env(nil), io.Discard)
+	assert.ErrorContains(t, err, "listen")
+	assert.ErrorContains(t, err, "日誌格式")
+}
+
+func TestLoadConfig_Usage(t *testing.T) {
+	_, err := loadConfig([]string{"-h"}, env(nil), io.Discard)
+	assert.ErrorIs(t, err, flag.ErrHelp)
+
+	_, err = loadConfig([]string{"-unknown"}, env(nil), io.Discard)
+	assert.ErrorIs(t, err, errUsage)
+}
+
+func TestKetoConfig_Options(t *testing.T) {
+	k := defaultConfig().Keto
+	k.Timeouts.Connect = time.Second
+	opts, err := k.ketoOptions()
+	require.NoError(t, err)
+	assert.Len(t, opts, 6)
+
+	k.TLS = ketoTLSConfig{Enabled: true, CAFile: filepath.Join(t.TempDir(), "missing.pem")}
+	_, err = k.ketoOptions()
+	assert.ErrorContains(t, err, "CA 文件")
+
+	k.TLS.CAFile = writeConfig(t, "not a certificate")
+	_, err = k.ketoOptions()
+	assert.ErrorContains(t, err, "沒有有效的證書")
+
+	k.TLS.CAFile = ""
+	k.Transport = string(keto.TransportREST)
+	opts, err = k.ketoOptions()
+	require.NoError(t, err)
+	assert.Len(t, opts, 7)
+}
@@ -1,24 +1,228 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/AidChen0509/oosa_ketosdk/api"
 	"github.com/AidChen0509/oosa_ketosdk/keto"
+	"github.com/AidChen0509/oosa_ketosdk/keto/embedded"
+	"github.com/AidChen0509/oosa_ketosdk/telemetry"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 )
 
 func main() {
+	cfg, err := loadConfig(os.Args[1:], os.LookupEnv, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		if !errors.Is(err, errUsage) {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		os.Exit(2)
+	}
+	gin.SetMode(cfg.GinMode)
+
+	logger, err := newLogger(cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	slog.SetDefault(logger)
+
+	// 所有清理都在 run 中通過 defer 完成，因此只在 run 返回後退出
+	if err := run(cfg, logger); err != nil {
+		logger.Error("服務器異常退出", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+}
+
+// run 啟動服務器，直到收到 SIGINT 或 SIGTERM 後優雅關閉
+// 關閉順序: 停止接受請求並等待進行中的請求、關閉 Keto 客戶端、關閉內嵌存儲和記錄文件、關閉追蹤
+func run(cfg config, logger *slog.Logger) error {
+	// 初始化追蹤
+	shutdownTracing, err := telemetry.SetupTracing(context.Background(), telemetry.TracingConfig{
+		ServiceName:  api.ServiceName,
+		Exporter:     cfg.Tracing.Exporter,
+		File:         cfg.Tracing.File,
+		OTLPEndpoint: cfg.Tracing.OTLPEndpoint,
+		OTLPInsecure: cfg.Tracing.OTLPInsecure,
+	})
+	if err != nil {
+		return fmt.Errorf("無法初始化追蹤: %w", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	// 指標通過 API 服務器的 /metrics 端點提供
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	ketoOpts, err := cfg.Keto.ketoOptions()
+	if err != nil {
+		return fmt.Errorf("無效的 Keto 配置: %w", err)
+	}
+	ketoOpts = append(ketoOpts, keto.WithMetrics(registry), keto.WithLogger(logger))
+	if cfg.Keto.AuditFile != "" {
+		auditSink, err := keto.NewFileAuditSink(cfg.Keto.AuditFile)
+		if err != nil {
+			return fmt.Errorf("無法打開審計文件: %w", err)
+		}
+		defer auditSink.Close()
+		ketoOpts = append(ketoOpts, keto.WithAuditSink(auditSink))
+	}
+	// cmd/server 沒有認證中間件，調用者只能來自可信代理設置的請求頭
+	var serverOpts []api.ServerOption
+	if cfg.Keto.ActorHeader != "" {
+		serverOpts = append(serverOpts, api.WithActor(api.ActorFromHeader(cfg.Keto.ActorHeader)))
+	} else if cfg.Keto.AuditFile != "" {
+		logger.Warn("未設置 -audit-actor-header，審計記錄不會包含調用者")
+	}
+	if cfg.Keto.RecordFile != "" {
+		recorder, err := keto.NewRecorder(cfg.Keto.RecordFile)
+		if err != nil {
+			return fmt.Errorf("無法創建記錄文件: %w", err)
+		}
+		defer recorder.Close()
+		ketoOpts = append(ketoOpts, keto.WithRecorder(recorder))
+	}
+	if cfg.Keto.ReplayFile != "" {
+		replayer, err := keto.NewReplayer(cfg.Keto.ReplayFile)
+		if err != nil {
+			return fmt.Errorf("無法讀取記錄文件: %w", err)
+		}
+		ketoOpts = append(ketoOpts, keto.WithReplay(replayer))
+	}
+
+	// standalone 模式下客戶端通過 bufconn 連接內嵌的存儲，語意與 Keto 相同
+	writeAddr, readAddr := cfg.Keto.WriteAddr, cfg.Keto.ReadAddr
+	if cfg.Keto.Standalone {
+		store, err := embedded.Open(cfg.Keto.DataFile, embedded.WithSchema(keto.Schema()))
+		if err != nil {
+			return fmt.Errorf("無法打開內嵌存儲: %w", err)
+		}
+		defer store.Close()
+		writeAddr, readAddr = store.Addr(), store.Addr()
+		ketoOpts = append(ketoOpts,
+			keto.WithTransport(keto.TransportGRPC),
+			keto.WithDialOptions(store.DialOptions()...),
+		)
+		logger.Info("使用內嵌的元組存儲", slog.String("data_file", cfg.Keto.DataFile))
+	}
+
 	// 初始化 Keto 客戶端
-	ketoClient, err := keto.NewClient("127.0.0.1:4467", "127.0.0.1:4466")
+	ketoClient, err := keto.NewClient(writeAddr, readAddr, ketoOpts...)
+	if err != nil {
+		return fmt.Errorf("無法連接到 Keto 服務: %w", err)
+	}
+	if cfg.MigrateEventSubjects {
+		return migrateEventSubjects(ketoClient, cfg.Keto.Timeouts.Close, logger)
+	}
+
+	// 創建 API 服務器，之後由 server.Shutdown 關閉 Keto 客戶端，這裡不再關閉
+	server, err := api.NewServer(ketoClient, append(serverOpts,
+		api.WithMetrics(registry),
+		api.WithLogger(logger),
+		api.WithHTTPTimeouts(cfg.HTTP.timeouts()),
+		api.WithKetoCloseTimeout(cfg.Keto.Timeouts.Close),
+	)...)
 	if err != nil {
-		log.Fatalf("無法連接到 Keto 服務: %v", err)
+		ctx := context.Background()
+		if d := cfg.Keto.Timeouts.Close; d > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, d)
+			defer cancel()
+		}
+		return errors.Join(fmt.Errorf("無法創建 API 服務器: %w", err), ketoClient.Close(ctx))
 	}
-	defer ketoClient.Close()
 
-	// 這裡開始添加你的 API 服務
-	log.Println("事件照片管理後端啟動...")
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// 創建並啟動 API 服務器
-	server := api.NewServer(ketoClient)
-	log.Fatal(server.Run(":8080"))
+	logger.Info("事件照片管理後端啟動",
+		slog.String("addr", cfg.Listen),
+		slog.Bool("tls", cfg.TLS.CertFile != ""),
+		slog.String("keto_write", writeAddr),
+		slog.String("keto_read", readAddr),
+	)
+	served := make(chan error, 1)
+	go func() {
+		if cfg.TLS.CertFile != "" {
+			served <- server.RunTLS(cfg.Listen, cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		} else {
+			served <- server.Run(cfg.Listen)
+		}
+	}()
+
+	var serveErr error
+	select {
+	case serveErr = <-served:
+		if serveErr != nil {
+			serveErr = fmt.Errorf("API 服務器異常退出: %w", serveErr)
+		}
+	case <-ctx.Done():
+		// 恢復默認的信號處理，再次收到信號時立即退出
+		stop()
+		logger.Info("收到停止信號，等待進行中的請求完成", slog.Duration("timeout", cfg.HTTP.ShutdownTimeout))
+		defer func() { <-served }()
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return errors.Join(serveErr, err)
+	}
+	if serveErr != nil {
+		return serveErr
+	}
+	logger.Info("服務器已停止")
+	return nil
+}
+
+// migrateEventSubjects 改寫舊格式的照片-事件元組並關閉客戶端
+func migrateEventSubjects(ketoClient *keto.Client, closeTimeout time.Duration, logger *slog.Logger) error {
+	n, err := ketoClient.MigrateEventSubjects(context.Background())
+	if err == nil {
+		logger.Info("已改寫舊格式的元組", slog.Int("count", n))
+	} else {
+		err = fmt.Errorf("無法改寫舊格式的元組: %w", err)
+	}
+
+	ctx := context.Background()
+	if closeTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, closeTimeout)
+		defer cancel()
+	}
+	return errors.Join(err, ketoClient.Close(ctx))
+}
+
+// newLogger 根據級別和格式創建日誌
+func newLogger(level, format string) (*slog.Logger, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("無效的日誌級別 %q", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: l}
+	switch format {
+	case "text":
+		return slog.New(slog.NewTextHandler(os.Stderr, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(os.Stderr, opts)), nil
+	default:
+		return nil, fmt.Errorf("無效的日誌格式 %q", format)
+	}
 }
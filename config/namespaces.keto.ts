@@ -0,0 +1,28 @@
+// 此文件由 cmd/oplgen 根據 SDK 的命名空間定義生成，請勿手動修改。
+import { Namespace, SubjectSet, Context } from "@ory/keto-namespace-types"
+
+class User implements Namespace {}
+
+class Event implements Namespace {
+  related: {
+    members: (User | SubjectSet<Event, "members">)[]
+  }
+
+  permits = {
+    view: (ctx: Context): boolean =>
+      this.related.members.includes(ctx.subject),
+  }
+}
+
+class Photo implements Namespace {
+  related: {
+    reference: Event[]
+    polaroid: Event[]
+  }
+
+  permits = {
+    view: (ctx: Context): boolean =>
+      this.related.reference.traverse((s) => s.permits.view(ctx)) ||
+      this.related.polaroid.traverse((s) => s.permits.view(ctx)),
+  }
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"sync"
@@ -15,7 +16,9 @@ func main() {
 	if err != nil {
 		log.Fatalf("初始化 Keto 客戶端失敗: %v", err)
 	}
-	defer ketoClient.Close()
+	defer ketoClient.Close(context.Background())
+
+	ctx := context.Background()
 
 	// 模擬多個事件
 	events := []string{"event1", "event2", "event3"}
@@ -35,7 +38,7 @@ func main() {
 		})
 	}
 
-	if err := ketoClient.BatchCreatePhotoEventReferences(relations); err != nil {
+	if err := ketoClient.BatchCreatePhotoEventReferences(ctx, relations); err != nil {
 		log.Fatalf("批量建立 reference 關係失敗: %v", err)
 	}
 
@@ -45,20 +48,20 @@ func main() {
 		{PhotoID: photos[4], EventID: events[1]},
 	}
 
-	if err := ketoClient.BatchCreatePhotoEventPolaroids(polaroidRelations); err != nil {
+	if err := ketoClient.BatchCreatePhotoEventPolaroids(ctx, polaroidRelations); err != nil {
 		log.Fatalf("批量建立 polaroid 關係失敗: %v", err)
 	}
 
 	// 跨事件的照片關係 - 一張照片屬於多個事件
-	if err := ketoClient.CreatePhotoEventReference(photos[2], events[2]); err != nil {
+	if err := ketoClient.CreatePhotoEventReference(ctx, photos[2], events[2]); err != nil {
 		log.Fatalf("建立跨事件照片關係失敗: %v", err)
 	}
 
 	// 展示權限檢查的使用
 	fmt.Println("\n檢查權限...")
-	checkAndPrintPermission(ketoClient, "Photo", photos[0], "reference", events[0])
-	checkAndPrintPermission(ketoClient, "Photo", photos[3], "reference", events[0]) // 應該為 false
-	checkAndPrintPermission(ketoClient, "Photo", photos[3], "polaroid", events[1])
+	checkAndPrintPermission(ctx, ketoClient, "Photo", photos[0], "reference", events[0])
+	checkAndPrintPermission(ctx, ketoClient, "Photo", photos[3], "reference", events[0]) // 應該為 false
+	checkAndPrintPermission(ctx, ketoClient, "Photo", photos[3], "polaroid", events[1])
 
 	// 查詢關係
 	fmt.Println("\n查詢關係...")
@@ -69,29 +72,29 @@ func main() {
 		wg.Add(1)
 		go func(eventID string) {
 			defer wg.Done()
-			fetchAndPrintPhotos(ketoClient, eventID)
+			fetchAndPrintPhotos(ctx, ketoClient, eventID)
 		}(event)
 	}
 	wg.Wait()
 
 	// 查詢特定照片的所有事件關係
-	fetchAndPrintEvents(ketoClient, photos[2]) // 這張照片屬於多個事件
+	fetchAndPrintEvents(ctx, ketoClient, photos[2]) // 這張照片屬於多個事件
 
 	// 清理某些關係
 	fmt.Println("\n清理關係...")
-	if err := ketoClient.DeletePhotoEventRelation(photos[0], events[0], "reference"); err != nil {
+	if err := ketoClient.DeletePhotoEventRelation(ctx, photos[0], events[0], "reference"); err != nil {
 		log.Printf("刪除關係失敗: %v", err)
 	} else {
 		fmt.Printf("成功刪除照片 %s 與事件 %s 的 reference 關係\n", photos[0], events[0])
 	}
 
 	// 再次查詢確認關係已刪除
-	checkAndPrintPermission(ketoClient, "Photo", photos[0], "reference", events[0]) // 應該為 false
+	checkAndPrintPermission(ctx, ketoClient, "Photo", photos[0], "reference", events[0]) // 應該為 false
 }
 
 // 輔助函數: 檢查並打印權限
-func checkAndPrintPermission(client *keto.Client, namespace, object, relation, subject string) {
-	allowed, err := client.CheckPermission(namespace, object, relation, subject)
+func checkAndPrintPermission(ctx context.Context, client *keto.Client, namespace, object, relation, subject string) {
+	allowed, err := client.CheckPermission(ctx, namespace, object, relation, subject)
 	if err != nil {
 		log.Printf("檢查權限失敗 (%s %s %s %s): %v", namespace, object, relation, subject, err)
 		return
@@ -100,9 +103,9 @@ func checkAndPrintPermission(client *keto.Client, namespace, object, relation, s
 }
 
 // 輔助函數: 獲取並打印照片
-func fetchAndPrintPhotos(client *keto.Client, eventID string) {
+func fetchAndPrintPhotos(ctx context.Context, client *keto.Client, eventID string) {
 	// 獲取 reference 照片
-	refPhotos, err := client.GetEventReferencePhotos(eventID)
+	refPhotos, err := client.GetEventReferencePhotos(ctx, eventID)
 	if err != nil {
 		log.Printf("獲取事件 %s 的 reference 照片失敗: %v", eventID, err)
 	} else {
@@ -110,7 +113,7 @@ func fetchAndPrintPhotos(client *keto.Client, eventID string) {
 	}
 
 	// 獲取 polaroid 照片
-	polPhotos, err := client.GetEventPolaroidPhotos(eventID)
+	polPhotos, err := client.GetEventPolaroidPhotos(ctx, eventID)
 	if err != nil {
 		log.Printf("獲取事件 %s 的 polaroid 照片失敗: %v", eventID, err)
 	} else {
@@ -119,8 +122,8 @@ func fetchAndPrintPhotos(client *keto.Client, eventID string) {
 }
 
 // 輔助函數: 獲取並打印事件
-func fetchAndPrintEvents(client *keto.Client, photoID string) {
-	events, err := client.GetPhotoEvents(photoID)
+func fetchAndPrintEvents(ctx context.Context, client *keto.Client, photoID string) {
+	events, err := client.GetPhotoEvents(ctx, photoID)
 	if err != nil {
 		log.Printf("獲取照片 %s 的事件失敗: %v", photoID, err)
 		return
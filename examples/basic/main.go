@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 
@@ -14,20 +15,22 @@ func main() {
 	if err != nil {
 		log.Fatalf("初始化 Keto 客戶端失敗: %v", err)
 	}
-	defer ketoClient.Close()
+	defer ketoClient.Close(context.Background())
+
+	ctx := context.Background()
 
 	// 示例 1: 創建照片和事件之間的關係
 	photoID := "photo123"
 	eventID := "event456"
 
-	err = ketoClient.CreatePhotoEventReference(photoID, eventID)
+	err = ketoClient.CreatePhotoEventReference(ctx, photoID, eventID)
 	if err != nil {
 		log.Fatalf("建立 reference 關係失敗: %v", err)
 	}
 	fmt.Println("成功建立照片和事件的 reference 關係")
 
 	// 示例 2: 檢查權限
-	allowed, err := ketoClient.CheckPermission("Photo", photoID, "reference", eventID)
+	allowed, err := ketoClient.CheckPermission(ctx, "Photo", photoID, "reference", eventID)
 	if err != nil {
 		log.Fatalf("檢查權限失敗: %v", err)
 	}
@@ -38,21 +41,21 @@ func main() {
 		{PhotoID: "photo123", EventID: "event789"},
 		{PhotoID: "photo456", EventID: "event789"},
 	}
-	err = ketoClient.BatchCreatePhotoEventReferences(relations)
+	err = ketoClient.BatchCreatePhotoEventReferences(ctx, relations)
 	if err != nil {
 		log.Fatalf("批量建立關係失敗: %v", err)
 	}
 	fmt.Println("成功批量建立照片和事件的關係")
 
 	// 示例 4: 獲取事件關聯的照片
-	photos, err := ketoClient.GetEventReferencePhotos(eventID)
+	photos, err := ketoClient.GetEventReferencePhotos(ctx, eventID)
 	if err != nil {
 		log.Fatalf("獲取照片失敗: %v", err)
 	}
 	fmt.Printf("事件 %s 關聯的照片: %v\n", eventID, photos)
 
 	// 示例 5: 刪除照片和事件之間的關係
-	err = ketoClient.DeletePhotoEventRelation(photoID, eventID, "reference")
+	err = ketoClient.DeletePhotoEventRelation(ctx, photoID, eventID, "reference")
 	if err != nil {
 		log.Fatalf("刪除關係失敗: %v", err)
 	}
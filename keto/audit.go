@@ -0,0 +1,149 @@
+package keto
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+)
+
+// 審計記錄的操作類型
+const (
+	AuditActionInsert = "insert"
+	AuditActionDelete = "delete"
+)
+
+// 審計記錄的結果
+const (
+	AuditResultOK    = "ok"
+	AuditResultError = "error"
+)
+
+// AuditRecord 一次元組寫入或刪除的審計記錄
+type AuditRecord struct {
+	Time      time.Time     `json:"time"`
+	Action    string        `json:"action"`
+	Tuple     RelationTuple `json:"tuple"`
+	Actor     string        `json:"actor,omitempty"`
+	RequestID string        `json:"request_id,omitempty"`
+	Tenant    string        `json:"tenant,omitempty"`
+	Result    string        `json:"result"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// AuditSink 接收審計記錄
+// 實現必須可以並發調用；返回的錯誤只會記錄到日誌，不會影響寫入操作的結果
+type AuditSink interface {
+	WriteAudit(ctx context.Context, record AuditRecord) error
+}
+
+// WithAuditSink 為每個元組的寫入和刪除向 sinks 發送審計記錄
+// 可多次使用，記錄會發送到所有 sink
+func WithAuditSink(sinks ...AuditSink) Option {
+	return func(o *options) {
+		o.auditSinks = append(o.auditSinks, sinks...)
+	}
+}
+
+// actorKey context 中操作者的鍵
+type actorKey struct{}
+
+// ContextWithActor 返回帶有操作者的 context，審計記錄會包含此操作者
+func ContextWithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext 返回 context 中的操作者，沒有時返回空字符串
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+// audit 為每個元組發送一條審計記錄
+func (k *Client) audit(ctx context.Context, action rts.RelationTupleDelta_Action, tuples []RelationTuple, err error) {
+	if len(k.auditSinks) == 0 {
+		return
+	}
+
+	record := AuditRecord{
+		Time:      time.Now().UTC(),
+		Action:    actionName(action),
+		Actor:     ActorFromContext(ctx),
+		RequestID: RequestIDFromContext(ctx),
+		Tenant:    string(k.tenant),
+		Result:    AuditResultOK,
+	}
+	if err != nil {
+		record.Result = AuditResultError
+		record.Error = err.Error()
+	}
+
+	for _, tuple := range tuples {
+		record.Tuple = tuple
+		for _, sink := range k.auditSinks {
+			if err := sink.WriteAudit(ctx, record); err != nil {
+				k.log().WarnContext(ctx, "審計記錄寫入失敗",
+					slog.String("tuple", tuple.String()),
+					slog.String("error", err.Error()),
+				)
+			}
+		}
+	}
+}
+
+// FileAuditSink 以 JSON Lines 格式將審計記錄追加到文件
+type FileAuditSink struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+// NewFileAuditSink 打開 (或創建) path 並返回 FileAuditSink
+// 記錄追加到文件末尾，使用完畢後需調用 Close
+func NewFileAuditSink(path string) (*FileAuditSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("keto: 無法打開審計文件: %w", err)
+	}
+	return &FileAuditSink{file: f, enc: json.NewEncoder(f)}, nil
+}
+
+// WriteAudit 將記錄寫入為一行 JSON
+func (s *FileAuditSink) WriteAudit(ctx context.Context, record AuditRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enc.Encode(record)
+}
+
+// Close 關閉審計文件
+func (s *FileAuditSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// MemoryAuditSink 將審計記錄保存在內存中，用於測試
+type MemoryAuditSink struct {
+	mu      sync.Mutex
+	records []AuditRecord
+}
+
+// WriteAudit 保存記錄
+func (s *MemoryAuditSink) WriteAudit(ctx context.Context, record AuditRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, record)
+	return nil
+}
+
+// Records 返回目前保存的所有記錄
+func (s *MemoryAuditSink) Records() []AuditRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]AuditRecord(nil), s.records...)
+}
@@ -0,0 +1,153 @@
+package keto
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_AuditsWrites(t *testing.T) {
+	sink := &MemoryAuditSink{}
+	mockWriteClient := new(MockWriteServiceClient)
+	client := &Client{writeClient: mockWriteClient, auditSinks: []AuditSink{sink}}
+
+	mockWriteClient.On("TransactRelationTuples", mock.Anything, mock.Anything).Return(&rts.TransactRelationTuplesResponse{}, nil).Once()
+	mockWriteClient.On("TransactRelationTuples", mock.Anything, mock.Anything).Return((*rts.TransactRelationTuplesResponse)(nil), errors.New("unavailable")).Once()
+
+	ctx := ContextWithActor(ContextWithRequestID(context.Background(), "req-1"), "alice")
+	require.NoError(t, client.BatchCreatePhotoEventReferences(ctx, []PhotoEventRelation{
+		{PhotoID: "photo1", EventID: "event1"},
+		{PhotoID: "photo2", EventID: "event1"},
+	}))
+	require.Error(t, client.DeletePhotoEventRelation(ctx, "photo1", "event1", RelationReference))
+
+	records := sink.Records()
+	require.Len(t, records, 3)
+
+	assert.Equal(t, AuditActionInsert, records[0].Action)
+	assert.Equal(t, "Photo:photo1#reference@(Event:event1#)", records[0].Tuple.String())
+	assert.Equal(t, "alice", records[0].Actor)
+	assert.Equal(t, "req-1", records[0].RequestID)
+	assert.Equal(t, AuditResultOK, records[0].Result)
+	assert.False(t, records[0].Time.IsZero())
+	assert.Equal(t, "Photo:photo2#reference@(Event:event1#)", records[1].Tuple.String())
+
+	assert.Equal(t, AuditActionDelete, records[2].Action)
+	assert.Equal(t, AuditResultError, records[2].Result)
+	assert.Equal(t, "unavailable", records[2].Error)
+}
+
+func TestClient_AuditsRejectedWrites(t *testing.T) {
+	sink := &MemoryAuditSink{}
+	mockWriteClient := new(MockWriteServiceClient)
+	client := &Client{
+		writeClient: mockWriteClient,
+		auditSinks:  []AuditSink{sink},
+		limiter:     newRateLimiter([limitClassCount]*RateLimit{limitWrite: {Rate: 1, FailFast: true}}),
+		lifecycle:   newLifecycle(),
+	}
+	mockWriteClient.On("TransactRelationTuples", mock.Anything, mock.Anything).Return(&rts.TransactRelationTuplesResponse{}, nil)
+
+	ctx := ContextWithActor(context.Background(), "alice")
+	require.NoError(t, client.CreatePhotoEventReference(ctx, "photo1", "event1"))
+	err := client.CreatePhotoEventReference(ctx, "photo2", "event1")
+	require.ErrorIs(t, err, ErrRateLimited)
+	require.NoError(t, client.Close(context.Background()))
+	err = client.DeletePhotoEventRelation(ctx, "photo1", "event1", RelationReference)
+	require.ErrorIs(t, err, ErrClientClosed)
+
+	// 沒有發送到 Keto 的變更也有審計記錄
+	mockWriteClient.AssertNumberOfCalls(t, "TransactRelationTuples", 1)
+	records := sink.Records()
+	require.Len(t, records, 3)
+	assert.Equal(t, AuditResultOK, records[0].Result)
+
+	assert.Equal(t, "Photo:photo2#reference@(Event:event1#)", records[1].Tuple.String())
+	assert.Equal(t, AuditResultError, records[1].Result)
+	assert.Contains(t, records[1].Error, ErrRateLimited.Error())
+	assert.Equal(t, "alice", records[1].Actor)
+
+	assert.Equal(t, AuditActionDelete, records[2].Action)
+	assert.Equal(t, AuditResultError, records[2].Result)
+	assert.Equal(t, ErrClientClosed.Error(), records[2].Error)
+}
+
+func TestClient_AuditsTenant(t *testing.T) {
+	sink := &MemoryAuditSink{}
+	mockWriteClient := new(MockWriteServiceClient)
+	client := &Client{writeClient: mockWriteClient, auditSinks: []AuditSink{sink}}
+	tenantClient, err := client.ForTenant("acme")
+	require.NoError(t, err)
+
+	mockWriteClient.On("TransactRelationTuples", mock.Anything, mock.Anything).Return(&rts.TransactRelationTuplesResponse{}, nil)
+	require.NoError(t, tenantClient.CreatePhotoEventPolaroid(context.Background(), "photo1", "event1"))
+
+	records := sink.Records()
+	require.Len(t, records, 1)
+	assert.Equal(t, "acme", records[0].Tenant)
+	// 記錄的是調用者看到的元組，不含租戶前綴
+	assert.Equal(t, "Photo:photo1#polaroid@(Event:event1#)", records[0].Tuple.String())
+}
+
+func TestFileAuditSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := NewFileAuditSink(path)
+	require.NoError(t, err)
+
+	mockWriteClient := new(MockWriteServiceClient)
+	client := &Client{writeClient: mockWriteClient, auditSinks: []AuditSink{sink}}
+	mockWriteClient.On("TransactRelationTuples", mock.Anything, mock.Anything).Return(&rts.TransactRelationTuplesResponse{}, nil)
+
+	ctx := ContextWithActor(context.Background(), "alice")
+	require.NoError(t, client.CreatePhotoEventReference(ctx, "photo1", "event1"))
+	require.NoError(t, client.DeletePhotoEventRelation(ctx, "photo1", "event1", RelationReference))
+	require.NoError(t, sink.Close())
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var lines []map[string]any
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var line map[string]any
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+	require.Len(t, lines, 2)
+	assert.Equal(t, "insert", lines[0]["action"])
+	assert.Equal(t, "Photo:photo1#reference@(Event:event1#)", lines[0]["tuple"])
+	assert.Equal(t, "alice", lines[0]["actor"])
+	assert.Equal(t, "ok", lines[0]["result"])
+	assert.Equal(t, "delete", lines[1]["action"])
+	assert.NotContains(t, lines[0], "error")
+
+	// 重新打開時追加到文件末尾
+	sink, err = NewFileAuditSink(path)
+	require.NoError(t, err)
+	require.NoError(t, sink.WriteAudit(context.Background(), AuditRecord{Action: AuditActionInsert}))
+	require.NoError(t, sink.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 3, countLines(data))
+}
+
+func countLines(data []byte) int {
+	n := 0
+	for _, b := range data {
+		if b == '\n' {
+			n++
+		}
+	}
+	return n
+}
@@ -2,10 +2,14 @@ package keto
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
 
 	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
 // Client 封裝了 Keto 相關操作的客戶端
@@ -18,132 +22,244 @@ type Client struct {
 	namespacesClient rts.NamespacesServiceClient
 	versionClient    rts.VersionServiceClient
 	writeConn        *grpc.ClientConn
-	readConn         *grpc.ClientConn
+	readConns        []*grpc.ClientConn
+	restConns        []*restConn
+	replicas         *replicaPool
+	ids              IDValidator
+	tenant           tenant
+	limiter          *rateLimiter
+	logger           *slog.Logger
+	auditSinks       []AuditSink
+	metrics          *clientMetrics
+	hedgeDelay       time.Duration
+	lifecycle        *lifecycle
+	timeouts         [timeoutClassCount]time.Duration
+	dryRun           bool
 }
 
 // NewClient 創建一個新的 Keto 客戶端
 //
 // 參數:
 //   - writeAddress: Keto 寫入服務的地址 (例如: "127.0.0.1:4467")
-//   - readAddress: Keto 讀取服務的地址 (例如: "127.0.0.1:4466")
+//   - readAddress: Keto 讀取服務的地址 (例如: "127.0.0.1:4466")，可以是解析到多個副本的 DNS 名稱
+//   - opts: 可選的配置 (例如: WithIDValidator、WithCheckRateLimit、WithReadReplicas)
 //
 // 返回:
 //   - *Client: 新創建的 Keto 客戶端
-//   - error: 如果連接失敗則返回錯誤
-func NewClient(writeAddress, readAddress string) (*Client, error) {
-	writeConn, err := grpc.NewClient(writeAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
-	if err != nil {
-		return nil, err
+//   - error: 如果連接失敗、指標註冊失敗或連接未就緒 (ErrNotReady，見 WithEagerConnect) 則返回錯誤
+func NewClient(writeAddress, readAddress string, opts ...Option) (*Client, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
 	}
 
-	readConn, err := grpc.NewClient(readAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	// 撥號時攔截器已需要指標，但指標在連接建立後才註冊，失敗時不會留在調用方的 reg 中
+	if o.metricsRegisterer != nil {
+		o.metrics = newClientMetrics()
+	}
+
+	conns, err := o.dial(writeAddress, append([]string{readAddress}, o.readReplicas...))
 	if err != nil {
 		return nil, err
 	}
 
-	checkClient := rts.NewCheckServiceClient(readConn)
-	expandClient := rts.NewExpandServiceClient(readConn)
-	namespacesClient := rts.NewNamespacesServiceClient(readConn)
-	versionClient := rts.NewVersionServiceClient(readConn)
+	// 讀取連接：readAddress 以及 WithReadReplicas 添加的副本
+	readConn := conns.reads[0]
+	var pool *replicaPool
+	if len(conns.reads) > 1 {
+		replicas := make([]*replica, 0, len(conns.reads))
+		for i, conn := range conns.reads {
+			address := readAddress
+			if i > 0 {
+				address = o.readReplicas[i-1]
+			}
+			replicas = append(replicas, &replica{address: address, conn: conn})
+		}
+		pool = newReplicaPool(replicas, &o)
+		readConn = pool
+	}
 
-	return &Client{
-		writeClient:      rts.NewWriteServiceClient(writeConn),
+	client := &Client{
+		writeClient:      rts.NewWriteServiceClient(conns.write),
 		readClient:       rts.NewReadServiceClient(readConn),
-		checkClient:      checkClient,
-		expandClient:     expandClient,
-		namespacesClient: namespacesClient,
-		versionClient:    versionClient,
-		writeConn:        writeConn,
-		readConn:         readConn,
-	}, nil
+		checkClient:      rts.NewCheckServiceClient(readConn),
+		expandClient:     rts.NewExpandServiceClient(readConn),
+		namespacesClient: rts.NewNamespacesServiceClient(readConn),
+		versionClient:    rts.NewVersionServiceClient(readConn),
+		writeConn:        conns.grpcWrite,
+		readConns:        conns.grpcReads,
+		restConns:        conns.rest,
+		replicas:         pool,
+		ids:              o.ids,
+		limiter:          newRateLimiter(o.rateLimits),
+		logger:           o.logger,
+		auditSinks:       o.auditSinks,
+		metrics:          o.metrics,
+		hedgeDelay:       o.hedgeDelay,
+		lifecycle:        newLifecycle(),
+		timeouts:         o.timeouts,
+		dryRun:           o.dryRun,
+	}
+
+	if o.eagerConnect {
+		timeout := o.connectTimeout
+		if timeout <= 0 {
+			timeout = DefaultConnectTimeout
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if err := client.waitUntilReady(ctx); err != nil {
+			client.closeConns()
+			return nil, err
+		}
+	}
+	if o.metrics != nil {
+		if err := o.metrics.register(o.metricsRegisterer); err != nil {
+			client.closeConns()
+			return nil, err
+		}
+	}
+	return client, nil
+}
+
+// Close 關閉客戶端
+// 停止接受新的調用 (返回 ErrClientClosed)，等待進行中的調用結束後關閉所有連接
+// ctx 結束時不再等待，直接關閉連接，進行中的調用會失敗
+// 可以多次調用，之後的調用返回第一次的結果
+// 租戶客戶端 (ForTenant) 共用原客戶端的連接，對其調用 Close 不會關閉連接
+//
+// 參數:
+//   - ctx: 限制等待進行中調用的時間
+//
+// 返回:
+//   - error: 等待超時 (包含 ctx.Err()) 或關閉連接失敗時返回的所有錯誤
+func (k *Client) Close(ctx context.Context) error {
+	if k.tenant != "" {
+		return nil
+	}
+	if k.lifecycle == nil {
+		return k.closeConns()
+	}
+
+	l := k.lifecycle
+	l.closeOnce.Do(func() {
+		var waitErr error
+		select {
+		case <-l.shutdown():
+		case <-ctx.Done():
+			waitErr = fmt.Errorf("keto: 等待進行中的請求結束時中止: %w", ctx.Err())
+		}
+		l.closeErr = errors.Join(waitErr, k.closeConns())
+	})
+	return l.closeErr
 }
 
-// Close 關閉客戶端的所有連接
-// 在應用程序結束時應調用此方法以釋放資源
-func (k *Client) Close() {
-	k.writeConn.Close()
-	k.readConn.Close()
+// closeConns 關閉所有連接，返回所有關閉錯誤
+func (k *Client) closeConns() error {
+	var errs []error
+	if k.writeConn != nil {
+		errs = append(errs, k.writeConn.Close())
+	}
+	for _, conn := range k.readConns {
+		errs = append(errs, conn.Close())
+	}
+	for _, conn := range k.restConns {
+		conn.close()
+	}
+	return errors.Join(errs...)
 }
 
 // CreatePhotoEventReference 建立照片和事件之間的 reference 關係
 //
 // 參數:
+//   - ctx: 請求的 context，用於取消、截止時間和追蹤
 //   - photoID: 照片的唯一標識符
 //   - eventID: 事件的唯一標識符
 //
 // 返回:
-//   - error: 如操作失敗則返回錯誤
-func (k *Client) CreatePhotoEventReference(photoID, eventID string) error {
-	_, err := k.writeClient.TransactRelationTuples(context.Background(), &rts.TransactRelationTuplesRequest{
-		RelationTupleDeltas: []*rts.RelationTupleDelta{
-			{
-				Action: rts.RelationTupleDelta_ACTION_INSERT,
-				RelationTuple: &rts.RelationTuple{
-					Namespace: "Photo",
-					Object:    photoID,
-					Relation:  "reference",
-					Subject: &rts.Subject{
-						Ref: &rts.Subject_Id{
-							Id: eventID,
-						},
-					},
-				},
-			},
-		},
-	})
-	return err
+//   - error: 如 ID 無效 (ErrInvalidID) 或操作失敗則返回錯誤
+func (k *Client) CreatePhotoEventReference(ctx context.Context, photoID, eventID string) error {
+	tuple, err := k.photoEventTuple(photoID, eventID, RelationReference)
+	if err != nil {
+		return err
+	}
+	return k.transact(ctx, rts.RelationTupleDelta_ACTION_INSERT, tuple)
 }
 
 // CreatePhotoEventPolaroid 建立照片和事件之間的 polaroid 關係
 //
 // 參數:
+//   - ctx: 請求的 context，用於取消、截止時間和追蹤
 //   - photoID: 照片的唯一標識符
 //   - eventID: 事件的唯一標識符
 //
 // 返回:
-//   - error: 如操作失敗則返回錯誤
-func (k *Client) CreatePhotoEventPolaroid(photoID, eventID string) error {
-	_, err := k.writeClient.TransactRelationTuples(context.Background(), &rts.TransactRelationTuplesRequest{
-		RelationTupleDeltas: []*rts.RelationTupleDelta{
-			{
-				Action: rts.RelationTupleDelta_ACTION_INSERT,
-				RelationTuple: &rts.RelationTuple{
-					Namespace: "Photo",
-					Object:    photoID,
-					Relation:  "polaroid",
-					Subject: &rts.Subject{
-						Ref: &rts.Subject_Id{
-							Id: eventID,
-						},
-					},
-				},
-			},
-		},
-	})
-	return err
+//   - error: 如 ID 無效 (ErrInvalidID) 或操作失敗則返回錯誤
+func (k *Client) CreatePhotoEventPolaroid(ctx context.Context, photoID, eventID string) error {
+	tuple, err := k.photoEventTuple(photoID, eventID, RelationPolaroid)
+	if err != nil {
+		return err
+	}
+	return k.transact(ctx, rts.RelationTupleDelta_ACTION_INSERT, tuple)
 }
 
 // CheckPermission 使用關係查詢來檢查權限
 //
 // 參數:
+//   - ctx: 請求的 context，用於取消、截止時間和追蹤
 //   - namespace: 命名空間 (例如: "Photo")
 //   - object: 對象標識符
 //   - relation: 關係類型 (例如: "reference", "polaroid")
-//   - subject: 主體標識符
+//   - subject: 主體標識符，關係的類型是其他命名空間時 (例如 Photo#reference) 為該命名空間的對象 ID (例如事件 ID)
 //
 // 返回:
 //   - bool: 如果有權限則返回 true
-//   - error: 如查詢失敗則返回錯誤
-func (k *Client) CheckPermission(namespace, object, relation, subject string) (bool, error) {
-	resp, err := k.checkClient.Check(context.Background(), &rts.CheckRequest{
+//   - error: 如 ID 無效 (ErrInvalidID) 或查詢失敗則返回錯誤
+func (k *Client) CheckPermission(ctx context.Context, namespace, object, relation, subject string) (bool, error) {
+	var err error
+	if namespace, err = (IDValidator{}).Normalize("namespace", namespace); err != nil {
+		return false, err
+	}
+	if relation, err = (IDValidator{}).Normalize("relation", relation); err != nil {
+		return false, err
+	}
+	if object, err = k.ids.Normalize("object", object); err != nil {
+		return false, err
+	}
+	if subject, err = k.ids.Normalize("subject", subject); err != nil {
+		return false, err
+	}
+
+	if err := k.lifecycle.acquire(); err != nil {
+		return false, err
+	}
+	defer k.lifecycle.release()
+
+	ctx, timeout, cancel := k.withTimeout(ctx, timeoutCheck)
+	defer cancel()
+
+	if err := k.limiter.wait(ctx, limitCheck); err != nil {
+		return false, timeout.wrap(err)
+	}
+
+	checked := RelationTuple{Namespace: namespace, Object: object, Relation: relation, Subject: subjectFor(namespace, relation, subject)}
+	scoped := k.tenant.scope(checked)
+	start := time.Now()
+	resp, err := k.check(ctx, &rts.CheckRequest{
 		Namespace: namespace,
-		Object:    object,
+		Object:    scoped.Object,
 		Relation:  relation,
-		Subject:   rts.NewSubjectID(subject),
+		Subject:   scoped.Subject.ToProto(),
 	})
 	if err != nil {
+		err = timeout.wrap(err)
+		k.logCall(ctx, "check", start, err, slog.String("tuple", checked.String()))
 		return false, err
 	}
+	k.logCall(ctx, "check", start, nil,
+		slog.String("tuple", checked.String()),
+		slog.Bool("allowed", resp.Allowed),
+	)
 	return resp.Allowed, nil
 }
 
@@ -157,145 +273,79 @@ type PhotoEventRelation struct {
 // BatchCreatePhotoEventReferences 批量建立照片與事件的 reference 關係
 //
 // 參數:
+//   - ctx: 請求的 context，用於取消、截止時間和追蹤
 //   - relations: 要創建的照片-事件關係數組
 //
 // 返回:
-//   - error: 如操作失敗則返回錯誤
-func (k *Client) BatchCreatePhotoEventReferences(relations []PhotoEventRelation) error {
-	deltas := make([]*rts.RelationTupleDelta, 0, len(relations))
-
-	for _, rel := range relations {
-		deltas = append(deltas, &rts.RelationTupleDelta{
-			Action: rts.RelationTupleDelta_ACTION_INSERT,
-			RelationTuple: &rts.RelationTuple{
-				Namespace: "Photo",
-				Object:    rel.PhotoID,
-				Relation:  "reference",
-				Subject: &rts.Subject{
-					Ref: &rts.Subject_Id{
-						Id: rel.EventID,
-					},
-				},
-			},
-		})
-	}
-
-	_, err := k.writeClient.TransactRelationTuples(context.Background(), &rts.TransactRelationTuplesRequest{
-		RelationTupleDeltas: deltas,
-	})
-	return err
+//   - error: 如 ID 無效 (ErrInvalidID) 或操作失敗則返回錯誤
+func (k *Client) BatchCreatePhotoEventReferences(ctx context.Context, relations []PhotoEventRelation) error {
+	tuples, err := k.photoEventTuples(relations, RelationReference)
+	if err != nil {
+		return err
+	}
+	return k.transact(ctx, rts.RelationTupleDelta_ACTION_INSERT, tuples...)
 }
 
 // BatchCreatePhotoEventPolaroids 批量建立照片與事件的 polaroid 關係
 //
 // 參數:
+//   - ctx: 請求的 context，用於取消、截止時間和追蹤
 //   - relations: 要創建的照片-事件關係數組
 //
 // 返回:
-//   - error: 如操作失敗則返回錯誤
-func (k *Client) BatchCreatePhotoEventPolaroids(relations []PhotoEventRelation) error {
-	deltas := make([]*rts.RelationTupleDelta, 0, len(relations))
-
-	for _, rel := range relations {
-		deltas = append(deltas, &rts.RelationTupleDelta{
-			Action: rts.RelationTupleDelta_ACTION_INSERT,
-			RelationTuple: &rts.RelationTuple{
-				Namespace: "Photo",
-				Object:    rel.PhotoID,
-				Relation:  "polaroid",
-				Subject: &rts.Subject{
-					Ref: &rts.Subject_Id{
-						Id: rel.EventID,
-					},
-				},
-			},
-		})
-	}
-
-	_, err := k.writeClient.TransactRelationTuples(context.Background(), &rts.TransactRelationTuplesRequest{
-		RelationTupleDeltas: deltas,
-	})
-	return err
+//   - error: 如 ID 無效 (ErrInvalidID) 或操作失敗則返回錯誤
+func (k *Client) BatchCreatePhotoEventPolaroids(ctx context.Context, relations []PhotoEventRelation) error {
+	tuples, err := k.photoEventTuples(relations, RelationPolaroid)
+	if err != nil {
+		return err
+	}
+	return k.transact(ctx, rts.RelationTupleDelta_ACTION_INSERT, tuples...)
 }
 
 // GetEventReferencePhotos 獲取與特定事件有 reference 關係的所有照片
 //
 // 參數:
+//   - ctx: 請求的 context，用於取消、截止時間和追蹤
 //   - eventID: 事件的唯一標識符
 //
 // 返回:
 //   - []string: 照片 ID 的列表
-//   - error: 如查詢失敗則返回錯誤
-func (k *Client) GetEventReferencePhotos(eventID string) ([]string, error) {
-	resp, err := k.readClient.ListRelationTuples(context.Background(), &rts.ListRelationTuplesRequest{
-		RelationQuery: &rts.RelationQuery{
-			Namespace: strPtr("Photo"),
-			Relation:  strPtr("reference"),
-			Subject: &rts.Subject{
-				Ref: &rts.Subject_Id{
-					Id: eventID,
-				},
-			},
-		},
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	photoIDs := make([]string, len(resp.RelationTuples))
-	for i, tuple := range resp.RelationTuples {
-		photoIDs[i] = tuple.Object
-	}
-
-	return photoIDs, nil
+//   - error: 如 ID 無效 (ErrInvalidID) 或查詢失敗則返回錯誤
+func (k *Client) GetEventReferencePhotos(ctx context.Context, eventID string) ([]string, error) {
+	return k.getEventPhotos(ctx, eventID, RelationReference)
 }
 
 // GetEventPolaroidPhotos 獲取與特定事件有 polaroid 關係的所有照片
 //
 // 參數:
+//   - ctx: 請求的 context，用於取消、截止時間和追蹤
 //   - eventID: 事件的唯一標識符
 //
 // 返回:
 //   - []string: 照片 ID 的列表
-//   - error: 如查詢失敗則返回錯誤
-func (k *Client) GetEventPolaroidPhotos(eventID string) ([]string, error) {
-	resp, err := k.readClient.ListRelationTuples(context.Background(), &rts.ListRelationTuplesRequest{
-		RelationQuery: &rts.RelationQuery{
-			Namespace: strPtr("Photo"),
-			Relation:  strPtr("polaroid"),
-			Subject: &rts.Subject{
-				Ref: &rts.Subject_Id{
-					Id: eventID,
-				},
-			},
-		},
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	photoIDs := make([]string, len(resp.RelationTuples))
-	for i, tuple := range resp.RelationTuples {
-		photoIDs[i] = tuple.Object
-	}
-
-	return photoIDs, nil
+//   - error: 如 ID 無效 (ErrInvalidID) 或查詢失敗則返回錯誤
+func (k *Client) GetEventPolaroidPhotos(ctx context.Context, eventID string) ([]string, error) {
+	return k.getEventPhotos(ctx, eventID, RelationPolaroid)
 }
 
 // GetPhotoEvents 獲取與特定照片有關係的所有事件
 //
 // 參數:
+//   - ctx: 請求的 context，用於取消、截止時間和追蹤
 //   - photoID: 照片的唯一標識符
 //
 // 返回:
 //   - map[string][]string: 按關係類型分類的事件 ID 映射表
-//   - error: 如查詢失敗則返回錯誤
-func (k *Client) GetPhotoEvents(photoID string) (map[string][]string, error) {
-	resp, err := k.readClient.ListRelationTuples(context.Background(), &rts.ListRelationTuplesRequest{
-		RelationQuery: &rts.RelationQuery{
-			Namespace: strPtr("Photo"),
-			Object:    strPtr(photoID),
-		},
+//   - error: 如 ID 無效 (ErrInvalidID) 或查詢失敗則返回錯誤
+func (k *Client) GetPhotoEvents(ctx context.Context, photoID string) (map[string][]string, error) {
+	photoID, err := k.ids.Normalize("photo_id", photoID)
+	if err != nil {
+		return nil, err
+	}
+
+	tuples, err := k.listRelationTuples(ctx, &rts.RelationQuery{
+		Namespace: strPtr(NamespacePhoto),
+		Object:    strPtr(photoID),
 	})
 	if err != nil {
 		return nil, err
@@ -303,15 +353,15 @@ func (k *Client) GetPhotoEvents(photoID string) (map[string][]string, error) {
 
 	// 按關係類型分類事件ID
 	events := map[string][]string{
-		"reference": {},
-		"polaroid":  {},
+		RelationReference: {},
+		RelationPolaroid:  {},
 	}
 
-	for _, tuple := range resp.RelationTuples {
+	for _, tuple := range tuples {
 		switch tuple.Relation {
-		case "reference", "polaroid":
-			if subject, ok := tuple.Subject.Ref.(*rts.Subject_Id); ok {
-				events[tuple.Relation] = append(events[tuple.Relation], subject.Id)
+		case RelationReference, RelationPolaroid:
+			if set := tuple.Subject.Set; set != nil && set.Namespace == NamespaceEvent && set.Relation == "" {
+				events[tuple.Relation] = append(events[tuple.Relation], set.Object)
 			}
 		}
 	}
@@ -322,33 +372,204 @@ func (k *Client) GetPhotoEvents(photoID string) (map[string][]string, error) {
 // DeletePhotoEventRelation 刪除照片和事件之間的關係
 //
 // 參數:
+//   - ctx: 請求的 context，用於取消、截止時間和追蹤
 //   - photoID: 照片的唯一標識符
 //   - eventID: 事件的唯一標識符
 //   - relationType: 關係類型 ("reference" 或 "polaroid")
 //
 // 返回:
-//   - error: 如操作失敗則返回錯誤
-func (k *Client) DeletePhotoEventRelation(photoID, eventID, relationType string) error {
-	_, err := k.writeClient.TransactRelationTuples(context.Background(), &rts.TransactRelationTuplesRequest{
-		RelationTupleDeltas: []*rts.RelationTupleDelta{
-			{
-				Action: rts.RelationTupleDelta_ACTION_DELETE,
-				RelationTuple: &rts.RelationTuple{
-					Namespace: "Photo",
-					Object:    photoID,
-					Relation:  relationType, // "reference" 或 "polaroid"
-					Subject: &rts.Subject{
-						Ref: &rts.Subject_Id{
-							Id: eventID,
-						},
-					},
-				},
-			},
-		},
+//   - error: 如 ID 無效 (ErrInvalidID) 或操作失敗則返回錯誤
+func (k *Client) DeletePhotoEventRelation(ctx context.Context, photoID, eventID, relationType string) error {
+	tuple, err := k.photoEventTuple(photoID, eventID, relationType)
+	if err != nil {
+		return err
+	}
+	return k.transact(ctx, rts.RelationTupleDelta_ACTION_DELETE, tuple)
+}
+
+// photoEventTuple 驗證 ID 並建立照片和事件之間的元組
+func (k *Client) photoEventTuple(photoID, eventID, relation string) (RelationTuple, error) {
+	photoID, err := k.ids.Normalize("photo_id", photoID)
+	if err != nil {
+		return RelationTuple{}, err
+	}
+	eventID, err = k.ids.Normalize("event_id", eventID)
+	if err != nil {
+		return RelationTuple{}, err
+	}
+
+	return RelationTuple{
+		Namespace: NamespacePhoto,
+		Object:    photoID,
+		Relation:  relation,
+		Subject:   eventSubject(eventID),
+	}, nil
+}
+
+// photoEventTuples 驗證 ID 並建立多個照片和事件之間的元組
+func (k *Client) photoEventTuples(relations []PhotoEventRelation, relation string) ([]RelationTuple, error) {
+	tuples := make([]RelationTuple, 0, len(relations))
+	for i, rel := range relations {
+		tuple, err := k.photoEventTuple(rel.PhotoID, rel.EventID, relation)
+		if err != nil {
+			return nil, fmt.Errorf("relations[%d]: %w", i, err)
+		}
+		tuples = append(tuples, tuple)
+	}
+	return tuples, nil
+}
+
+// getEventPhotos 獲取與特定事件有指定關係的所有照片
+func (k *Client) getEventPhotos(ctx context.Context, eventID, relation string) ([]string, error) {
+	eventID, err := k.ids.Normalize("event_id", eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	tuples, err := k.listRelationTuples(ctx, &rts.RelationQuery{
+		Namespace: strPtr(NamespacePhoto),
+		Relation:  strPtr(relation),
+		Subject:   eventSubject(eventID).ToProto(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	photoIDs := make([]string, len(tuples))
+	for i, tuple := range tuples {
+		photoIDs[i] = tuple.Object
+	}
+
+	return photoIDs, nil
+}
+
+// transact 將元組變更寫入 Keto
+// 元組會在發送前加上租戶前綴；試運行時只計算變更，不發送到 Keto
+// 發送前被拒絕 (客戶端已關閉或超出限流) 的變更也會產生審計記錄
+func (k *Client) transact(ctx context.Context, action rts.RelationTupleDelta_Action, tuples ...RelationTuple) error {
+	return k.transactDeltas(ctx, tupleDeltas{action: action, tuples: tuples})
+}
+
+// tupleDeltas 同一類型的一組元組變更
+type tupleDeltas struct {
+	action rts.RelationTupleDelta_Action
+	tuples []RelationTuple
+}
+
+// transactDeltas 在一個事務中寫入多組元組變更，其他行為與 transact 相同
+func (k *Client) transactDeltas(ctx context.Context, groups ...tupleDeltas) error {
+	if k.dryRun || DryRunFromContext(ctx) != nil {
+		for _, g := range groups {
+			if err := k.planDeltas(ctx, g.action, g.tuples); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	audit := func(err error) {
+		for _, g := range groups {
+			k.audit(ctx, g.action, g.tuples, err)
+		}
+	}
+	if err := k.lifecycle.acquire(); err != nil {
+		audit(err)
+		return err
+	}
+	defer k.lifecycle.release()
+
+	var (
+		deltas  []*rts.RelationTupleDelta
+		actions []string
+		tuples  []RelationTuple
+	)
+	for _, g := range groups {
+		actions = append(actions, g.action.String())
+		tuples = append(tuples, g.tuples...)
+		for _, tuple := range g.tuples {
+			deltas = append(deltas, &rts.RelationTupleDelta{
+				Action:        g.action,
+				RelationTuple: k.tenant.scope(tuple).ToProto(),
+			})
+		}
+	}
+
+	class := timeoutWrite
+	if len(deltas) > 1 {
+		class = timeoutBatchWrite
+	}
+	ctx, timeout, cancel := k.withTimeout(ctx, class)
+	defer cancel()
+
+	if err := k.limiter.wait(ctx, limitWrite); err != nil {
+		err = timeout.wrap(err)
+		audit(err)
+		return err
+	}
+
+	start := time.Now()
+	_, err := k.writeClient.TransactRelationTuples(ctx, &rts.TransactRelationTuplesRequest{
+		RelationTupleDeltas: deltas,
 	})
+	err = timeout.wrap(err)
+	k.logCall(ctx, "transact", start, err,
+		slog.String("action", strings.Join(actions, ",")),
+		slog.Any("tuples", tupleStrings(tuples)),
+	)
+	audit(err)
 	return err
 }
 
+// listRelationTuples 查詢符合條件的元組
+// 查詢條件會加上租戶前綴，返回的元組會去除租戶前綴
+func (k *Client) listRelationTuples(ctx context.Context, query *rts.RelationQuery) ([]RelationTuple, error) {
+	tuples, _, err := k.listRelationTuplesPage(ctx, query, "")
+	return tuples, err
+}
+
+// listRelationTuplesPage 查詢符合條件的一頁元組，返回下一頁的 token，沒有下一頁時為空
+func (k *Client) listRelationTuplesPage(ctx context.Context, query *rts.RelationQuery, pageToken string) ([]RelationTuple, string, error) {
+	if err := k.lifecycle.acquire(); err != nil {
+		return nil, "", err
+	}
+	defer k.lifecycle.release()
+
+	ctx, timeout, cancel := k.withTimeout(ctx, timeoutList)
+	defer cancel()
+
+	if err := k.limiter.wait(ctx, limitRead); err != nil {
+		return nil, "", timeout.wrap(err)
+	}
+
+	start := time.Now()
+	resp, err := k.readClient.ListRelationTuples(ctx, &rts.ListRelationTuplesRequest{
+		RelationQuery: k.tenant.scopeQuery(query),
+		PageToken:     pageToken,
+	})
+	if err != nil {
+		err = timeout.wrap(err)
+		k.logCall(ctx, "list", start, err, slog.String("query", queryString(query)))
+		return nil, "", err
+	}
+	k.logCall(ctx, "list", start, nil,
+		slog.String("query", queryString(query)),
+		slog.Int("count", len(resp.RelationTuples)),
+	)
+
+	tuples := make([]RelationTuple, 0, len(resp.RelationTuples))
+	for _, t := range resp.RelationTuples {
+		tuple, err := RelationTupleFromProto(t)
+		if err != nil {
+			return nil, "", err
+		}
+		if tuple, err = k.tenant.unscope(tuple); err != nil {
+			return nil, "", err
+		}
+		tuples = append(tuples, tuple)
+	}
+	return tuples, resp.NextPageToken, nil
+}
+
 // strPtr 輔助函數，用於返回字符串的指針
 func strPtr(s string) *string {
 	return &s
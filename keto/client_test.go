@@ -80,13 +80,6 @@ func (m *MockVersionServiceClient) GetVersion(ctx context.Context, in *rts.GetVe
 	return args.Get(0).(*rts.GetVersionResponse), args.Error(1)
 }
 
-// 測試 NewClient
-// 略過覆蓋率檢查，因為連接到 gRPC 服務器的代碼難以進行單元測試
-// 手動測試函數以確保其正確性但不計入代碼覆蓋率
-func TestNewClient_Skipped(t *testing.T) {
-	t.Skip("Skipping NewClient test as it requires actual gRPC connections")
-}
-
 func TestCreatePhotoEventReference(t *testing.T) {
 	// 設置模擬客戶端
 	mockWriteClient := new(MockWriteServiceClient)
@@ -117,11 +110,12 @@ func TestCreatePhotoEventReference(t *testing.T) {
 		return delta.RelationTuple.Namespace == "Photo" &&
 			delta.RelationTuple.Object == "photo1" &&
 			delta.RelationTuple.Relation == "reference" &&
-			delta.RelationTuple.Subject.GetId() == "event1"
+			delta.RelationTuple.Subject.GetSet().GetNamespace() == "Event" &&
+			delta.RelationTuple.Subject.GetSet().GetObject() == "event1"
 	})).Return(&rts.TransactRelationTuplesResponse{}, nil)
 
 	// 執行測試
-	err := client.CreatePhotoEventReference("photo1", "event1")
+	err := client.CreatePhotoEventReference(context.Background(), "photo1", "event1")
 
 	// 驗證結果
 	assert.NoError(t, err)
@@ -158,11 +152,12 @@ func TestCreatePhotoEventPolaroid(t *testing.T) {
 		return delta.RelationTuple.Namespace == "Photo" &&
 			delta.RelationTuple.Object == "photo1" &&
 			delta.RelationTuple.Relation == "polaroid" &&
-			delta.RelationTuple.Subject.GetId() == "event1"
+			delta.RelationTuple.Subject.GetSet().GetNamespace() == "Event" &&
+			delta.RelationTuple.Subject.GetSet().GetObject() == "event1"
 	})).Return(&rts.TransactRelationTuplesResponse{}, nil)
 
 	// 執行測試
-	err := client.CreatePhotoEventPolaroid("photo1", "event1")
+	err := client.CreatePhotoEventPolaroid(context.Background(), "photo1", "event1")
 
 	// 驗證結果
 	assert.NoError(t, err)
@@ -194,7 +189,7 @@ func TestCheckPermission_Error(t *testing.T) {
 	mockCheckClient.On("Check", mock.Anything, mock.Anything).Return((*rts.CheckResponse)(nil), testError)
 
 	// 執行測試 - 這會覆蓋 111 行的 return false, err
-	allowed, err := client.CheckPermission("Photo", "photo1", "reference", "event1")
+	allowed, err := client.CheckPermission(context.Background(), "Photo", "photo1", "reference", "event1")
 
 	// 驗證結果
 	assert.Equal(t, testError, err)
@@ -232,11 +227,12 @@ func TestCheckPermission(t *testing.T) {
 			return req.Namespace == "Photo" &&
 				req.Object == "photo1" &&
 				req.Relation == "reference" &&
-				req.Subject.GetId() == "event1"
+				req.Subject.GetSet().GetNamespace() == "Event" &&
+				req.Subject.GetSet().GetObject() == "event1"
 		})).Return(mockResponse, nil).Once()
 
 		// 執行測試
-		allowed, err := client.CheckPermission("Photo", "photo1", "reference", "event1")
+		allowed, err := client.CheckPermission(context.Background(), "Photo", "photo1", "reference", "event1")
 
 		// 驗證結果
 		assert.NoError(t, err)
@@ -255,11 +251,12 @@ func TestCheckPermission(t *testing.T) {
 			return req.Namespace == "Photo" &&
 				req.Object == "photo1" &&
 				req.Relation == "reference" &&
-				req.Subject.GetId() == "event2"
+				req.Subject.GetSet().GetNamespace() == "Event" &&
+				req.Subject.GetSet().GetObject() == "event2"
 		})).Return(mockResponse, nil).Once()
 
 		// 執行測試
-		allowed, err := client.CheckPermission("Photo", "photo1", "reference", "event2")
+		allowed, err := client.CheckPermission(context.Background(), "Photo", "photo1", "reference", "event2")
 
 		// 驗證結果
 		assert.NoError(t, err)
@@ -294,21 +291,13 @@ func TestGetEventReferencePhotos(t *testing.T) {
 				Namespace: "Photo",
 				Object:    "photo1",
 				Relation:  "reference",
-				Subject: &rts.Subject{
-					Ref: &rts.Subject_Id{
-						Id: "event1",
-					},
-				},
+				Subject:   rts.NewSubjectSet("Event", "event1", ""),
 			},
 			{
 				Namespace: "Photo",
 				Object:    "photo2",
 				Relation:  "reference",
-				Subject: &rts.Subject{
-					Ref: &rts.Subject_Id{
-						Id: "event1",
-					},
-				},
+				Subject:   rts.NewSubjectSet("Event", "event1", ""),
 			},
 		},
 	}
@@ -316,7 +305,7 @@ func TestGetEventReferencePhotos(t *testing.T) {
 	mockReadClient.On("ListRelationTuples", mock.Anything, mock.Anything).Return(mockResponse, nil)
 
 	// 執行測試
-	photos, err := client.GetEventReferencePhotos("event1")
+	photos, err := client.GetEventReferencePhotos(context.Background(), "event1")
 
 	// 驗證結果
 	assert.NoError(t, err)
@@ -351,7 +340,7 @@ func TestGetEventReferencePhotos_Error(t *testing.T) {
 	mockReadClient.On("ListRelationTuples", mock.Anything, mock.Anything).Return((*rts.ListRelationTuplesResponse)(nil), testError)
 
 	// 執行測試 - 這會覆蓋 188 行的 return nil, err
-	photos, err := client.GetEventReferencePhotos("event1")
+	photos, err := client.GetEventReferencePhotos(context.Background(), "event1")
 
 	// 驗證結果
 	assert.Equal(t, testError, err)
@@ -385,21 +374,13 @@ func TestGetEventPolaroidPhotos(t *testing.T) {
 				Namespace: "Photo",
 				Object:    "photo1",
 				Relation:  "polaroid",
-				Subject: &rts.Subject{
-					Ref: &rts.Subject_Id{
-						Id: "event1",
-					},
-				},
+				Subject:   rts.NewSubjectSet("Event", "event1", ""),
 			},
 			{
 				Namespace: "Photo",
 				Object:    "photo3",
 				Relation:  "polaroid",
-				Subject: &rts.Subject{
-					Ref: &rts.Subject_Id{
-						Id: "event1",
-					},
-				},
+				Subject:   rts.NewSubjectSet("Event", "event1", ""),
 			},
 		},
 	}
@@ -407,7 +388,7 @@ func TestGetEventPolaroidPhotos(t *testing.T) {
 	mockReadClient.On("ListRelationTuples", mock.Anything, mock.Anything).Return(mockResponse, nil)
 
 	// 執行測試
-	photos, err := client.GetEventPolaroidPhotos("event1")
+	photos, err := client.GetEventPolaroidPhotos(context.Background(), "event1")
 
 	// 驗證結果
 	assert.NoError(t, err)
@@ -443,21 +424,13 @@ func TestGetPhotoEvents(t *testing.T) {
 				Namespace: "Photo",
 				Object:    "photo1",
 				Relation:  "reference",
-				Subject: &rts.Subject{
-					Ref: &rts.Subject_Id{
-						Id: "event1",
-					},
-				},
+				Subject:   rts.NewSubjectSet("Event", "event1", ""),
 			},
 			{
 				Namespace: "Photo",
 				Object:    "photo1",
 				Relation:  "polaroid",
-				Subject: &rts.Subject{
-					Ref: &rts.Subject_Id{
-						Id: "event2",
-					},
-				},
+				Subject:   rts.NewSubjectSet("Event", "event2", ""),
 			},
 		},
 	}
@@ -465,7 +438,7 @@ func TestGetPhotoEvents(t *testing.T) {
 	mockReadClient.On("ListRelationTuples", mock.Anything, mock.Anything).Return(mockResponse, nil)
 
 	// 執行測試
-	events, err := client.GetPhotoEvents("photo1")
+	events, err := client.GetPhotoEvents(context.Background(), "photo1")
 
 	// 驗證結果
 	assert.NoError(t, err)
@@ -501,7 +474,7 @@ func TestGetPhotoEvents_Error(t *testing.T) {
 	mockReadClient.On("ListRelationTuples", mock.Anything, mock.Anything).Return((*rts.ListRelationTuplesResponse)(nil), testError)
 
 	// 執行測試 - 這會覆蓋 213 行的 return nil, err
-	events, err := client.GetPhotoEvents("photo1")
+	events, err := client.GetPhotoEvents(context.Background(), "photo1")
 
 	// 驗證結果
 	assert.Equal(t, testError, err)
@@ -549,7 +522,7 @@ func TestBatchCreatePhotoEventReferences(t *testing.T) {
 		{PhotoID: "photo1", EventID: "event1"},
 		{PhotoID: "photo2", EventID: "event1"},
 	}
-	err := client.BatchCreatePhotoEventReferences(relations)
+	err := client.BatchCreatePhotoEventReferences(context.Background(), relations)
 
 	// 驗證結果
 	assert.NoError(t, err)
@@ -596,7 +569,7 @@ func TestBatchCreatePhotoEventPolaroids(t *testing.T) {
 		{PhotoID: "photo1", EventID: "event1"},
 		{PhotoID: "photo2", EventID: "event1"},
 	}
-	err := client.BatchCreatePhotoEventPolaroids(relations)
+	err := client.BatchCreatePhotoEventPolaroids(context.Background(), relations)
 
 	// 驗證結果
 	assert.NoError(t, err)
@@ -635,11 +608,12 @@ func TestDeletePhotoEventRelation(t *testing.T) {
 			delta.RelationTuple.Namespace == "Photo" &&
 			delta.RelationTuple.Object == "photo1" &&
 			delta.RelationTuple.Relation == "reference" &&
-			delta.RelationTuple.Subject.GetId() == "event1"
+			delta.RelationTuple.Subject.GetSet().GetNamespace() == "Event" &&
+			delta.RelationTuple.Subject.GetSet().GetObject() == "event1"
 	})).Return(&rts.TransactRelationTuplesResponse{}, nil)
 
 	// 執行測試
-	err := client.DeletePhotoEventRelation("photo1", "event1", "reference")
+	err := client.DeletePhotoEventRelation(context.Background(), "photo1", "event1", "reference")
 
 	// 驗證結果
 	assert.NoError(t, err)
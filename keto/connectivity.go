@@ -0,0 +1,147 @@
+package keto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// ErrNotReady 連接在指定時間內沒有就緒
+var ErrNotReady = errors.New("keto: 連接未就緒")
+
+// 連接的角色
+const (
+	ConnWrite = "write"
+	ConnRead  = "read"
+)
+
+// DefaultConnectTimeout WithEagerConnect 未指定時間時的默認等待時間
+const DefaultConnectTimeout = 10 * time.Second
+
+// WithEagerConnect 讓 NewClient 立即建立所有連接，並等待它們就緒
+// 連接在 timeout 內沒有就緒時，NewClient 返回 ErrNotReady；timeout <= 0 時使用 DefaultConnectTimeout
+// 默認在第一次調用時才建立連接
+func WithEagerConnect(timeout time.Duration) Option {
+	return func(o *options) {
+		o.eagerConnect = true
+		o.connectTimeout = timeout
+	}
+}
+
+// WithWaitForReady 設置調用的默認 WaitForReady 行為
+// 為 true 時，連接暫時不可用的調用會等待連接就緒 (直到 context 結束)，而不是立即返回 Unavailable
+// 單次調用可以使用 ContextWithWaitForReady 覆蓋
+func WithWaitForReady(waitForReady bool) Option {
+	return func(o *options) {
+		o.waitForReady = &waitForReady
+	}
+}
+
+// waitForReadyKey context 中 WaitForReady 設置的鍵
+type waitForReadyKey struct{}
+
+// ContextWithWaitForReady 返回為此次調用設置 WaitForReady 的 context
+func ContextWithWaitForReady(ctx context.Context, waitForReady bool) context.Context {
+	return context.WithValue(ctx, waitForReadyKey{}, waitForReady)
+}
+
+// waitForReadyInterceptor 根據 context 覆蓋調用的 WaitForReady
+func waitForReadyInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if waitForReady, ok := ctx.Value(waitForReadyKey{}).(bool); ok {
+		opts = append(opts, grpc.WaitForReady(waitForReady))
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// ConnectivityEvent 連接狀態的變化
+type ConnectivityEvent struct {
+	Role    string             // ConnWrite 或 ConnRead
+	Address string             // 連接的地址
+	State   connectivity.State // 新的狀態
+}
+
+// roleConn 帶有角色的連接
+type roleConn struct {
+	role string
+	conn *grpc.ClientConn
+}
+
+// conns 返回客戶端的所有連接
+func (k *Client) conns() []roleConn {
+	var conns []roleConn
+	if k.writeConn != nil {
+		conns = append(conns, roleConn{role: ConnWrite, conn: k.writeConn})
+	}
+	for _, conn := range k.readConns {
+		conns = append(conns, roleConn{role: ConnRead, conn: conn})
+	}
+	return conns
+}
+
+// WatchConnectivity 訂閱所有連接的狀態變化
+// 返回的 channel 先發送每個連接的當前狀態，之後每次狀態變化發送一個事件
+// ctx 結束或客戶端關閉後 channel 會被關閉；接收方處理過慢時會阻塞後續事件
+// REST 傳輸沒有連接狀態，返回的 channel 會立即關閉
+func (k *Client) WatchConnectivity(ctx context.Context) <-chan ConnectivityEvent {
+	events := make(chan ConnectivityEvent)
+
+	var wg sync.WaitGroup
+	for _, rc := range k.conns() {
+		wg.Add(1)
+		go func(role string, conn *grpc.ClientConn) {
+			defer wg.Done()
+			state := conn.GetState()
+			for {
+				event := ConnectivityEvent{Role: role, Address: conn.Target(), State: state}
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+				if state == connectivity.Shutdown || !conn.WaitForStateChange(ctx, state) {
+					return
+				}
+				state = conn.GetState()
+			}
+		}(rc.role, rc.conn)
+	}
+
+	go func() {
+		wg.Wait()
+		close(events)
+	}()
+	return events
+}
+
+// waitUntilReady 建立所有連接並等待它們就緒
+// REST 傳輸沒有連接狀態，改為檢查 Keto 的 /health/ready 端點
+func (k *Client) waitUntilReady(ctx context.Context) error {
+	for _, conn := range k.restConns {
+		if err := conn.ready(ctx); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrNotReady, conn.base, err)
+		}
+	}
+
+	conns := k.conns()
+	for _, rc := range conns {
+		rc.conn.Connect()
+	}
+	for _, rc := range conns {
+		conn := rc.conn
+		for {
+			state := conn.GetState()
+			if state == connectivity.Ready {
+				break
+			}
+			if !conn.WaitForStateChange(ctx, state) {
+				return fmt.Errorf("%w: %s 連接 %s 的狀態為 %s", ErrNotReady, rc.role, conn.Target(), state)
+			}
+		}
+	}
+	return nil
+}
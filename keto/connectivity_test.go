@@ -0,0 +1,115 @@
+package keto
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/status"
+)
+
+// unusedAddress 返回沒有服務監聽的本地地址
+func unusedAddress(t *testing.T) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := lis.Addr().String()
+	lis.Close()
+	return addr
+}
+
+func TestNewClient_EagerConnect(t *testing.T) {
+	_, addr := startCheckServer(t)
+
+	client, err := NewClient(addr, addr, WithEagerConnect(5*time.Second))
+	require.NoError(t, err)
+	defer client.Close(context.Background())
+
+	assert.Equal(t, connectivity.Ready, client.writeConn.GetState())
+	assert.Equal(t, connectivity.Ready, client.readConns[0].GetState())
+}
+
+func TestNewClient_EagerConnectTimeout(t *testing.T) {
+	addr := unusedAddress(t)
+
+	start := time.Now()
+	_, err := NewClient(addr, addr, WithEagerConnect(100*time.Millisecond))
+
+	assert.True(t, errors.Is(err, ErrNotReady), "err = %v", err)
+	assert.Contains(t, err.Error(), addr)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestWatchConnectivity(t *testing.T) {
+	_, addr := startCheckServer(t)
+
+	client, err := NewClient(addr, addr)
+	require.NoError(t, err)
+	defer client.Close(context.Background())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := client.WatchConnectivity(ctx)
+
+	// 初始狀態為 Idle，調用後連接變為 Ready
+	ready := map[string]bool{}
+	for event := range events {
+		assert.Equal(t, addr, event.Address)
+		if event.State == connectivity.Idle {
+			client.writeConn.Connect()
+			client.readConns[0].Connect()
+		}
+		if event.State == connectivity.Ready {
+			ready[event.Role] = true
+		}
+		if ready[ConnWrite] && ready[ConnRead] {
+			break
+		}
+	}
+	assert.True(t, ready[ConnWrite])
+	assert.True(t, ready[ConnRead])
+
+	// ctx 結束後 channel 被關閉
+	cancel()
+	for range events {
+	}
+}
+
+func TestWaitForReady(t *testing.T) {
+	addr := unusedAddress(t)
+
+	client, err := NewClient(addr, addr)
+	require.NoError(t, err)
+	defer client.Close(context.Background())
+
+	// 默認在連接不可用時立即失敗
+	_, err = client.CheckPermission(context.Background(), "Photo", "photo1", "reference", "event1")
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	// 單次調用等待連接就緒，直到 context 結束
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_, err = client.CheckPermission(ContextWithWaitForReady(ctx, true), "Photo", "photo1", "reference", "event1")
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+}
+
+func TestWithWaitForReady(t *testing.T) {
+	addr := unusedAddress(t)
+
+	client, err := NewClient(addr, addr, WithWaitForReady(true))
+	require.NoError(t, err)
+	defer client.Close(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_, err = client.CheckPermission(ctx, "Photo", "photo1", "reference", "event1")
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+
+	// 單次調用可以覆蓋默認設置
+	_, err = client.CheckPermission(ContextWithWaitForReady(context.Background(), false), "Photo", "photo1", "reference", "event1")
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
@@ -0,0 +1,428 @@
+package keto
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AidChen0509/oosa_ketosdk/ketotest"
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 設置以下環境變量後，契約測試連接真實的 Keto 而不是 ketotest：
+//
+//	KETO_TEST_WRITE_ADDR=localhost:4467 KETO_TEST_READ_ADDR=localhost:4466 go test ./keto -run TestContract
+//
+// KETO_TEST_TRANSPORT=rest 時使用 REST 傳輸，地址為 Keto 的 HTTP 地址。
+// 測試套件運行兩次：一次使用普通客戶端，ID 加上隨機前綴；一次使用隨機的租戶。
+// 兩種方式都在子測試結束時刪除寫入的元組。
+const (
+	envTestWriteAddr = "KETO_TEST_WRITE_ADDR"
+	envTestReadAddr  = "KETO_TEST_READ_ADDR"
+	envTestTransport = "KETO_TEST_TRANSPORT"
+)
+
+// contractTarget 為每個子測試返回一個連接到空存儲的客戶端，以及把測試使用的 ID 轉換為實際 ID 的函數
+type contractTarget func(t *testing.T) (*Client, func(string) string)
+
+// sameID 不轉換 ID，用於獨佔存儲或已限定在租戶內的客戶端
+func sameID(id string) string { return id }
+
+// fakeTarget 通過 gRPC 連接到 ketotest 的內存服務器
+func fakeTarget(t *testing.T) (*Client, func(string) string) {
+	srv := ketotest.NewServer(ketotest.WithSchema(Schema()))
+	t.Cleanup(srv.Close)
+
+	client, err := NewClient(srv.Addr(), srv.Addr(), WithDialOptions(srv.DialOptions()...))
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close(context.Background()) })
+	return client, sameID
+}
+
+// fakeRESTTarget 通過 REST 連接到 ketotest 的內存服務器
+func fakeRESTTarget(t *testing.T) (*Client, func(string) string) {
+	srv := ketotest.NewServer(ketotest.WithSchema(Schema()))
+	t.Cleanup(srv.Close)
+	ts := httptest.NewServer(srv.HTTPHandler())
+	t.Cleanup(ts.Close)
+
+	client, err := NewClient(ts.URL, ts.URL, WithTransport(TransportREST))
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close(context.Background()) })
+	return client, sameID
+}
+
+// randomID 返回以 prefix 開頭的隨機 ID
+func randomID(t *testing.T, prefix string) string {
+	buf := make([]byte, 8)
+	_, err := rand.Read(buf)
+	require.NoError(t, err)
+	return prefix + hex.EncodeToString(buf)
+}
+
+// realTenantTarget 連接到環境變量指定的 Keto，並限定在隨機的租戶內
+func realTenantTarget(writeAddr, readAddr string, transport Transport) contractTarget {
+	return func(t *testing.T) (*Client, func(string) string) {
+		base, err := NewClient(writeAddr, readAddr, WithTransport(transport), WithEagerConnect(DefaultConnectTimeout))
+		require.NoError(t, err)
+
+		tenantID := randomID(t, "contract-")
+		client, err := base.ForTenant(tenantID)
+		require.NoError(t, err)
+
+		t.Cleanup(func() {
+			cleanupPrefix(t, base, tenant(tenantID).prefix())
+			base.Close(context.Background())
+		})
+		return client, sameID
+	}
+}
+
+// realPlainTarget 連接到環境變量指定的 Keto，不使用租戶
+// 測試使用的 ID 加上隨機前綴，避免與 Keto 中已有的元組或其他子測試衝突
+func realPlainTarget(writeAddr, readAddr string, transport Transport) contractTarget {
+	return func(t *testing.T) (*Client, func(string) string) {
+		client, err := NewClient(writeAddr, readAddr, WithTransport(transport), WithEagerConnect(DefaultConnectTimeout))
+		require.NoError(t, err)
+		// 子測試可能關閉 client，清理使用單獨的客戶端
+		cleaner, err := NewClient(writeAddr, readAddr, WithTransport(transport))
+		require.NoError(t, err)
+
+		prefix := randomID(t, "contract-") + "-"
+		t.Cleanup(func() {
+			cleanupPrefix(t, cleaner, prefix)
+			cleaner.Close(context.Background())
+			client.Close(context.Background())
+		})
+		return client, func(id string) string { return prefix + id }
+	}
+}
+
+// cleanupPrefix 刪除對象 ID 以 prefix 開頭的所有照片和事件元組
+func cleanupPrefix(t *testing.T, base *Client, prefix string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var deltas []*rts.RelationTupleDelta
+	for _, namespace := range []string{NamespacePhoto, NamespaceEvent} {
+		pageToken := ""
+		for {
+			resp, err := base.readClient.ListRelationTuples(ctx, &rts.ListRelationTuplesRequest{
+				RelationQuery: &rts.RelationQuery{Namespace: strPtr(namespace)},
+				PageToken:     pageToken,
+			})
+			if err != nil {
+				t.Logf("清理 %s 失敗: %v", prefix, err)
+				return
+			}
+			for _, tuple := range resp.RelationTuples {
+				if strings.HasPrefix(tuple.Object, prefix) {
+					deltas = append(deltas, &rts.RelationTupleDelta{Action: rts.RelationTupleDelta_ACTION_DELETE, RelationTuple: tuple})
+				}
+			}
+			if pageToken = resp.NextPageToken; pageToken == "" {
+				break
+			}
+		}
+	}
+	if len(deltas) == 0 {
+		return
+	}
+	if _, err := base.writeClient.TransactRelationTuples(ctx, &rts.TransactRelationTuplesRequest{RelationTupleDeltas: deltas}); err != nil {
+		t.Logf("清理 %s 失敗: %v", prefix, err)
+	}
+}
+
+// 測試客戶端在 ketotest 和真實 Keto 上的行為一致
+func TestContract(t *testing.T) {
+	writeAddr, readAddr := os.Getenv(envTestWriteAddr), os.Getenv(envTestReadAddr)
+	if writeAddr != "" || readAddr != "" {
+		require.NotEmpty(t, writeAddr, envTestWriteAddr+" 不能為空")
+		require.NotEmpty(t, readAddr, envTestReadAddr+" 不能為空")
+		transport := Transport(os.Getenv(envTestTransport))
+		t.Run("Plain", func(t *testing.T) { runContract(t, realPlainTarget(writeAddr, readAddr, transport)) })
+		t.Run("Tenant", func(t *testing.T) { runContract(t, realTenantTarget(writeAddr, readAddr, transport)) })
+		return
+	}
+	t.Run("GRPC", func(t *testing.T) { runContract(t, fakeTarget) })
+	t.Run("REST", func(t *testing.T) { runContract(t, fakeRESTTarget) })
+}
+
+// runContract 對目標執行所有契約測試
+func runContract(t *testing.T, newClient contractTarget) {
+	ctx := context.Background()
+
+	t.Run("CreateAndListReference", func(t *testing.T) {
+		client, id := newClient(t)
+
+		require.NoError(t, client.CreatePhotoEventReference(ctx, id("photo1"), id("event1")))
+		require.NoError(t, client.CreatePhotoEventReference(ctx, id("photo2"), id("event1")))
+		require.NoError(t, client.CreatePhotoEventReference(ctx, id("photo3"), id("event2")))
+
+		photos, err := client.GetEventReferencePhotos(ctx, id("event1"))
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{id("photo1"), id("photo2")}, photos)
+
+		polaroids, err := client.GetEventPolaroidPhotos(ctx, id("event1"))
+		require.NoError(t, err)
+		assert.Empty(t, polaroids)
+	})
+
+	t.Run("CreateAndListPolaroid", func(t *testing.T) {
+		client, id := newClient(t)
+
+		require.NoError(t, client.CreatePhotoEventPolaroid(ctx, id("photo1"), id("event1")))
+
+		polaroids, err := client.GetEventPolaroidPhotos(ctx, id("event1"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{id("photo1")}, polaroids)
+
+		photos, err := client.GetEventReferencePhotos(ctx, id("event1"))
+		require.NoError(t, err)
+		assert.Empty(t, photos)
+	})
+
+	t.Run("DuplicateCreateIsIdempotent", func(t *testing.T) {
+		client, id := newClient(t)
+
+		require.NoError(t, client.CreatePhotoEventReference(ctx, id("photo1"), id("event1")))
+		require.NoError(t, client.CreatePhotoEventReference(ctx, id("photo1"), id("event1")))
+
+		photos, err := client.GetEventReferencePhotos(ctx, id("event1"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{id("photo1")}, photos)
+	})
+
+	t.Run("BatchCreate", func(t *testing.T) {
+		client, id := newClient(t)
+
+		require.NoError(t, client.BatchCreatePhotoEventReferences(ctx, []PhotoEventRelation{
+			{PhotoID: id("photo1"), EventID: id("event1")},
+			{PhotoID: id("photo2"), EventID: id("event1")},
+		}))
+		require.NoError(t, client.BatchCreatePhotoEventPolaroids(ctx, []PhotoEventRelation{
+			{PhotoID: id("photo3"), EventID: id("event1")},
+		}))
+
+		photos, err := client.GetEventReferencePhotos(ctx, id("event1"))
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{id("photo1"), id("photo2")}, photos)
+
+		polaroids, err := client.GetEventPolaroidPhotos(ctx, id("event1"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{id("photo3")}, polaroids)
+	})
+
+	t.Run("BatchCreateValidatesAllItems", func(t *testing.T) {
+		client, id := newClient(t)
+
+		err := client.BatchCreatePhotoEventReferences(ctx, []PhotoEventRelation{
+			{PhotoID: id("photo1"), EventID: id("event1")},
+			{PhotoID: "photo:2", EventID: id("event1")},
+		})
+		assert.ErrorIs(t, err, ErrInvalidID)
+
+		photos, err := client.GetEventReferencePhotos(ctx, id("event1"))
+		require.NoError(t, err)
+		assert.Empty(t, photos)
+	})
+
+	t.Run("GetPhotoEvents", func(t *testing.T) {
+		client, id := newClient(t)
+
+		require.NoError(t, client.CreatePhotoEventReference(ctx, id("photo1"), id("event1")))
+		require.NoError(t, client.CreatePhotoEventReference(ctx, id("photo1"), id("event2")))
+		require.NoError(t, client.CreatePhotoEventPolaroid(ctx, id("photo1"), id("event3")))
+
+		events, err := client.GetPhotoEvents(ctx, id("photo1"))
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{id("event1"), id("event2")}, events[RelationReference])
+		assert.Equal(t, []string{id("event3")}, events[RelationPolaroid])
+
+		events, err = client.GetPhotoEvents(ctx, id("photo2"))
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]string{RelationReference: {}, RelationPolaroid: {}}, events)
+	})
+
+	t.Run("CheckPermission", func(t *testing.T) {
+		client, id := newClient(t)
+
+		require.NoError(t, client.CreatePhotoEventReference(ctx, id("photo1"), id("event1")))
+
+		allowed, err := client.CheckPermission(ctx, NamespacePhoto, id("photo1"), RelationReference, id("event1"))
+		require.NoError(t, err)
+		assert.True(t, allowed)
+
+		allowed, err = client.CheckPermission(ctx, NamespacePhoto, id("photo1"), RelationPolaroid, id("event1"))
+		require.NoError(t, err)
+		assert.False(t, allowed)
+
+		allowed, err = client.CheckPermission(ctx, NamespacePhoto, id("photo1"), RelationReference, id("event2"))
+		require.NoError(t, err)
+		assert.False(t, allowed)
+	})
+
+	t.Run("PhotoViewForEventMembers", func(t *testing.T) {
+		client, id := newClient(t)
+
+		// 事件成員通過 reference 和 polaroid 關係獲得照片的 view 權限
+		require.NoError(t, client.transact(ctx, rts.RelationTupleDelta_ACTION_INSERT,
+			RelationTuple{Namespace: NamespaceEvent, Object: id("event1"), Relation: "members", Subject: SubjectID(id("alice"))},
+			RelationTuple{Namespace: NamespaceEvent, Object: id("event2"), Relation: "members", Subject: SubjectID(id("bob"))},
+		))
+		require.NoError(t, client.CreatePhotoEventReference(ctx, id("photo1"), id("event1")))
+		require.NoError(t, client.CreatePhotoEventPolaroid(ctx, id("photo2"), id("event2")))
+
+		for _, tt := range []struct {
+			photo, user string
+			want        bool
+		}{
+			{id("photo1"), id("alice"), true},
+			{id("photo2"), id("bob"), true},
+			{id("photo1"), id("bob"), false},
+			{id("photo2"), id("alice"), false},
+		} {
+			allowed, err := client.CheckPermission(ctx, NamespacePhoto, tt.photo, "view", tt.user)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, allowed, "%s 查看 %s", tt.user, tt.photo)
+		}
+
+		events, err := client.GetPhotoEvents(ctx, id("photo1"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{id("event1")}, events[RelationReference])
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		client, id := newClient(t)
+
+		require.NoError(t, client.CreatePhotoEventReference(ctx, id("photo1"), id("event1")))
+		require.NoError(t, client.CreatePhotoEventPolaroid(ctx, id("photo1"), id("event1")))
+		require.NoError(t, client.DeletePhotoEventRelation(ctx, id("photo1"), id("event1"), RelationReference))
+
+		photos, err := client.GetEventReferencePhotos(ctx, id("event1"))
+		require.NoError(t, err)
+		assert.Empty(t, photos)
+
+		// 只刪除指定的關係
+		polaroids, err := client.GetEventPolaroidPhotos(ctx, id("event1"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{id("photo1")}, polaroids)
+
+		// 刪除不存在的關係不報錯
+		assert.NoError(t, client.DeletePhotoEventRelation(ctx, id("photo1"), id("event1"), RelationReference))
+	})
+
+	t.Run("InvalidIDs", func(t *testing.T) {
+		client, id := newClient(t)
+
+		assert.ErrorIs(t, client.CreatePhotoEventReference(ctx, "photo 1", id("event1")), ErrInvalidID)
+		assert.ErrorIs(t, client.CreatePhotoEventPolaroid(ctx, id("photo1"), ""), ErrInvalidID)
+		assert.ErrorIs(t, client.DeletePhotoEventRelation(ctx, id("photo1"), "event#1", RelationReference), ErrInvalidID)
+
+		_, err := client.GetEventReferencePhotos(ctx, "event@1")
+		assert.ErrorIs(t, err, ErrInvalidID)
+		_, err = client.GetPhotoEvents(ctx, "")
+		assert.ErrorIs(t, err, ErrInvalidID)
+		_, err = client.CheckPermission(ctx, NamespacePhoto, id("photo1"), RelationReference, "a:b")
+		assert.ErrorIs(t, err, ErrInvalidID)
+	})
+
+	t.Run("DryRun", func(t *testing.T) {
+		client, id := newClient(t)
+
+		require.NoError(t, client.CreatePhotoEventReference(ctx, id("photo1"), id("event1")))
+
+		dryCtx, plan := ContextWithDryRun(ctx)
+		require.NoError(t, client.CreatePhotoEventReference(dryCtx, id("photo1"), id("event1")))
+		require.NoError(t, client.CreatePhotoEventReference(dryCtx, id("photo2"), id("event1")))
+		require.NoError(t, client.DeletePhotoEventRelation(dryCtx, id("photo1"), id("event1"), RelationReference))
+
+		deltas := plan.Deltas()
+		require.Len(t, deltas, 3)
+		assert.False(t, deltas[0].Changed, "已存在的元組")
+		assert.True(t, deltas[1].Changed)
+		assert.True(t, deltas[2].Changed)
+
+		// 沒有任何寫入
+		photos, err := client.GetEventReferencePhotos(ctx, id("event1"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{id("photo1")}, photos)
+	})
+
+	t.Run("TenantIsolation", func(t *testing.T) {
+		client, id := newClient(t)
+
+		if client.Tenant() != "" {
+			t.Skip("客戶端已限定在租戶內")
+		}
+		other, err := client.ForTenant(id("other"))
+		require.NoError(t, err)
+		assert.Equal(t, id("other"), other.Tenant())
+
+		require.NoError(t, client.CreatePhotoEventReference(ctx, id("photo1"), id("event1")))
+		require.NoError(t, other.CreatePhotoEventReference(ctx, id("photo2"), id("event1")))
+
+		photos, err := other.GetEventReferencePhotos(ctx, id("event1"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{id("photo2")}, photos)
+
+		allowed, err := other.CheckPermission(ctx, NamespacePhoto, id("photo1"), RelationReference, id("event1"))
+		require.NoError(t, err)
+		assert.False(t, allowed)
+
+		events, err := other.GetPhotoEvents(ctx, id("photo1"))
+		require.NoError(t, err)
+		assert.Empty(t, events[RelationReference])
+	})
+
+	t.Run("WatchConnectivity", func(t *testing.T) {
+		client, _ := newClient(t)
+		if len(client.conns()) == 0 {
+			t.Skip("REST 傳輸沒有連接狀態")
+		}
+
+		watchCtx, cancel := context.WithCancel(ctx)
+		events := client.WatchConnectivity(watchCtx)
+
+		roles := map[string]bool{}
+		for len(roles) < 2 {
+			select {
+			case event := <-events:
+				roles[event.Role] = true
+			case <-time.After(5 * time.Second):
+				t.Fatal("沒有收到連接狀態")
+			}
+		}
+		assert.True(t, roles[ConnWrite])
+		assert.True(t, roles[ConnRead])
+		assert.Nil(t, client.ReadReplicas())
+
+		cancel()
+		for range events {
+		}
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		client, id := newClient(t)
+
+		require.NoError(t, client.CreatePhotoEventReference(ctx, id("photo1"), id("event1")))
+		if client.Tenant() != "" {
+			// 租戶客戶端不持有連接，Close 不影響原客戶端
+			assert.NoError(t, client.Close(ctx))
+			return
+		}
+		require.NoError(t, client.Close(ctx))
+
+		_, err := client.GetEventReferencePhotos(ctx, id("event1"))
+		assert.True(t, errors.Is(err, ErrClientClosed))
+		assert.ErrorIs(t, client.CreatePhotoEventReference(ctx, id("photo2"), id("event1")), ErrClientClosed)
+	})
+}
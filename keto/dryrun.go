@@ -0,0 +1,112 @@
+package keto
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+)
+
+// Delta 一個元組的變更
+type Delta struct {
+	Action  string        `json:"action"`  // AuditActionInsert 或 AuditActionDelete
+	Tuple   RelationTuple `json:"tuple"`   // 調用方看到的元組，不含租戶前綴
+	Changed bool          `json:"changed"` // 執行後元組是否真的會改變 (插入不存在的或刪除存在的元組)
+}
+
+// DryRun 收集試運行期間計算出的變更
+type DryRun struct {
+	mu     sync.Mutex
+	deltas []Delta
+}
+
+// dryRunKey context 中 DryRun 的鍵
+type dryRunKey struct{}
+
+// ContextWithDryRun 返回試運行的 context 和收集變更的 DryRun
+// 使用此 context 的寫入和刪除只會驗證輸入並計算變更，不會發送到 Keto
+func ContextWithDryRun(ctx context.Context) (context.Context, *DryRun) {
+	d := &DryRun{}
+	return context.WithValue(ctx, dryRunKey{}, d), d
+}
+
+// ContextWithDryRun 返回試運行的 context 和收集變更的 DryRun，實現 api.DryRunClient
+func (k *Client) ContextWithDryRun(ctx context.Context) (context.Context, *DryRun) {
+	return ContextWithDryRun(ctx)
+}
+
+// DryRunFromContext 返回 context 中的 DryRun，不是試運行時返回 nil
+// api.KetoClientInterface 的其他實現需要檢查它並實現 api.DryRunClient 才能支持試運行
+func DryRunFromContext(ctx context.Context) *DryRun {
+	d, _ := ctx.Value(dryRunKey{}).(*DryRun)
+	return d
+}
+
+// Add 記錄變更
+func (d *DryRun) Add(deltas ...Delta) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.deltas = append(d.deltas, deltas...)
+}
+
+// Deltas 返回目前記錄的所有變更
+func (d *DryRun) Deltas() []Delta {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]Delta{}, d.deltas...)
+}
+
+// WithDryRun 讓客戶端的所有寫入和刪除都以試運行方式執行
+// 計算出的變更以 info 級別記錄到日誌，不會發送到 Keto；查詢和權限檢查不受影響
+func WithDryRun(enabled bool) Option {
+	return func(o *options) {
+		o.dryRun = enabled
+	}
+}
+
+// actionName 返回變更類型的名稱
+func actionName(action rts.RelationTupleDelta_Action) string {
+	if action == rts.RelationTupleDelta_ACTION_DELETE {
+		return AuditActionDelete
+	}
+	return AuditActionInsert
+}
+
+// planDeltas 計算試運行的變更，記錄到 ctx 的 DryRun 和日誌
+// 需要查詢每個元組當前是否存在
+func (k *Client) planDeltas(ctx context.Context, action rts.RelationTupleDelta_Action, tuples []RelationTuple) error {
+	deltas := make([]Delta, 0, len(tuples))
+	for _, tuple := range tuples {
+		proto := tuple.ToProto()
+		existing, err := k.listRelationTuples(ctx, &rts.RelationQuery{
+			Namespace: &proto.Namespace,
+			Object:    &proto.Object,
+			Relation:  &proto.Relation,
+			Subject:   proto.Subject,
+		})
+		if err != nil {
+			return err
+		}
+
+		exists := len(existing) > 0
+		deltas = append(deltas, Delta{
+			Action:  actionName(action),
+			Tuple:   tuple,
+			Changed: exists == (action == rts.RelationTupleDelta_ACTION_DELETE),
+		})
+	}
+
+	if d := DryRunFromContext(ctx); d != nil {
+		d.Add(deltas...)
+	}
+	for _, delta := range deltas {
+		k.log().LogAttrs(ctx, slog.LevelInfo, "keto 試運行",
+			slog.String("action", delta.Action),
+			slog.String("tuple", delta.Tuple.String()),
+			slog.Bool("changed", delta.Changed),
+			slog.String("request_id", RequestIDFromContext(ctx)),
+		)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package keto
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// matchesObject 匹配查詢指定對象的請求
+func matchesObject(object string) interface{} {
+	return mock.MatchedBy(func(req *rts.ListRelationTuplesRequest) bool {
+		return req.RelationQuery.GetObject() == object
+	})
+}
+
+func TestDryRun_Context(t *testing.T) {
+	mockWriteClient := new(MockWriteServiceClient)
+	mockReadClient := new(MockReadServiceClient)
+	sink := &MemoryAuditSink{}
+	client := &Client{writeClient: mockWriteClient, readClient: mockReadClient, auditSinks: []AuditSink{sink}}
+
+	// photo1 的元組已存在，photo2 的不存在
+	existing := &rts.ListRelationTuplesResponse{RelationTuples: []*rts.RelationTuple{
+		{Namespace: NamespacePhoto, Object: "photo1", Relation: RelationReference, Subject: rts.NewSubjectSet(NamespaceEvent, "event1", "")},
+	}}
+	mockReadClient.On("ListRelationTuples", mock.Anything, matchesObject("photo1")).Return(existing, nil)
+	mockReadClient.On("ListRelationTuples", mock.Anything, matchesObject("photo2")).Return(&rts.ListRelationTuplesResponse{}, nil)
+
+	ctx, plan := ContextWithDryRun(context.Background())
+	err := client.BatchCreatePhotoEventReferences(ctx, []PhotoEventRelation{
+		{PhotoID: "photo1", EventID: "event1"},
+		{PhotoID: "photo2", EventID: "event1"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, client.DeletePhotoEventRelation(ctx, "photo1", "event1", RelationReference))
+	require.NoError(t, client.DeletePhotoEventRelation(ctx, "photo2", "event1", RelationReference))
+
+	deltas := plan.Deltas()
+	require.Len(t, deltas, 4)
+	assert.Equal(t, Delta{Action: AuditActionInsert, Tuple: mustParseTuple(t, "Photo:photo1#reference@(Event:event1#)"), Changed: false}, deltas[0])
+	assert.Equal(t, Delta{Action: AuditActionInsert, Tuple: mustParseTuple(t, "Photo:photo2#reference@(Event:event1#)"), Changed: true}, deltas[1])
+	assert.Equal(t, Delta{Action: AuditActionDelete, Tuple: mustParseTuple(t, "Photo:photo1#reference@(Event:event1#)"), Changed: true}, deltas[2])
+	assert.Equal(t, Delta{Action: AuditActionDelete, Tuple: mustParseTuple(t, "Photo:photo2#reference@(Event:event1#)"), Changed: false}, deltas[3])
+
+	// 不寫入 Keto，也不產生審計記錄
+	mockWriteClient.AssertNotCalled(t, "TransactRelationTuples", mock.Anything, mock.Anything)
+	assert.Empty(t, sink.Records())
+}
+
+func TestDryRun_ValidatesInput(t *testing.T) {
+	client := &Client{}
+
+	ctx, plan := ContextWithDryRun(context.Background())
+	err := client.CreatePhotoEventReference(ctx, "photo:1", "event1")
+
+	assert.ErrorIs(t, err, ErrInvalidID)
+	assert.Empty(t, plan.Deltas())
+}
+
+func TestDryRun_TenantClient(t *testing.T) {
+	mockReadClient := new(MockReadServiceClient)
+	client := &Client{readClient: mockReadClient}
+	tenantClient, err := client.ForTenant("acme")
+	require.NoError(t, err)
+
+	// 查詢使用帶租戶前綴的 ID，變更中的元組不含前綴
+	mockReadClient.On("ListRelationTuples", mock.Anything, matchesObject("acme/photo1")).Return(&rts.ListRelationTuplesResponse{}, nil)
+
+	ctx, plan := ContextWithDryRun(context.Background())
+	require.NoError(t, tenantClient.CreatePhotoEventPolaroid(ctx, "photo1", "event1"))
+
+	require.Len(t, plan.Deltas(), 1)
+	assert.Equal(t, "Photo:photo1#polaroid@(Event:event1#)", plan.Deltas()[0].Tuple.String())
+	mockReadClient.AssertExpectations(t)
+}
+
+func TestWithDryRun(t *testing.T) {
+	var buf bytes.Buffer
+	mockWriteClient := new(MockWriteServiceClient)
+	mockReadClient := new(MockReadServiceClient)
+	client := &Client{
+		writeClient: mockWriteClient,
+		readClient:  mockReadClient,
+		dryRun:      true,
+		logger:      slog.New(slog.NewTextHandler(&buf, nil)),
+	}
+	mockReadClient.On("ListRelationTuples", mock.Anything, mock.Anything).Return(&rts.ListRelationTuplesResponse{}, nil)
+
+	require.NoError(t, client.CreatePhotoEventReference(context.Background(), "photo1", "event1"))
+
+	mockWriteClient.AssertNotCalled(t, "TransactRelationTuples", mock.Anything, mock.Anything)
+	assert.Contains(t, buf.String(), "keto 試運行")
+	assert.Contains(t, buf.String(), "tuple=Photo:photo1#reference@(Event:event1#)")
+	assert.Contains(t, buf.String(), "changed=true")
+}
+
+// mustParseTuple 解析元組字符串，失敗時終止測試
+func mustParseTuple(t *testing.T, s string) RelationTuple {
+	t.Helper()
+	tuple, err := ParseRelationTuple(s)
+	require.NoError(t, err)
+	return tuple
+}
@@ -0,0 +1,177 @@
+package embedded
+
+import (
+	"github.com/AidChen0509/oosa_ketosdk/opl"
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+)
+
+// maxDepth 返回請求使用的最大深度，未指定或超過 DefaultMaxDepth 時使用 DefaultMaxDepth
+func maxDepth(requested int32) int {
+	if requested <= 0 || int(requested) > DefaultMaxDepth {
+		return DefaultMaxDepth
+	}
+	return int(requested)
+}
+
+// result 檢查的結果
+// 與 Keto 相同，深度用完時結果為 unknown，而不是 notMember，
+// 否則 Not 會把 "無法確定" 變成成立
+type result int
+
+const (
+	notMember result = iota // 不成立
+	isMember                // 成立
+	unknown                 // 深度用完，無法確定
+)
+
+// not 返回 Not 規則的結果，unknown 保持不變
+func (r result) not() result {
+	switch r {
+	case isMember:
+		return notMember
+	case notMember:
+		return isMember
+	default:
+		return unknown
+	}
+}
+
+// allowed 判斷元組是否成立，unknown 視為不成立
+func (s *Server) allowed(t *rts.RelationTuple, depth int) bool {
+	return s.check(t, depth) == isMember
+}
+
+// check 判斷元組是否成立，語意與 Keto 相同：
+//   - 關係為 WithSchema 中的權限時，按權限規則計算
+//   - 否則元組直接存儲，或經由主體集合間接成立 (例如 Event:event1#members@(Event:event2#members))
+//
+// 每經過一次主體集合、Traverse 或 Invoke 深度減一，深度用完時結果為 unknown
+func (s *Server) check(t *rts.RelationTuple, depth int) result {
+	if depth <= 0 {
+		return unknown
+	}
+	if ns, ok := opl.Lookup(s.schema, t.GetNamespace()); ok {
+		if permit, ok := ns.Permit(t.GetRelation()); ok {
+			return s.evalRule(ns, t.GetObject(), permit.Rule, t.GetSubject(), depth)
+		}
+	}
+	return s.checkRelation(t, depth)
+}
+
+// checkRelation 判斷關係元組直接存儲或經由主體集合間接成立
+func (s *Server) checkRelation(t *rts.RelationTuple, depth int) result {
+	if s.store.contains(t) {
+		return isMember
+	}
+
+	res := notMember
+	namespace, object, relation := t.GetNamespace(), t.GetObject(), t.GetRelation()
+	for _, stored := range s.store.list(&rts.RelationQuery{Namespace: &namespace, Object: &object, Relation: &relation}) {
+		set := stored.GetSubject().GetSet()
+		if set == nil || set.GetRelation() == "" {
+			continue
+		}
+		res = union(res, s.check(&rts.RelationTuple{
+			Namespace: set.GetNamespace(),
+			Object:    set.GetObject(),
+			Relation:  set.GetRelation(),
+			Subject:   t.GetSubject(),
+		}, depth-1))
+		if res == isMember {
+			return res
+		}
+	}
+	return res
+}
+
+// union 合併 Or 的兩個分支：任一成立即成立，否則任一無法確定即無法確定
+func union(a, b result) result {
+	if a == isMember || b == isMember {
+		return isMember
+	}
+	if a == unknown || b == unknown {
+		return unknown
+	}
+	return notMember
+}
+
+// intersection 合併 And 的兩個分支：任一不成立即不成立，否則任一無法確定即無法確定
+func intersection(a, b result) result {
+	if a == notMember || b == notMember {
+		return notMember
+	}
+	if a == unknown || b == unknown {
+		return unknown
+	}
+	return isMember
+}
+
+// evalRule 在 ns 的 object 上計算權限規則
+func (s *Server) evalRule(ns opl.Namespace, object string, rule opl.Rule, subject *rts.Subject, depth int) result {
+	switch r := rule.(type) {
+	case opl.Includes:
+		return s.checkRelation(&rts.RelationTuple{Namespace: ns.Name, Object: object, Relation: r.Relation, Subject: subject}, depth)
+	case opl.Traverse:
+		// 與 Keto 相同，只沿主體集合 (例如 Event:event1) 前往相關對象，主體 ID 不會被遍歷
+		res := notMember
+		for _, stored := range s.store.list(&rts.RelationQuery{Namespace: &ns.Name, Object: &object, Relation: &r.Relation}) {
+			set := stored.GetSubject().GetSet()
+			if set == nil {
+				continue
+			}
+			res = union(res, s.check(&rts.RelationTuple{Namespace: set.GetNamespace(), Object: set.GetObject(), Relation: r.Permit, Subject: subject}, depth-1))
+			if res == isMember {
+				return res
+			}
+		}
+		return res
+	case opl.Invoke:
+		return s.check(&rts.RelationTuple{Namespace: ns.Name, Object: object, Relation: r.Permit, Subject: subject}, depth-1)
+	case opl.Or:
+		res := notMember
+		for _, rule := range r {
+			if res = union(res, s.evalRule(ns, object, rule, subject, depth)); res == isMember {
+				return res
+			}
+		}
+		return res
+	case opl.And:
+		res := isMember
+		for _, rule := range r {
+			if res = intersection(res, s.evalRule(ns, object, rule, subject, depth)); res == notMember {
+				return res
+			}
+		}
+		return res
+	case opl.Not:
+		return s.evalRule(ns, object, r.Rule, subject, depth).not()
+	default:
+		return notMember
+	}
+}
+
+// expand 展開主體集合的元組，直接主體為葉子節點，主體集合在深度允許時遞歸展開
+func (s *Server) expand(set *rts.SubjectSet, depth int) *rts.SubjectTree {
+	root := &rts.SubjectTree{
+		NodeType: rts.NodeType_NODE_TYPE_UNION,
+		Tuple: &rts.RelationTuple{
+			Namespace: set.GetNamespace(),
+			Object:    set.GetObject(),
+			Relation:  set.GetRelation(),
+		},
+	}
+	if depth <= 1 {
+		root.NodeType = rts.NodeType_NODE_TYPE_LEAF
+		return root
+	}
+
+	namespace, object, relation := set.GetNamespace(), set.GetObject(), set.GetRelation()
+	for _, t := range s.store.list(&rts.RelationQuery{Namespace: &namespace, Object: &object, Relation: &relation}) {
+		if child := t.GetSubject().GetSet(); child != nil {
+			root.Children = append(root.Children, s.expand(child, depth-1))
+			continue
+		}
+		root.Children = append(root.Children, &rts.SubjectTree{NodeType: rts.NodeType_NODE_TYPE_LEAF, Tuple: t})
+	}
+	return root
+}
@@ -0,0 +1,198 @@
+package embedded
+
+import (
+	"context"
+	"testing"
+
+	"github.com/AidChen0509/oosa_ketosdk/opl"
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// testSchema 測試用的命名空間：文件夾可繼承父文件夾的權限，群組可以嵌套
+var testSchema = []opl.Namespace{
+	{Name: "User"},
+	{
+		Name: "Group",
+		Relations: []opl.Relation{
+			{Name: "members", Subjects: []opl.SubjectType{{Namespace: "User"}, {Namespace: "Group", Relation: "members"}}},
+		},
+	},
+	{
+		Name: "Folder",
+		Relations: []opl.Relation{
+			{Name: "parents", Subjects: []opl.SubjectType{{Namespace: "Folder"}}},
+			{Name: "viewers", Subjects: []opl.SubjectType{{Namespace: "User"}, {Namespace: "Group", Relation: "members"}}},
+			{Name: "owners", Subjects: []opl.SubjectType{{Namespace: "User"}}},
+			{Name: "banned", Subjects: []opl.SubjectType{{Namespace: "User"}, {Namespace: "Group", Relation: "members"}}},
+		},
+		Permits: []opl.Permit{
+			{Name: "view", Rule: opl.Or{
+				opl.Includes{Relation: "viewers"},
+				opl.Includes{Relation: "owners"},
+				opl.Traverse{Relation: "parents", Permit: "view"},
+			}},
+			{Name: "edit", Rule: opl.And{
+				opl.Includes{Relation: "owners"},
+				opl.Not{Rule: opl.Includes{Relation: "banned"}},
+			}},
+			{Name: "share", Rule: opl.Invoke{Permit: "edit"}},
+		},
+	},
+}
+
+// subjectSet 創建主體為主體集合的元組
+func subjectSet(namespace, object, relation, setNamespace, setObject, setRelation string) *rts.RelationTuple {
+	return &rts.RelationTuple{
+		Namespace: namespace, Object: object, Relation: relation,
+		Subject: rts.NewSubjectSet(setNamespace, setObject, setRelation),
+	}
+}
+
+// newSchemaServer 創建使用 testSchema 的服務器和 CheckService 客戶端
+func newSchemaServer(t *testing.T) (*Server, func(namespace, object, relation, subject string, maxDepth int32) bool) {
+	s := NewServer(WithSchema(testSchema))
+	t.Cleanup(s.Close)
+	checkClient := rts.NewCheckServiceClient(dial(t, s))
+
+	check := func(namespace, object, relation, subject string, maxDepth int32) bool {
+		resp, err := checkClient.Check(context.Background(), &rts.CheckRequest{
+			Tuple:    tuple(namespace, object, relation, subject),
+			MaxDepth: maxDepth,
+		})
+		require.NoError(t, err)
+		return resp.Allowed
+	}
+	return s, check
+}
+
+func TestCheck_SubjectSetIndirection(t *testing.T) {
+	s, check := newSchemaServer(t)
+	insert(t, s,
+		subjectSet("Folder", "docs", "viewers", "Group", "engineering", "members"),
+		subjectSet("Group", "engineering", "members", "Group", "backend", "members"),
+		tuple("Group", "backend", "members", "alice"),
+	)
+
+	assert.True(t, check("Group", "backend", "members", "alice", 0))
+	assert.True(t, check("Group", "engineering", "members", "alice", 0))
+	assert.True(t, check("Folder", "docs", "viewers", "alice", 0))
+	assert.False(t, check("Folder", "docs", "viewers", "bob", 0))
+}
+
+func TestCheck_Union(t *testing.T) {
+	s, check := newSchemaServer(t)
+	insert(t, s,
+		tuple("Folder", "docs", "viewers", "alice"),
+		tuple("Folder", "docs", "owners", "bob"),
+	)
+
+	assert.True(t, check("Folder", "docs", "view", "alice", 0))
+	assert.True(t, check("Folder", "docs", "view", "bob", 0))
+	assert.False(t, check("Folder", "docs", "view", "carol", 0))
+}
+
+func TestCheck_IntersectionAndExclusion(t *testing.T) {
+	s, check := newSchemaServer(t)
+	insert(t, s,
+		tuple("Folder", "docs", "owners", "alice"),
+		tuple("Folder", "docs", "owners", "bob"),
+		tuple("Folder", "docs", "banned", "bob"),
+		tuple("Folder", "docs", "viewers", "carol"),
+	)
+
+	assert.True(t, check("Folder", "docs", "edit", "alice", 0))
+	assert.False(t, check("Folder", "docs", "edit", "bob", 0), "被排除的主體")
+	assert.False(t, check("Folder", "docs", "edit", "carol", 0), "只滿足交集的一邊")
+
+	// Invoke 其他權限
+	assert.True(t, check("Folder", "docs", "share", "alice", 0))
+	assert.False(t, check("Folder", "docs", "share", "bob", 0))
+}
+
+func TestCheck_TraverseToParent(t *testing.T) {
+	s, check := newSchemaServer(t)
+	insert(t, s,
+		subjectSet("Folder", "report", "parents", "Folder", "docs", ""),
+		subjectSet("Folder", "docs", "parents", "Folder", "root", ""),
+		tuple("Folder", "root", "viewers", "alice"),
+		tuple("Folder", "docs", "owners", "bob"),
+	)
+
+	assert.True(t, check("Folder", "report", "view", "alice", 0))
+	assert.True(t, check("Folder", "report", "view", "bob", 0))
+	assert.False(t, check("Folder", "root", "view", "bob", 0), "權限不會向上傳遞")
+}
+
+func TestCheck_MaxDepth(t *testing.T) {
+	s, check := newSchemaServer(t)
+	insert(t, s,
+		subjectSet("Folder", "d", "parents", "Folder", "c", ""),
+		subjectSet("Folder", "c", "parents", "Folder", "b", ""),
+		subjectSet("Folder", "b", "parents", "Folder", "a", ""),
+		tuple("Folder", "a", "viewers", "alice"),
+	)
+
+	// d -> c -> b -> a 需要 4 層
+	assert.True(t, check("Folder", "d", "view", "alice", 4))
+	assert.False(t, check("Folder", "d", "view", "alice", 3))
+	assert.True(t, check("Folder", "d", "view", "alice", 0), "默認深度足夠")
+
+	// 超過默認深度的鏈不成立
+	insert(t, s,
+		subjectSet("Folder", "f", "parents", "Folder", "e", ""),
+		subjectSet("Folder", "e", "parents", "Folder", "d", ""),
+	)
+	assert.False(t, check("Folder", "f", "view", "alice", 0))
+}
+
+func TestCheck_MaxDepthInsideExclusion(t *testing.T) {
+	s, check := newSchemaServer(t)
+	insert(t, s,
+		tuple("Folder", "docs", "owners", "alice"),
+		subjectSet("Folder", "docs", "banned", "Group", "g1", "members"),
+		subjectSet("Group", "g1", "members", "Group", "g2", "members"),
+		tuple("Group", "g2", "members", "alice"),
+	)
+
+	// banned 需要 3 層才能確定；深度不足時結果無法確定，Not 之後仍然無法確定，因此拒絕
+	assert.False(t, check("Folder", "docs", "edit", "alice", 1))
+	assert.False(t, check("Folder", "docs", "edit", "alice", 2))
+	assert.False(t, check("Folder", "docs", "edit", "alice", 3))
+	assert.False(t, check("Folder", "docs", "edit", "alice", 0))
+
+	// 深度足夠時確定不在 banned 中的主體仍然可以編輯
+	insert(t, s, tuple("Folder", "docs", "owners", "bob"))
+	assert.False(t, check("Folder", "docs", "edit", "bob", 1), "無法確定 bob 是否被排除")
+	assert.True(t, check("Folder", "docs", "edit", "bob", 3))
+}
+
+func TestCheck_Cycle(t *testing.T) {
+	s, check := newSchemaServer(t)
+	insert(t, s,
+		subjectSet("Group", "a", "members", "Group", "b", "members"),
+		subjectSet("Group", "b", "members", "Group", "a", "members"),
+	)
+
+	assert.False(t, check("Group", "a", "members", "alice", 0))
+}
+
+func TestWithSchema_RejectsUndefinedRelations(t *testing.T) {
+	s := NewServer(WithSchema(testSchema))
+	defer s.Close()
+	write := rts.NewWriteServiceClient(dial(t, s))
+
+	// 權限不能直接寫入
+	_, err := write.TransactRelationTuples(context.Background(), &rts.TransactRelationTuplesRequest{
+		RelationTupleDeltas: rts.RelationTupleToDeltas([]*rts.RelationTuple{tuple("Folder", "docs", "view", "alice")}, rts.RelationTupleDelta_ACTION_INSERT),
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = write.TransactRelationTuples(context.Background(), &rts.TransactRelationTuplesRequest{
+		RelationTupleDeltas: rts.RelationTupleToDeltas([]*rts.RelationTuple{tuple("Folder", "docs", "viewers", "alice")}, rts.RelationTupleDelta_ACTION_INSERT),
+	})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,91 @@
+package embedded
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+)
+
+// dataFile 持久化文件的內容
+type dataFile struct {
+	Tuples []jsonTuple `json:"tuples"`
+}
+
+// Open 創建將元組保存在 path 的服務器，使用完畢後需調用 Close
+//
+// 文件存在時先載入其中的元組；之後每次寫入或刪除都會把所有元組序列化後寫入臨時文件再替換 path，
+// 保存失敗時變更不會生效，調用返回 Internal。
+// 每次變更的成本與元組總數成正比 (沒有追加日誌或壓縮)，只適合開發環境和少量元組。
+func Open(path string, opts ...Option) (*Server, error) {
+	tuples, err := loadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s := NewServer(opts...)
+	if err := s.store.transact(rts.RelationTupleToDeltas(tuples, rts.RelationTupleDelta_ACTION_INSERT)); err != nil {
+		s.Close()
+		return nil, err
+	}
+	s.store.persist = func(tuples []*rts.RelationTuple) error {
+		return saveFile(path, tuples)
+	}
+	return s, nil
+}
+
+// loadFile 讀取文件中的元組，文件不存在時返回空列表
+func loadFile(path string) ([]*rts.RelationTuple, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("embedded: 無法讀取數據文件: %w", err)
+	}
+
+	var file dataFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("embedded: 數據文件 %s 無效: %w", path, err)
+	}
+	tuples := make([]*rts.RelationTuple, len(file.Tuples))
+	for i, t := range file.Tuples {
+		tuples[i] = t.toProto()
+	}
+	return tuples, nil
+}
+
+// saveFile 原子地將元組寫入文件
+func saveFile(path string, tuples []*rts.RelationTuple) error {
+	file := dataFile{Tuples: make([]jsonTuple, len(tuples))}
+	for i, t := range tuples {
+		file.Tuples[i] = jsonTupleFromProto(t)
+	}
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,77 @@
+package embedded
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// writeTuples 通過 WriteService 寫入元組
+func writeTuples(t *testing.T, s *Server, tuples ...*rts.RelationTuple) error {
+	_, err := rts.NewWriteServiceClient(dial(t, s)).TransactRelationTuples(context.Background(), &rts.TransactRelationTuplesRequest{
+		RelationTupleDeltas: rts.RelationTupleToDeltas(tuples, rts.RelationTupleDelta_ACTION_INSERT),
+	})
+	return err
+}
+
+func TestOpen_PersistsAcrossRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keto.json")
+
+	s, err := Open(path)
+	require.NoError(t, err)
+	require.NoError(t, writeTuples(t, s,
+		tuple("Photo", "photo2", "reference", "event1"),
+		tuple("Photo", "photo1", "reference", "event1"),
+		&rts.RelationTuple{Namespace: "Photo", Object: "photo3", Relation: "reference", Subject: rts.NewSubjectSet("Event", "event1", "")},
+	))
+	_, err = rts.NewWriteServiceClient(dial(t, s)).DeleteRelationTuples(context.Background(), &rts.DeleteRelationTuplesRequest{
+		RelationQuery: &rts.RelationQuery{Object: strPtr("photo1")},
+	})
+	require.NoError(t, err)
+	want := s.Tuples()
+	s.Close()
+
+	reopened, err := Open(path)
+	require.NoError(t, err)
+	defer reopened.Close()
+
+	got := reopened.Tuples()
+	require.Len(t, got, 2)
+	for i := range want {
+		assert.True(t, proto.Equal(want[i], got[i]), "元組 %d 應保持寫入順序", i)
+	}
+
+	// 重新打開後繼續寫入
+	insert(t, reopened, tuple("Photo", "photo4", "polaroid", "event2"))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"object": "photo4"`)
+}
+
+func TestOpen_SaveFailureKeepsState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "keto.json")
+
+	s, err := Open(path)
+	require.NoError(t, err)
+	defer s.Close()
+
+	err = writeTuples(t, s, tuple("Photo", "photo1", "reference", "event1"))
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Empty(t, s.Tuples())
+}
+
+func TestOpen_InvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keto.json")
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0o600))
+
+	_, err := Open(path)
+	assert.ErrorContains(t, err, "數據文件")
+}
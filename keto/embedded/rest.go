@@ -0,0 +1,388 @@
+package embedded
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// HTTPHandler 返回實現 Keto REST API 的 http.Handler，與 gRPC 服務共用存儲
+//
+// 讀取和寫入端點使用同一個 Handler，可以配合 httptest.NewServer 使用：
+//
+//	ts := httptest.NewServer(srv.HTTPHandler())
+//	defer ts.Close()
+//
+//	client, err := keto.NewClient(ts.URL, ts.URL, keto.WithTransport(keto.TransportREST))
+func (s *Server) HTTPHandler() http.Handler {
+	read := &readService{s: s}
+	write := &writeService{s: s}
+	check := &checkService{s: s}
+	expand := &expandService{s: s}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /relation-tuples", func(w http.ResponseWriter, r *http.Request) {
+		params := r.URL.Query()
+		req := &rts.ListRelationTuplesRequest{
+			RelationQuery: queryFromParams(params),
+			PageToken:     params.Get("page_token"),
+		}
+		if size := params.Get("page_size"); size != "" {
+			n, err := strconv.Atoi(size)
+			if err != nil {
+				writeError(w, status.Errorf(codes.InvalidArgument, "無效的 page_size %q", size))
+				return
+			}
+			req.PageSize = int32(n)
+		}
+		resp, err := read.ListRelationTuples(r.Context(), req)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		out := jsonListResponse{RelationTuples: []jsonTuple{}, NextPageToken: resp.NextPageToken}
+		for _, t := range resp.RelationTuples {
+			out.RelationTuples = append(out.RelationTuples, jsonTupleFromProto(t))
+		}
+		writeJSON(w, http.StatusOK, out)
+	})
+
+	mux.HandleFunc("PUT /admin/relation-tuples", func(w http.ResponseWriter, r *http.Request) {
+		var tuple jsonTuple
+		if !readJSON(w, r, &tuple) {
+			return
+		}
+		_, err := write.TransactRelationTuples(r.Context(), &rts.TransactRelationTuplesRequest{
+			RelationTupleDeltas: rts.RelationTupleToDeltas([]*rts.RelationTuple{tuple.toProto()}, rts.RelationTupleDelta_ACTION_INSERT),
+		})
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, tuple)
+	})
+
+	mux.HandleFunc("PATCH /admin/relation-tuples", func(w http.ResponseWriter, r *http.Request) {
+		var deltas []jsonDelta
+		if !readJSON(w, r, &deltas) {
+			return
+		}
+		req := &rts.TransactRelationTuplesRequest{}
+		for _, d := range deltas {
+			action, ok := jsonActions[d.Action]
+			if !ok {
+				writeError(w, status.Errorf(codes.InvalidArgument, "無效的操作 %q", d.Action))
+				return
+			}
+			req.RelationTupleDeltas = append(req.RelationTupleDeltas, &rts.RelationTupleDelta{
+				Action:        action,
+				RelationTuple: d.RelationTuple.toProto(),
+			})
+		}
+		if _, err := write.TransactRelationTuples(r.Context(), req); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("DELETE /admin/relation-tuples", func(w http.ResponseWriter, r *http.Request) {
+		_, err := write.DeleteRelationTuples(r.Context(), &rts.DeleteRelationTuplesRequest{
+			RelationQuery: queryFromParams(r.URL.Query()),
+		})
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	// check 端點沒有權限時返回 403，openapi 版本總是返回 200
+	checkHandler := func(deniedStatus int) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			var tuple jsonTuple
+			if r.Method == http.MethodPost {
+				if !readJSON(w, r, &tuple) {
+					return
+				}
+			} else {
+				tuple = tupleFromParams(r.URL.Query())
+			}
+			req := &rts.CheckRequest{Tuple: tuple.toProto()}
+			if depth := r.URL.Query().Get("max-depth"); depth != "" {
+				n, err := strconv.Atoi(depth)
+				if err != nil {
+					writeError(w, status.Errorf(codes.InvalidArgument, "無效的 max-depth %q", depth))
+					return
+				}
+				req.MaxDepth = int32(n)
+			}
+			resp, err := check.Check(r.Context(), req)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			code := http.StatusOK
+			if !resp.Allowed {
+				code = deniedStatus
+			}
+			writeJSON(w, code, jsonCheckResponse{Allowed: resp.Allowed})
+		}
+	}
+	mux.HandleFunc("GET /relation-tuples/check", checkHandler(http.StatusForbidden))
+	mux.HandleFunc("POST /relation-tuples/check", checkHandler(http.StatusForbidden))
+	mux.HandleFunc("GET /relation-tuples/check/openapi", checkHandler(http.StatusOK))
+	mux.HandleFunc("POST /relation-tuples/check/openapi", checkHandler(http.StatusOK))
+
+	mux.HandleFunc("GET /relation-tuples/expand", func(w http.ResponseWriter, r *http.Request) {
+		params := r.URL.Query()
+		req := &rts.ExpandRequest{
+			Subject: rts.NewSubjectSet(params.Get("namespace"), params.Get("object"), params.Get("relation")),
+		}
+		if depth := params.Get("max-depth"); depth != "" {
+			n, err := strconv.Atoi(depth)
+			if err != nil {
+				writeError(w, status.Errorf(codes.InvalidArgument, "無效的 max-depth %q", depth))
+				return
+			}
+			req.MaxDepth = int32(n)
+		}
+		resp, err := expand.Expand(r.Context(), req)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, jsonTreeFromProto(resp.Tree))
+	})
+
+	mux.HandleFunc("GET /namespaces", func(w http.ResponseWriter, r *http.Request) {
+		out := jsonNamespacesResponse{Namespaces: []jsonNamespace{}}
+		for _, name := range s.namespaces {
+			out.Namespaces = append(out.Namespaces, jsonNamespace{Name: name})
+		}
+		writeJSON(w, http.StatusOK, out)
+	})
+
+	mux.HandleFunc("GET /version", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, jsonVersionResponse{Version: s.version})
+	})
+
+	health := func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	}
+	mux.HandleFunc("GET /health/alive", health)
+	mux.HandleFunc("GET /health/ready", health)
+
+	return mux
+}
+
+// jsonSubjectSet REST API 中的主體集合
+type jsonSubjectSet struct {
+	Namespace string `json:"namespace"`
+	Object    string `json:"object"`
+	Relation  string `json:"relation"`
+}
+
+// jsonTuple REST API 中的元組
+type jsonTuple struct {
+	Namespace  string          `json:"namespace"`
+	Object     string          `json:"object"`
+	Relation   string          `json:"relation"`
+	SubjectID  *string         `json:"subject_id,omitempty"`
+	SubjectSet *jsonSubjectSet `json:"subject_set,omitempty"`
+}
+
+// jsonDelta PATCH /admin/relation-tuples 的一個變更
+type jsonDelta struct {
+	Action        string    `json:"action"`
+	RelationTuple jsonTuple `json:"relation_tuple"`
+}
+
+// jsonActions REST API 的操作名稱
+var jsonActions = map[string]rts.RelationTupleDelta_Action{
+	"insert": rts.RelationTupleDelta_ACTION_INSERT,
+	"delete": rts.RelationTupleDelta_ACTION_DELETE,
+}
+
+type jsonListResponse struct {
+	RelationTuples []jsonTuple `json:"relation_tuples"`
+	NextPageToken  string      `json:"next_page_token"`
+}
+
+type jsonCheckResponse struct {
+	Allowed bool `json:"allowed"`
+}
+
+type jsonNamespace struct {
+	Name string `json:"name"`
+}
+
+type jsonNamespacesResponse struct {
+	Namespaces []jsonNamespace `json:"namespaces"`
+}
+
+type jsonVersionResponse struct {
+	Version string `json:"version"`
+}
+
+// jsonTree GET /relation-tuples/expand 返回的樹
+type jsonTree struct {
+	Type     string     `json:"type"`
+	Tuple    *jsonTuple `json:"tuple,omitempty"`
+	Children []jsonTree `json:"children,omitempty"`
+}
+
+// jsonError Keto 的錯誤響應
+type jsonError struct {
+	Error jsonErrorBody `json:"error"`
+}
+
+type jsonErrorBody struct {
+	Code    int    `json:"code"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// toProto 轉換為 protobuf 元組
+func (t jsonTuple) toProto() *rts.RelationTuple {
+	tuple := &rts.RelationTuple{Namespace: t.Namespace, Object: t.Object, Relation: t.Relation}
+	switch {
+	case t.SubjectSet != nil:
+		tuple.Subject = rts.NewSubjectSet(t.SubjectSet.Namespace, t.SubjectSet.Object, t.SubjectSet.Relation)
+	case t.SubjectID != nil:
+		tuple.Subject = rts.NewSubjectID(*t.SubjectID)
+	}
+	return tuple
+}
+
+// jsonTupleFromProto 轉換 protobuf 元組
+func jsonTupleFromProto(t *rts.RelationTuple) jsonTuple {
+	tuple := jsonTuple{Namespace: t.GetNamespace(), Object: t.GetObject(), Relation: t.GetRelation()}
+	if set := t.GetSubject().GetSet(); set != nil {
+		tuple.SubjectSet = &jsonSubjectSet{Namespace: set.Namespace, Object: set.Object, Relation: set.Relation}
+	} else if t.GetSubject() != nil {
+		id := t.GetSubject().GetId()
+		tuple.SubjectID = &id
+	}
+	return tuple
+}
+
+// jsonTreeFromProto 轉換 protobuf 的樹
+func jsonTreeFromProto(t *rts.SubjectTree) jsonTree {
+	tree := jsonTree{Type: jsonNodeTypes[t.GetNodeType()]}
+	if t.GetTuple() != nil {
+		tuple := jsonTupleFromProto(t.GetTuple())
+		tree.Tuple = &tuple
+	}
+	for _, child := range t.GetChildren() {
+		tree.Children = append(tree.Children, jsonTreeFromProto(child))
+	}
+	return tree
+}
+
+// jsonNodeTypes REST API 的節點類型名稱
+var jsonNodeTypes = map[rts.NodeType]string{
+	rts.NodeType_NODE_TYPE_UNION:        "union",
+	rts.NodeType_NODE_TYPE_EXCLUSION:    "exclusion",
+	rts.NodeType_NODE_TYPE_INTERSECTION: "intersection",
+	rts.NodeType_NODE_TYPE_LEAF:         "leaf",
+}
+
+// tupleFromParams 從 URL 參數讀取元組
+func tupleFromParams(params url.Values) jsonTuple {
+	tuple := jsonTuple{
+		Namespace: params.Get("namespace"),
+		Object:    params.Get("object"),
+		Relation:  params.Get("relation"),
+	}
+	if params.Has("subject_set.namespace") {
+		tuple.SubjectSet = &jsonSubjectSet{
+			Namespace: params.Get("subject_set.namespace"),
+			Object:    params.Get("subject_set.object"),
+			Relation:  params.Get("subject_set.relation"),
+		}
+	} else if params.Has("subject_id") {
+		id := params.Get("subject_id")
+		tuple.SubjectID = &id
+	}
+	return tuple
+}
+
+// queryFromParams 從 URL 參數讀取查詢條件，未設置的參數不作為條件
+func queryFromParams(params url.Values) *rts.RelationQuery {
+	query := &rts.RelationQuery{}
+	if params.Has("namespace") {
+		query.Namespace = strPtr(params.Get("namespace"))
+	}
+	if params.Has("object") {
+		query.Object = strPtr(params.Get("object"))
+	}
+	if params.Has("relation") {
+		query.Relation = strPtr(params.Get("relation"))
+	}
+	if params.Has("subject_set.namespace") {
+		query.Subject = rts.NewSubjectSet(params.Get("subject_set.namespace"), params.Get("subject_set.object"), params.Get("subject_set.relation"))
+	} else if params.Has("subject_id") {
+		query.Subject = rts.NewSubjectID(params.Get("subject_id"))
+	}
+	return query
+}
+
+// readJSON 解析請求內容，失敗時返回 400
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	data, err := io.ReadAll(r.Body)
+	if err == nil {
+		err = json.Unmarshal(data, v)
+	}
+	if err != nil {
+		writeError(w, status.Errorf(codes.InvalidArgument, "無效的請求內容: %v", err))
+		return false
+	}
+	return true
+}
+
+// writeJSON 寫入 JSON 響應
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError 將 gRPC 狀態轉換為 Keto 格式的錯誤響應
+func writeError(w http.ResponseWriter, err error) {
+	code := httpStatus(status.Code(err))
+	writeJSON(w, code, jsonError{Error: jsonErrorBody{
+		Code:    code,
+		Status:  http.StatusText(code),
+		Message: status.Convert(err).Message(),
+	}})
+}
+
+// httpStatus 將 gRPC 狀態碼轉換為 HTTP 狀態碼
+func httpStatus(code codes.Code) int {
+	switch code {
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// strPtr 返回字符串的指針
+func strPtr(s string) *string {
+	return &s
+}
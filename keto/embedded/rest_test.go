@@ -0,0 +1,112 @@
+package embedded
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newHTTPServer 創建服務器和對應的 httptest 服務器
+func newHTTPServer(t *testing.T, opts ...Option) (*Server, *httptest.Server) {
+	s := NewServer(opts...)
+	t.Cleanup(s.Close)
+	ts := httptest.NewServer(s.HTTPHandler())
+	t.Cleanup(ts.Close)
+	return s, ts
+}
+
+// do 發送請求並返回狀態碼和響應內容
+func do(t *testing.T, method, url, body string) (int, string) {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(data)
+}
+
+func TestHTTPHandler_WriteAndList(t *testing.T) {
+	s, ts := newHTTPServer(t)
+
+	code, _ := do(t, http.MethodPut, ts.URL+"/admin/relation-tuples",
+		`{"namespace":"Photo","object":"photo1","relation":"reference","subject_id":"event1"}`)
+	assert.Equal(t, http.StatusCreated, code)
+
+	code, _ = do(t, http.MethodPatch, ts.URL+"/admin/relation-tuples",
+		`[{"action":"insert","relation_tuple":{"namespace":"Photo","object":"photo2","relation":"reference","subject_set":{"namespace":"Event","object":"event1","relation":""}}}]`)
+	assert.Equal(t, http.StatusNoContent, code)
+	assert.Len(t, s.Tuples(), 2)
+
+	code, body := do(t, http.MethodGet, ts.URL+"/relation-tuples?namespace=Photo&page_size=1", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `{"relation_tuples":[{"namespace":"Photo","object":"photo1","relation":"reference","subject_id":"event1"}],"next_page_token":"1"}`, body)
+
+	code, _ = do(t, http.MethodDelete, ts.URL+"/admin/relation-tuples?namespace=Photo&object=photo1", "")
+	assert.Equal(t, http.StatusNoContent, code)
+	assert.Len(t, s.Tuples(), 1)
+}
+
+func TestHTTPHandler_Check(t *testing.T) {
+	s, ts := newHTTPServer(t)
+	insert(t, s, tuple("Photo", "photo1", "reference", "event1"))
+
+	code, body := do(t, http.MethodPost, ts.URL+"/relation-tuples/check",
+		`{"namespace":"Photo","object":"photo1","relation":"reference","subject_id":"event1"}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `{"allowed":true}`, body)
+
+	code, body = do(t, http.MethodGet, ts.URL+"/relation-tuples/check?namespace=Photo&object=photo1&relation=reference&subject_id=event2", "")
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.JSONEq(t, `{"allowed":false}`, body)
+
+	code, _ = do(t, http.MethodGet, ts.URL+"/relation-tuples/check/openapi?namespace=Photo&object=photo1&relation=reference&subject_id=event2", "")
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestHTTPHandler_Errors(t *testing.T) {
+	_, ts := newHTTPServer(t, WithNamespaces("Photo"))
+
+	code, body := do(t, http.MethodGet, ts.URL+"/relation-tuples?namespace=Unknown", "")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	var resp jsonError
+	require.NoError(t, json.Unmarshal([]byte(body), &resp))
+	assert.Equal(t, http.StatusNotFound, resp.Error.Code)
+	assert.NotEmpty(t, resp.Error.Message)
+
+	code, _ = do(t, http.MethodPatch, ts.URL+"/admin/relation-tuples", `[{"action":"upsert"}]`)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, _ = do(t, http.MethodPatch, ts.URL+"/admin/relation-tuples", `not json`)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestHTTPHandler_ExpandAndMetadata(t *testing.T) {
+	s, ts := newHTTPServer(t, WithNamespaces("Event"), WithVersion("v-test"))
+	insert(t, s, tuple("Event", "event1", "members", "alice"))
+
+	code, body := do(t, http.MethodGet, ts.URL+"/relation-tuples/expand?namespace=Event&object=event1&relation=members", "")
+	assert.Equal(t, http.StatusOK, code)
+	var tree jsonTree
+	require.NoError(t, json.Unmarshal([]byte(body), &tree))
+	assert.Equal(t, "union", tree.Type)
+	require.Len(t, tree.Children, 1)
+	assert.Equal(t, "leaf", tree.Children[0].Type)
+
+	_, body = do(t, http.MethodGet, ts.URL+"/namespaces", "")
+	assert.JSONEq(t, `{"namespaces":[{"name":"Event"}]}`, body)
+
+	_, body = do(t, http.MethodGet, ts.URL+"/version", "")
+	assert.JSONEq(t, `{"version":"v-test"}`, body)
+
+	code, _ = do(t, http.MethodGet, ts.URL+"/health/ready", "")
+	assert.Equal(t, http.StatusOK, code)
+}
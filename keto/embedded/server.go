@@ -0,0 +1,149 @@
+// Package embedded 提供在進程內實現 Keto gRPC 和 REST 服務的元組存儲
+//
+// 服務器通過 bufconn 提供 Read、Write、Check、Expand、Namespaces 和 Version 服務，
+// 不需要外部的 Keto 即可運行 keto.Client，例如 cmd/server 的 standalone 模式：
+//
+//	srv, err := embedded.Open("keto-data.json", embedded.WithSchema(keto.Schema()))
+//	defer srv.Close()
+//
+//	client, err := keto.NewClient(srv.Addr(), srv.Addr(), keto.WithDialOptions(srv.DialOptions()...))
+//
+// HTTPHandler 以 Keto 的 REST API 提供同一個存儲。
+// NewServer 創建的服務器只在內存中保存元組；Open 創建的服務器保存到文件。
+// 測試使用的 ketotest 包封裝了此包。
+package embedded
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/AidChen0509/oosa_ketosdk/opl"
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// 服務器的默認設置
+const (
+	DefaultVersion  = "embedded"
+	DefaultPageSize = 100
+	DefaultMaxDepth = 5 // 與 Keto 的 limit.max_read_depth 默認值相同
+)
+
+// bufSize bufconn 的緩衝區大小
+const bufSize = 1 << 20
+
+// Option 配置 Server 的選項
+type Option func(*Server)
+
+// WithNamespaces 設置已知的命名空間
+// 設置後寫入或查詢未知的命名空間會返回 NotFound；默認接受任何命名空間
+func WithNamespaces(names ...string) Option {
+	return func(s *Server) {
+		s.namespaces = append(s.namespaces, names...)
+	}
+}
+
+// WithSchema 設置命名空間的定義，例如 keto.Schema()
+// 命名空間會成為已知的命名空間；檢查權限時按 OPL 規則計算，寫入未定義的關係返回 InvalidArgument
+func WithSchema(namespaces []opl.Namespace) Option {
+	return func(s *Server) {
+		s.schema = append(s.schema, namespaces...)
+		for _, ns := range namespaces {
+			s.namespaces = append(s.namespaces, ns.Name)
+		}
+	}
+}
+
+// WithVersion 設置 Version 服務返回的版本，默認為 DefaultVersion
+func WithVersion(version string) Option {
+	return func(s *Server) {
+		s.version = version
+	}
+}
+
+// WithServerOptions 設置 gRPC 服務器的選項，例如攔截器
+func WithServerOptions(opts ...grpc.ServerOption) Option {
+	return func(s *Server) {
+		s.serverOptions = append(s.serverOptions, opts...)
+	}
+}
+
+// Server 在進程內實現 Keto gRPC 服務的服務器
+type Server struct {
+	namespaces    []string
+	schema        []opl.Namespace
+	version       string
+	serverOptions []grpc.ServerOption
+
+	store    *store
+	listener *bufconn.Listener
+	grpc     *grpc.Server
+}
+
+// NewServer 創建並啟動只在內存中保存元組的服務器，使用完畢後需調用 Close
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		version:  DefaultVersion,
+		store:    newStore(),
+		listener: bufconn.Listen(bufSize),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.grpc = grpc.NewServer(s.serverOptions...)
+	rts.RegisterReadServiceServer(s.grpc, &readService{s: s})
+	rts.RegisterWriteServiceServer(s.grpc, &writeService{s: s})
+	rts.RegisterCheckServiceServer(s.grpc, &checkService{s: s})
+	rts.RegisterExpandServiceServer(s.grpc, &expandService{s: s})
+	rts.RegisterNamespacesServiceServer(s.grpc, &namespacesService{s: s})
+	rts.RegisterVersionServiceServer(s.grpc, &versionService{s: s})
+
+	go s.grpc.Serve(s.listener)
+	return s
+}
+
+// Addr 返回連接服務器使用的地址，需配合 DialOptions 使用
+func (s *Server) Addr() string {
+	return "passthrough:///embedded"
+}
+
+// DialOptions 返回通過 bufconn 連接服務器的 gRPC 選項
+func (s *Server) DialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return s.listener.DialContext(ctx)
+		}),
+	}
+}
+
+// Close 停止服務器
+func (s *Server) Close() {
+	s.grpc.Stop()
+	s.listener.Close()
+}
+
+// Insert 直接寫入元組，例如準備初始數據
+// 使用 Open 創建的服務器無法保存元組時返回錯誤，元組不會寫入
+func (s *Server) Insert(tuples ...*rts.RelationTuple) error {
+	if err := s.store.transact(rts.RelationTupleToDeltas(tuples, rts.RelationTupleDelta_ACTION_INSERT)); err != nil {
+		return fmt.Errorf("embedded: 無法保存元組: %w", err)
+	}
+	return nil
+}
+
+// Tuples 按寫入順序返回目前存儲的所有元組
+func (s *Server) Tuples() []*rts.RelationTuple {
+	return s.store.list(nil)
+}
+
+// Reset 刪除所有元組
+// 使用 Open 創建的服務器無法保存元組時返回錯誤，元組不會刪除
+func (s *Server) Reset() error {
+	if err := s.store.reset(); err != nil {
+		return fmt.Errorf("embedded: 無法保存元組: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package embedded
+
+import (
+	"context"
+	"testing"
+
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// dial 連接測試服務器
+func dial(t *testing.T, s *Server) *grpc.ClientConn {
+	conn, err := grpc.NewClient(s.Addr(), append(s.DialOptions(), grpc.WithTransportCredentials(insecure.NewCredentials()))...)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// tuple 創建主體為 ID 的元組
+func tuple(namespace, object, relation, subject string) *rts.RelationTuple {
+	return &rts.RelationTuple{Namespace: namespace, Object: object, Relation: relation, Subject: rts.NewSubjectID(subject)}
+}
+
+// insert 寫入元組，失敗時結束測試
+func insert(t *testing.T, s *Server, tuples ...*rts.RelationTuple) {
+	t.Helper()
+	require.NoError(t, s.Insert(tuples...))
+}
+
+func TestWriteAndRead(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	conn := dial(t, s)
+	write, read := rts.NewWriteServiceClient(conn), rts.NewReadServiceClient(conn)
+	ctx := context.Background()
+
+	_, err := write.TransactRelationTuples(ctx, &rts.TransactRelationTuplesRequest{
+		RelationTupleDeltas: rts.RelationTupleToDeltas([]*rts.RelationTuple{
+			tuple("Photo", "photo1", "reference", "event1"),
+			tuple("Photo", "photo2", "reference", "event1"),
+			tuple("Photo", "photo1", "polaroid", "event2"),
+			tuple("Photo", "photo1", "reference", "event1"), // 重複寫入
+		}, rts.RelationTupleDelta_ACTION_INSERT),
+	})
+	require.NoError(t, err)
+	assert.Len(t, s.Tuples(), 3)
+
+	resp, err := read.ListRelationTuples(ctx, &rts.ListRelationTuplesRequest{
+		RelationQuery: &rts.RelationQuery{Namespace: strPtr("Photo"), Relation: strPtr("reference"), Subject: rts.NewSubjectID("event1")},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.RelationTuples, 2)
+	assert.Equal(t, "photo1", resp.RelationTuples[0].Object)
+	assert.Equal(t, "photo2", resp.RelationTuples[1].Object)
+	assert.Empty(t, resp.NextPageToken)
+
+	// 舊版的查詢條件
+	resp, err = read.ListRelationTuples(ctx, &rts.ListRelationTuplesRequest{
+		Query: &rts.ListRelationTuplesRequest_Query{Namespace: "Photo", Object: "photo1"},
+	})
+	require.NoError(t, err)
+	assert.Len(t, resp.RelationTuples, 2)
+
+	// 刪除
+	_, err = write.TransactRelationTuples(ctx, &rts.TransactRelationTuplesRequest{
+		RelationTupleDeltas: rts.RelationTupleToDeltas([]*rts.RelationTuple{
+			tuple("Photo", "photo1", "reference", "event1"),
+			tuple("Photo", "photo9", "reference", "event1"), // 不存在的元組
+		}, rts.RelationTupleDelta_ACTION_DELETE),
+	})
+	require.NoError(t, err)
+	assert.Len(t, s.Tuples(), 2)
+
+	_, err = write.DeleteRelationTuples(ctx, &rts.DeleteRelationTuplesRequest{
+		RelationQuery: &rts.RelationQuery{Object: strPtr("photo1")},
+	})
+	require.NoError(t, err)
+	assert.Len(t, s.Tuples(), 1)
+}
+
+func TestTransactIsAtomic(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	write := rts.NewWriteServiceClient(dial(t, s))
+
+	_, err := write.TransactRelationTuples(context.Background(), &rts.TransactRelationTuplesRequest{
+		RelationTupleDeltas: rts.RelationTupleToDeltas([]*rts.RelationTuple{
+			tuple("Photo", "photo1", "reference", "event1"),
+			tuple("Photo", "", "reference", "event1"),
+		}, rts.RelationTupleDelta_ACTION_INSERT),
+	})
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Empty(t, s.Tuples())
+}
+
+func TestPagination(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	read := rts.NewReadServiceClient(dial(t, s))
+
+	for _, object := range []string{"a", "b", "c", "d", "e"} {
+		insert(t, s, tuple("Photo", object, "reference", "event1"))
+	}
+
+	var objects []string
+	token := ""
+	for {
+		resp, err := read.ListRelationTuples(context.Background(), &rts.ListRelationTuplesRequest{
+			RelationQuery: &rts.RelationQuery{},
+			PageSize:      2,
+			PageToken:     token,
+		})
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(resp.RelationTuples), 2)
+		for _, t := range resp.RelationTuples {
+			objects = append(objects, t.Object)
+		}
+		if token = resp.NextPageToken; token == "" {
+			break
+		}
+	}
+	assert.Equal(t, []string{"a", "b", "c", "d", "e"}, objects)
+
+	_, err := read.ListRelationTuples(context.Background(), &rts.ListRelationTuplesRequest{PageToken: "x"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestCheck(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	check := rts.NewCheckServiceClient(dial(t, s))
+	insert(t, s, tuple("Photo", "photo1", "reference", "event1"))
+
+	resp, err := check.Check(context.Background(), &rts.CheckRequest{
+		Namespace: "Photo", Object: "photo1", Relation: "reference", Subject: rts.NewSubjectID("event1"),
+	})
+	require.NoError(t, err)
+	assert.True(t, resp.Allowed)
+
+	resp, err = check.Check(context.Background(), &rts.CheckRequest{Tuple: tuple("Photo", "photo1", "polaroid", "event1")})
+	require.NoError(t, err)
+	assert.False(t, resp.Allowed)
+}
+
+func TestExpand(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	expand := rts.NewExpandServiceClient(dial(t, s))
+
+	insert(t, s,
+		tuple("Event", "event1", "members", "alice"),
+		&rts.RelationTuple{Namespace: "Event", Object: "event1", Relation: "members", Subject: rts.NewSubjectSet("Event", "event2", "members")},
+		tuple("Event", "event2", "members", "bob"),
+	)
+
+	resp, err := expand.Expand(context.Background(), &rts.ExpandRequest{Subject: rts.NewSubjectSet("Event", "event1", "members")})
+	require.NoError(t, err)
+
+	tree := resp.Tree
+	assert.Equal(t, rts.NodeType_NODE_TYPE_UNION, tree.NodeType)
+	require.Len(t, tree.Children, 2)
+	assert.Equal(t, rts.NodeType_NODE_TYPE_LEAF, tree.Children[0].NodeType)
+	assert.Equal(t, "alice", tree.Children[0].Tuple.Subject.GetId())
+	assert.Equal(t, "event2", tree.Children[1].Tuple.Object)
+	require.Len(t, tree.Children[1].Children, 1)
+	assert.Equal(t, "bob", tree.Children[1].Children[0].Tuple.Subject.GetId())
+
+	// 深度限制
+	resp, err = expand.Expand(context.Background(), &rts.ExpandRequest{Subject: rts.NewSubjectSet("Event", "event1", "members"), MaxDepth: 2})
+	require.NoError(t, err)
+	assert.Equal(t, rts.NodeType_NODE_TYPE_LEAF, resp.Tree.Children[1].NodeType)
+	assert.Empty(t, resp.Tree.Children[1].Children)
+
+	_, err = expand.Expand(context.Background(), &rts.ExpandRequest{Subject: rts.NewSubjectID("alice")})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestNamespaces(t *testing.T) {
+	s := NewServer(WithNamespaces("Photo", "Event"))
+	defer s.Close()
+	conn := dial(t, s)
+
+	resp, err := rts.NewNamespacesServiceClient(conn).ListNamespaces(context.Background(), &rts.ListNamespacesRequest{})
+	require.NoError(t, err)
+	require.Len(t, resp.Namespaces, 2)
+	assert.Equal(t, "Photo", resp.Namespaces[0].Name)
+
+	_, err = rts.NewWriteServiceClient(conn).TransactRelationTuples(context.Background(), &rts.TransactRelationTuplesRequest{
+		RelationTupleDeltas: rts.RelationTupleToDeltas([]*rts.RelationTuple{tuple("Album", "a1", "owner", "alice")}, rts.RelationTupleDelta_ACTION_INSERT),
+	})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = rts.NewCheckServiceClient(conn).Check(context.Background(), &rts.CheckRequest{Tuple: tuple("Album", "a1", "owner", "alice")})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestVersion(t *testing.T) {
+	s := NewServer(WithVersion("v0.14.0"))
+	defer s.Close()
+
+	resp, err := rts.NewVersionServiceClient(dial(t, s)).GetVersion(context.Background(), &rts.GetVersionRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "v0.14.0", resp.Version)
+}
+
+func TestReset(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	insert(t, s, tuple("Photo", "photo1", "reference", "event1"))
+	require.NoError(t, s.Reset())
+	assert.Empty(t, s.Tuples())
+}
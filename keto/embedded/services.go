@@ -0,0 +1,249 @@
+package embedded
+
+import (
+	"context"
+	"slices"
+	"strconv"
+
+	"github.com/AidChen0509/oosa_ketosdk/opl"
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// checkNamespace 檢查命名空間是否已知
+func (s *Server) checkNamespace(namespace string) error {
+	if len(s.namespaces) == 0 || slices.Contains(s.namespaces, namespace) {
+		return nil
+	}
+	return status.Errorf(codes.NotFound, "未知的命名空間 %q", namespace)
+}
+
+// validateSubject 檢查主體是否完整
+func (s *Server) validateSubject(subject *rts.Subject) error {
+	if set := subject.GetSet(); set != nil {
+		if set.GetNamespace() == "" || set.GetObject() == "" {
+			return status.Error(codes.InvalidArgument, "主體集合的命名空間和對象不能為空")
+		}
+		return s.checkNamespace(set.GetNamespace())
+	}
+	if subject.GetId() == "" {
+		return status.Error(codes.InvalidArgument, "主體不能為空")
+	}
+	return nil
+}
+
+// validateTuple 檢查元組是否完整
+func (s *Server) validateTuple(t *rts.RelationTuple) error {
+	if t == nil {
+		return status.Error(codes.InvalidArgument, "元組不能為空")
+	}
+	if t.GetNamespace() == "" || t.GetObject() == "" || t.GetRelation() == "" {
+		return status.Error(codes.InvalidArgument, "元組的命名空間、對象和關係不能為空")
+	}
+	if err := s.checkNamespace(t.GetNamespace()); err != nil {
+		return err
+	}
+	return s.validateSubject(t.GetSubject())
+}
+
+// validateRelation 檢查寫入的關係已在 WithSchema 中定義
+func (s *Server) validateRelation(t *rts.RelationTuple) error {
+	ns, ok := opl.Lookup(s.schema, t.GetNamespace())
+	if !ok {
+		return nil
+	}
+	if _, ok := ns.Relation(t.GetRelation()); !ok {
+		return status.Errorf(codes.InvalidArgument, "命名空間 %q 沒有定義關係 %q", ns.Name, t.GetRelation())
+	}
+	return nil
+}
+
+// validateQuery 檢查查詢條件中的命名空間
+func (s *Server) validateQuery(query *rts.RelationQuery) error {
+	if query.Namespace != nil {
+		if err := s.checkNamespace(*query.Namespace); err != nil {
+			return err
+		}
+	}
+	if query.Subject != nil {
+		return s.validateSubject(query.Subject)
+	}
+	return nil
+}
+
+// legacyQuery 將舊版的查詢條件轉換為 RelationQuery，空字符串表示未指定
+func legacyQuery(namespace, object, relation string, subject *rts.Subject) *rts.RelationQuery {
+	query := &rts.RelationQuery{Subject: subject}
+	if namespace != "" {
+		query.Namespace = &namespace
+	}
+	if object != "" {
+		query.Object = &object
+	}
+	if relation != "" {
+		query.Relation = &relation
+	}
+	return query
+}
+
+// readService 實現 ReadService
+type readService struct {
+	rts.UnimplementedReadServiceServer
+	s *Server
+}
+
+// ListRelationTuples 分頁返回符合條件的元組，分頁令牌為下一頁的偏移量
+func (r *readService) ListRelationTuples(ctx context.Context, req *rts.ListRelationTuplesRequest) (*rts.ListRelationTuplesResponse, error) {
+	query := req.GetRelationQuery()
+	if q := req.GetQuery(); query == nil && q != nil {
+		query = legacyQuery(q.GetNamespace(), q.GetObject(), q.GetRelation(), q.GetSubject())
+	}
+	if query == nil {
+		query = &rts.RelationQuery{}
+	}
+	if err := r.s.validateQuery(query); err != nil {
+		return nil, err
+	}
+
+	offset := 0
+	if token := req.GetPageToken(); token != "" {
+		var err error
+		if offset, err = strconv.Atoi(token); err != nil || offset < 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "無效的分頁令牌 %q", token)
+		}
+	}
+	pageSize := int(req.GetPageSize())
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+
+	tuples := r.s.store.list(query)
+	if offset > len(tuples) {
+		offset = len(tuples)
+	}
+	end := min(offset+pageSize, len(tuples))
+
+	resp := &rts.ListRelationTuplesResponse{RelationTuples: tuples[offset:end]}
+	if end < len(tuples) {
+		resp.NextPageToken = strconv.Itoa(end)
+	}
+	return resp, nil
+}
+
+// writeService 實現 WriteService
+type writeService struct {
+	rts.UnimplementedWriteServiceServer
+	s *Server
+}
+
+// TransactRelationTuples 原子地寫入或刪除元組，任何一個變更無效時不套用任何變更
+func (w *writeService) TransactRelationTuples(ctx context.Context, req *rts.TransactRelationTuplesRequest) (*rts.TransactRelationTuplesResponse, error) {
+	for _, delta := range req.GetRelationTupleDeltas() {
+		switch delta.GetAction() {
+		case rts.RelationTupleDelta_ACTION_INSERT, rts.RelationTupleDelta_ACTION_DELETE:
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "無效的操作 %s", delta.GetAction())
+		}
+		if err := w.s.validateTuple(delta.GetRelationTuple()); err != nil {
+			return nil, err
+		}
+		if err := w.s.validateRelation(delta.GetRelationTuple()); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := w.s.store.transact(req.GetRelationTupleDeltas()); err != nil {
+		return nil, status.Errorf(codes.Internal, "無法保存元組: %v", err)
+	}
+	return &rts.TransactRelationTuplesResponse{}, nil
+}
+
+// DeleteRelationTuples 刪除所有符合條件的元組
+func (w *writeService) DeleteRelationTuples(ctx context.Context, req *rts.DeleteRelationTuplesRequest) (*rts.DeleteRelationTuplesResponse, error) {
+	query := req.GetRelationQuery()
+	if q := req.GetQuery(); query == nil && q != nil {
+		query = legacyQuery(q.GetNamespace(), q.GetObject(), q.GetRelation(), q.GetSubject())
+	}
+	if query == nil {
+		return nil, status.Error(codes.InvalidArgument, "刪除條件不能為空")
+	}
+	if err := w.s.validateQuery(query); err != nil {
+		return nil, err
+	}
+
+	if err := w.s.store.deleteMatching(query); err != nil {
+		return nil, status.Errorf(codes.Internal, "無法保存元組: %v", err)
+	}
+	return &rts.DeleteRelationTuplesResponse{}, nil
+}
+
+// checkService 實現 CheckService
+type checkService struct {
+	rts.UnimplementedCheckServiceServer
+	s *Server
+}
+
+// Check 檢查元組是否成立
+func (c *checkService) Check(ctx context.Context, req *rts.CheckRequest) (*rts.CheckResponse, error) {
+	tuple := req.GetTuple()
+	if tuple == nil {
+		tuple = &rts.RelationTuple{
+			Namespace: req.GetNamespace(),
+			Object:    req.GetObject(),
+			Relation:  req.GetRelation(),
+			Subject:   req.GetSubject(),
+		}
+	}
+	if err := c.s.validateTuple(tuple); err != nil {
+		return nil, err
+	}
+
+	allowed := c.s.allowed(tuple, maxDepth(req.GetMaxDepth()))
+	return &rts.CheckResponse{Allowed: allowed}, nil
+}
+
+// expandService 實現 ExpandService
+type expandService struct {
+	rts.UnimplementedExpandServiceServer
+	s *Server
+}
+
+// Expand 展開主體集合，返回其所有主體組成的樹
+func (e *expandService) Expand(ctx context.Context, req *rts.ExpandRequest) (*rts.ExpandResponse, error) {
+	set := req.GetSubject().GetSet()
+	if set == nil {
+		return nil, status.Error(codes.InvalidArgument, "只能展開主體集合")
+	}
+	if err := e.s.validateSubject(req.GetSubject()); err != nil {
+		return nil, err
+	}
+
+	return &rts.ExpandResponse{Tree: e.s.expand(set, maxDepth(req.GetMaxDepth()))}, nil
+}
+
+// namespacesService 實現 NamespacesService
+type namespacesService struct {
+	rts.UnimplementedNamespacesServiceServer
+	s *Server
+}
+
+// ListNamespaces 返回已知的命名空間
+func (n *namespacesService) ListNamespaces(ctx context.Context, req *rts.ListNamespacesRequest) (*rts.ListNamespacesResponse, error) {
+	resp := &rts.ListNamespacesResponse{}
+	for _, name := range n.s.namespaces {
+		resp.Namespaces = append(resp.Namespaces, &rts.Namespace{Name: name})
+	}
+	return resp, nil
+}
+
+// versionService 實現 VersionService
+type versionService struct {
+	rts.UnimplementedVersionServiceServer
+	s *Server
+}
+
+// GetVersion 返回服務器的版本
+func (v *versionService) GetVersion(ctx context.Context, req *rts.GetVersionRequest) (*rts.GetVersionResponse, error) {
+	return &rts.GetVersionResponse{Version: v.s.version}, nil
+}
@@ -0,0 +1,162 @@
+package embedded
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+	"sync"
+
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+	"google.golang.org/protobuf/proto"
+)
+
+// store 內存中的元組存儲
+// 元組按寫入順序返回，重複寫入同一個元組不會改變順序
+type store struct {
+	mu     sync.RWMutex
+	tuples map[string]storedTuple
+	seq    uint64
+
+	// persist 非 nil 時，每次變更後以寫入順序保存所有元組，失敗時不套用變更
+	persist func(tuples []*rts.RelationTuple) error
+}
+
+// storedTuple 已存儲的元組及其寫入順序
+type storedTuple struct {
+	tuple *rts.RelationTuple
+	seq   uint64
+}
+
+// newStore 創建空的存儲
+func newStore() *store {
+	return &store{tuples: map[string]storedTuple{}}
+}
+
+// tupleKey 返回元組的唯一鍵，格式與 Keto 的元組字符串相同
+func tupleKey(t *rts.RelationTuple) string {
+	return fmt.Sprintf("%s:%s#%s@%s", t.GetNamespace(), t.GetObject(), t.GetRelation(), subjectKey(t.GetSubject()))
+}
+
+// subjectKey 返回主體的字符串形式，主體集合以括號包圍
+func subjectKey(s *rts.Subject) string {
+	if set := s.GetSet(); set != nil {
+		return fmt.Sprintf("(%s:%s#%s)", set.GetNamespace(), set.GetObject(), set.GetRelation())
+	}
+	return s.GetId()
+}
+
+// transact 原子地套用所有變更
+func (s *store) transact(deltas []*rts.RelationTupleDelta) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next := maps.Clone(s.tuples)
+	seq := s.seq
+	for _, delta := range deltas {
+		key := tupleKey(delta.GetRelationTuple())
+		switch delta.GetAction() {
+		case rts.RelationTupleDelta_ACTION_INSERT:
+			if _, ok := next[key]; !ok {
+				seq++
+				next[key] = storedTuple{tuple: proto.Clone(delta.GetRelationTuple()).(*rts.RelationTuple), seq: seq}
+			}
+		case rts.RelationTupleDelta_ACTION_DELETE:
+			delete(next, key)
+		}
+	}
+	if err := s.commit(next); err != nil {
+		return err
+	}
+	s.seq = seq
+	return nil
+}
+
+// deleteMatching 刪除所有符合條件的元組
+func (s *store) deleteMatching(query *rts.RelationQuery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next := maps.Clone(s.tuples)
+	for key, stored := range next {
+		if matches(query, stored.tuple) {
+			delete(next, key)
+		}
+	}
+	return s.commit(next)
+}
+
+// commit 保存並替換為新的元組，調用方需持有寫鎖
+func (s *store) commit(next map[string]storedTuple) error {
+	if s.persist != nil {
+		if err := s.persist(ordered(next)); err != nil {
+			return err
+		}
+	}
+	s.tuples = next
+	return nil
+}
+
+// ordered 按寫入順序返回元組
+func ordered(tuples map[string]storedTuple) []*rts.RelationTuple {
+	found := make([]storedTuple, 0, len(tuples))
+	for _, stored := range tuples {
+		found = append(found, stored)
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].seq < found[j].seq })
+
+	out := make([]*rts.RelationTuple, len(found))
+	for i, stored := range found {
+		out[i] = stored.tuple
+	}
+	return out
+}
+
+// list 按寫入順序返回所有符合條件的元組
+func (s *store) list(query *rts.RelationQuery) []*rts.RelationTuple {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var tuples []*rts.RelationTuple
+	for _, t := range ordered(s.tuples) {
+		if matches(query, t) {
+			tuples = append(tuples, proto.Clone(t).(*rts.RelationTuple))
+		}
+	}
+	return tuples
+}
+
+// contains 判斷元組是否存在
+func (s *store) contains(t *rts.RelationTuple) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.tuples[tupleKey(t)]
+	return ok
+}
+
+// reset 刪除所有元組
+func (s *store) reset() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.commit(map[string]storedTuple{})
+}
+
+// matches 判斷元組是否符合查詢條件，未指定的字段匹配任何值
+func matches(query *rts.RelationQuery, t *rts.RelationTuple) bool {
+	if query == nil {
+		return true
+	}
+	if query.Namespace != nil && *query.Namespace != t.GetNamespace() {
+		return false
+	}
+	if query.Object != nil && *query.Object != t.GetObject() {
+		return false
+	}
+	if query.Relation != nil && *query.Relation != t.GetRelation() {
+		return false
+	}
+	if query.Subject != nil && !proto.Equal(query.Subject, t.GetSubject()) {
+		return false
+	}
+	return true
+}
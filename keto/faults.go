@@ -0,0 +1,200 @@
+package keto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// FaultRule 一條故障注入規則
+type FaultRule struct {
+	// Method 規則適用的方法，格式與指標的 method 標籤相同 (例如 "CheckService/Check")
+	// 為空時適用於所有方法
+	Method string
+	// Latency 每次調用增加的延遲
+	Latency time.Duration
+	// Jitter 在 Latency 之上額外增加 [0, Jitter) 的隨機延遲
+	Jitter time.Duration
+	// ErrorRates 每個 gRPC 狀態碼的注入概率，總和不能超過 1
+	ErrorRates map[codes.Code]float64
+}
+
+// FaultCount 一個方法被注入的故障數
+type FaultCount struct {
+	Delayed int // 增加延遲的調用數
+	Failed  int // 返回注入錯誤的調用數
+}
+
+// FaultInjector 為客戶端的 gRPC 調用注入延遲和錯誤，用於測試重試、超時和熔斷
+//
+// 每次調用使用第一條匹配的規則，因此針對特定方法的規則應放在通用規則之前。
+// 注入的錯誤不會發送到 Keto；延遲在 context 結束時中止。
+// 規則可以在運行時通過 SetRules、Enable 和 Disable 修改，可以並發調用。
+type FaultInjector struct {
+	mu      sync.Mutex
+	rules   []FaultRule
+	enabled bool
+	counts  map[string]FaultCount
+	random  func() float64
+}
+
+// WithFaultInjector 使用 f 為客戶端的調用注入故障
+// 注入發生在指標之後，因此注入的延遲和錯誤會反映在指標中
+func WithFaultInjector(f *FaultInjector) Option {
+	return func(o *options) {
+		o.faults = f
+	}
+}
+
+// NewFaultInjector 創建使用 rules 的 FaultInjector，創建後即啟用
+//
+// 返回:
+//   - error: 如規則無效 (概率不在 [0, 1] 內、總和超過 1 或使用 codes.OK) 則返回錯誤
+func NewFaultInjector(rules ...FaultRule) (*FaultInjector, error) {
+	f := &FaultInjector{enabled: true, counts: map[string]FaultCount{}, random: rand.Float64}
+	if err := f.SetRules(rules...); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// SetRules 替換所有規則
+func (f *FaultInjector) SetRules(rules ...FaultRule) error {
+	for i, rule := range rules {
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("keto: 故障規則 %d 無效: %w", i, err)
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = slices.Clone(rules)
+	return nil
+}
+
+// Enable 啟用故障注入
+func (f *FaultInjector) Enable() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.enabled = true
+}
+
+// Disable 停用故障注入，規則保留
+func (f *FaultInjector) Disable() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.enabled = false
+}
+
+// Counts 返回每個方法目前被注入的故障數
+func (f *FaultInjector) Counts() map[string]FaultCount {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	counts := make(map[string]FaultCount, len(f.counts))
+	for method, count := range f.counts {
+		counts[method] = count
+	}
+	return counts
+}
+
+// validate 檢查規則的概率
+func (r FaultRule) validate() error {
+	if r.Latency < 0 || r.Jitter < 0 {
+		return errors.New("延遲不能為負數")
+	}
+	var total float64
+	for code, rate := range r.ErrorRates {
+		if code == codes.OK {
+			return errors.New("不能注入 OK")
+		}
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("%s 的概率 %v 不在 [0, 1] 內", code, rate)
+		}
+		total += rate
+	}
+	if total > 1 {
+		return fmt.Errorf("錯誤概率總和 %v 超過 1", total)
+	}
+	return nil
+}
+
+// plan 為一次調用決定延遲和錯誤碼
+func (f *FaultInjector) plan(method string) (time.Duration, codes.Code, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.enabled {
+		return 0, codes.OK, false
+	}
+	i := slices.IndexFunc(f.rules, func(r FaultRule) bool { return r.Method == "" || r.Method == method })
+	if i < 0 {
+		return 0, codes.OK, false
+	}
+	rule := f.rules[i]
+
+	delay := rule.Latency
+	if rule.Jitter > 0 {
+		delay += time.Duration(f.random() * float64(rule.Jitter))
+	}
+
+	code := codes.OK
+	if len(rule.ErrorRates) > 0 {
+		// 按狀態碼排序，使相同的隨機數得到相同的結果
+		errorCodes := make([]codes.Code, 0, len(rule.ErrorRates))
+		for c := range rule.ErrorRates {
+			errorCodes = append(errorCodes, c)
+		}
+		slices.Sort(errorCodes)
+
+		roll, cumulative := f.random(), 0.0
+		for _, c := range errorCodes {
+			cumulative += rule.ErrorRates[c]
+			if roll < cumulative {
+				code = c
+				break
+			}
+		}
+	}
+
+	count := f.counts[method]
+	if delay > 0 {
+		count.Delayed++
+	}
+	if code != codes.OK {
+		count.Failed++
+	}
+	f.counts[method] = count
+	return delay, code, true
+}
+
+// unaryInterceptor 按規則延遲調用或返回注入的錯誤
+func (f *FaultInjector) unaryInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	name := shortMethod(method)
+	delay, code, ok := f.plan(name)
+	if !ok {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+	if code != codes.OK {
+		return status.Errorf(code, "keto: 注入的故障 (%s)", name)
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
@@ -0,0 +1,124 @@
+package keto
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AidChen0509/oosa_ketosdk/ketotest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// newFaultClient 創建連接到 ketotest 並使用 f 注入故障的客戶端
+func newFaultClient(t *testing.T, f *FaultInjector) *Client {
+	srv := ketotest.NewServer()
+	t.Cleanup(srv.Close)
+	client, err := NewClient(srv.Addr(), srv.Addr(), WithDialOptions(srv.DialOptions()...), WithFaultInjector(f))
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close(context.Background()) })
+	return client
+}
+
+func TestFaultInjector_PerMethodErrors(t *testing.T) {
+	f, err := NewFaultInjector(FaultRule{
+		Method:     "CheckService/Check",
+		ErrorRates: map[codes.Code]float64{codes.Unavailable: 1},
+	})
+	require.NoError(t, err)
+	client := newFaultClient(t, f)
+	ctx := context.Background()
+
+	require.NoError(t, client.CreatePhotoEventReference(ctx, "photo1", "event1"))
+	_, err = client.CheckPermission(ctx, NamespacePhoto, "photo1", RelationReference, "event1")
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	assert.Equal(t, map[string]FaultCount{"CheckService/Check": {Failed: 1}}, f.Counts())
+}
+
+func TestFaultInjector_Latency(t *testing.T) {
+	f, err := NewFaultInjector(FaultRule{Latency: 50 * time.Millisecond})
+	require.NoError(t, err)
+	client := newFaultClient(t, f)
+
+	start := time.Now()
+	_, err = client.GetEventReferencePhotos(context.Background(), "event1")
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	// 延遲超過 context 的期限時返回 DeadlineExceeded
+	require.NoError(t, f.SetRules(FaultRule{Latency: time.Second}))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = client.GetEventReferencePhotos(ctx, "event1")
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+}
+
+func TestFaultInjector_RuntimeControl(t *testing.T) {
+	f, err := NewFaultInjector(FaultRule{ErrorRates: map[codes.Code]float64{codes.Internal: 1}})
+	require.NoError(t, err)
+	client := newFaultClient(t, f)
+	ctx := context.Background()
+
+	_, err = client.GetEventReferencePhotos(ctx, "event1")
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	f.Disable()
+	_, err = client.GetEventReferencePhotos(ctx, "event1")
+	assert.NoError(t, err)
+
+	f.Enable()
+	require.NoError(t, f.SetRules())
+	_, err = client.GetEventReferencePhotos(ctx, "event1")
+	assert.NoError(t, err)
+}
+
+func TestFaultInjector_ErrorRates(t *testing.T) {
+	f, err := NewFaultInjector(FaultRule{ErrorRates: map[codes.Code]float64{
+		codes.Unavailable:       0.25,
+		codes.ResourceExhausted: 0.25,
+	}})
+	require.NoError(t, err)
+
+	// codes.ResourceExhausted (8) 排在 codes.Unavailable (14) 之前
+	for roll, want := range map[float64]codes.Code{
+		0.1: codes.ResourceExhausted,
+		0.3: codes.Unavailable,
+		0.6: codes.OK,
+	} {
+		f.random = func() float64 { return roll }
+		_, code, ok := f.plan("ReadService/ListRelationTuples")
+		assert.True(t, ok)
+		assert.Equal(t, want, code, "roll %v", roll)
+	}
+}
+
+func TestFaultInjector_FirstMatchingRule(t *testing.T) {
+	f, err := NewFaultInjector(
+		FaultRule{Method: "CheckService/Check", Latency: time.Millisecond},
+		FaultRule{Latency: time.Second},
+	)
+	require.NoError(t, err)
+
+	delay, _, _ := f.plan("CheckService/Check")
+	assert.Equal(t, time.Millisecond, delay)
+	delay, _, _ = f.plan("ReadService/ListRelationTuples")
+	assert.Equal(t, time.Second, delay)
+}
+
+func TestFaultInjector_InvalidRules(t *testing.T) {
+	tests := map[string]FaultRule{
+		"負延遲":    {Latency: -time.Second},
+		"OK":     {ErrorRates: map[codes.Code]float64{codes.OK: 0.5}},
+		"概率超出範圍": {ErrorRates: map[codes.Code]float64{codes.Internal: 1.5}},
+		"總和超過 1": {ErrorRates: map[codes.Code]float64{codes.Internal: 0.6, codes.Unavailable: 0.6}},
+	}
+	for name, rule := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewFaultInjector(rule)
+			assert.ErrorContains(t, err, "故障規則 0 無效")
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package keto
+
+import (
+	"context"
+	"time"
+
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+)
+
+// WithCheckHedging 啟用 CheckPermission 的對沖請求
+// 檢查在 delay 內沒有返回時，再發送一個相同的檢查，使用先成功返回的結果並取消另一個
+// 配合 WithReadReplicas 使用時，對沖請求通常會發送到另一個副本
+// 對沖請求同樣計入 WithCheckRateLimit 的限制，沒有可用的令牌時不發送，只等待原請求
+// delay 應接近檢查延遲的高百分位 (例如 p95)；delay <= 0 時不啟用
+func WithCheckHedging(delay time.Duration) Option {
+	return func(o *options) {
+		o.hedgeDelay = delay
+	}
+}
+
+// checkResult 一次檢查的結果
+type checkResult struct {
+	resp  *rts.CheckResponse
+	err   error
+	hedge bool
+}
+
+// check 發送檢查請求，啟用對沖時可能發送兩次
+func (k *Client) check(ctx context.Context, req *rts.CheckRequest) (*rts.CheckResponse, error) {
+	if k.hedgeDelay <= 0 {
+		return k.checkClient.Check(ctx, req)
+	}
+
+	// 返回時取消仍在進行中的請求
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan checkResult, 2)
+	send := func(hedge bool) {
+		resp, err := k.checkClient.Check(ctx, req)
+		results <- checkResult{resp: resp, err: err, hedge: hedge}
+	}
+
+	go send(false)
+
+	timer := time.NewTimer(k.hedgeDelay)
+	defer timer.Stop()
+	select {
+	case r := <-results:
+		return r.resp, r.err
+	case <-timer.C:
+	}
+
+	// 對沖請求不等待令牌，避免超出檢查的限流
+	if !k.limiter.allow(limitCheck) {
+		r := <-results
+		return r.resp, r.err
+	}
+	go send(true)
+	k.metrics.observeHedge()
+
+	// 使用先成功的結果；兩個都失敗時返回先返回的錯誤
+	first := <-results
+	if first.err == nil {
+		if first.hedge {
+			k.metrics.observeHedgeWin()
+		}
+		return first.resp, nil
+	}
+	second := <-results
+	if second.err == nil {
+		if second.hedge {
+			k.metrics.observeHedgeWin()
+		}
+		return second.resp, nil
+	}
+	return first.resp, first.err
+}
+
+// observeHedge 記錄發出的對沖檢查，未啟用指標時不做任何事
+func (m *clientMetrics) observeHedge() {
+	if m != nil {
+		m.hedges.Inc()
+	}
+}
+
+// observeHedgeWin 記錄對沖檢查勝出，未啟用指標時不做任何事
+func (m *clientMetrics) observeHedgeWin() {
+	if m != nil {
+		m.hedgeWins.Inc()
+	}
+}
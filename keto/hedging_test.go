@@ -0,0 +1,169 @@
+package keto
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// scriptedCheckClient 第 n 次調用使用 calls[n] 的 CheckService
+type scriptedCheckClient struct {
+	calls    []func(ctx context.Context) (*rts.CheckResponse, error)
+	n        atomic.Int32
+	canceled chan int
+}
+
+func (c *scriptedCheckClient) Check(ctx context.Context, in *rts.CheckRequest, opts ...grpc.CallOption) (*rts.CheckResponse, error) {
+	i := int(c.n.Add(1)) - 1
+	resp, err := c.calls[i](ctx)
+	if ctx.Err() != nil && c.canceled != nil {
+		c.canceled <- i
+	}
+	return resp, err
+}
+
+// respondAfter 在 d 後返回 allowed，context 取消時提前返回
+func respondAfter(d time.Duration, allowed bool, err error) func(ctx context.Context) (*rts.CheckResponse, error) {
+	return func(ctx context.Context) (*rts.CheckResponse, error) {
+		select {
+		case <-time.After(d):
+			if err != nil {
+				return nil, err
+			}
+			return &rts.CheckResponse{Allowed: allowed}, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// newHedgingClient 創建啟用對沖和指標的客戶端
+func newHedgingClient(t *testing.T, checkClient rts.CheckServiceClient) (*Client, *clientMetrics) {
+	metrics := newClientMetrics()
+	require.NoError(t, metrics.register(prometheus.NewRegistry()))
+	return &Client{checkClient: checkClient, hedgeDelay: 20 * time.Millisecond, metrics: metrics}, metrics
+}
+
+func TestCheckHedging_FastPrimary(t *testing.T) {
+	checkClient := &scriptedCheckClient{calls: []func(context.Context) (*rts.CheckResponse, error){
+		respondAfter(0, true, nil),
+	}}
+	client, metrics := newHedgingClient(t, checkClient)
+
+	allowed, err := client.CheckPermission(context.Background(), "Photo", "photo1", "reference", "event1")
+
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, int32(1), checkClient.n.Load())
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.hedges))
+}
+
+func TestCheckHedging_HedgeWins(t *testing.T) {
+	checkClient := &scriptedCheckClient{
+		calls: []func(context.Context) (*rts.CheckResponse, error){
+			respondAfter(time.Minute, false, nil),
+			respondAfter(0, true, nil),
+		},
+		canceled: make(chan int, 2),
+	}
+	client, metrics := newHedgingClient(t, checkClient)
+
+	start := time.Now()
+	allowed, err := client.CheckPermission(context.Background(), "Photo", "photo1", "reference", "event1")
+
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, int32(2), checkClient.n.Load())
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.hedges))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.hedgeWins))
+
+	// 慢的原請求被取消
+	select {
+	case i := <-checkClient.canceled:
+		assert.Equal(t, 0, i)
+	case <-time.After(time.Second):
+		t.Fatal("原請求沒有被取消")
+	}
+}
+
+func TestCheckHedging_PrimaryWinsAfterHedge(t *testing.T) {
+	checkClient := &scriptedCheckClient{calls: []func(context.Context) (*rts.CheckResponse, error){
+		respondAfter(30*time.Millisecond, true, nil),
+		respondAfter(time.Minute, false, nil),
+	}}
+	client, metrics := newHedgingClient(t, checkClient)
+
+	allowed, err := client.CheckPermission(context.Background(), "Photo", "photo1", "reference", "event1")
+
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.hedges))
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.hedgeWins))
+}
+
+func TestCheckHedging_FailedPrimaryFallsBackToHedge(t *testing.T) {
+	checkClient := &scriptedCheckClient{calls: []func(context.Context) (*rts.CheckResponse, error){
+		respondAfter(30*time.Millisecond, false, errors.New("unavailable")),
+		respondAfter(60*time.Millisecond, true, nil),
+	}}
+	client, metrics := newHedgingClient(t, checkClient)
+
+	allowed, err := client.CheckPermission(context.Background(), "Photo", "photo1", "reference", "event1")
+
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.hedgeWins))
+}
+
+func TestCheckHedging_BothFail(t *testing.T) {
+	checkClient := &scriptedCheckClient{calls: []func(context.Context) (*rts.CheckResponse, error){
+		respondAfter(30*time.Millisecond, false, errors.New("first")),
+		respondAfter(40*time.Millisecond, false, errors.New("second")),
+	}}
+	client, _ := newHedgingClient(t, checkClient)
+
+	_, err := client.CheckPermission(context.Background(), "Photo", "photo1", "reference", "event1")
+
+	assert.EqualError(t, err, "first")
+}
+
+// 測試沒有可用的檢查令牌時不發送對沖請求
+func TestCheckHedging_RespectsRateLimit(t *testing.T) {
+	checkClient := &scriptedCheckClient{calls: []func(context.Context) (*rts.CheckResponse, error){
+		respondAfter(60*time.Millisecond, true, nil),
+		respondAfter(0, false, nil),
+	}}
+	client, metrics := newHedgingClient(t, checkClient)
+	// 原請求用完唯一的令牌
+	client.limiter = newRateLimiter([limitClassCount]*RateLimit{limitCheck: {Rate: 0.001, Burst: 1}})
+
+	allowed, err := client.CheckPermission(context.Background(), "Photo", "photo1", "reference", "event1")
+
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, int32(1), checkClient.n.Load())
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.hedges))
+}
+
+func TestCheckHedging_Disabled(t *testing.T) {
+	checkClient := &scriptedCheckClient{calls: []func(context.Context) (*rts.CheckResponse, error){
+		respondAfter(50*time.Millisecond, true, nil),
+	}}
+	client := &Client{checkClient: checkClient}
+
+	allowed, err := client.CheckPermission(context.Background(), "Photo", "photo1", "reference", "event1")
+
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, int32(1), checkClient.n.Load())
+}
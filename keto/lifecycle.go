@@ -0,0 +1,68 @@
+package keto
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrClientClosed 客戶端已關閉
+var ErrClientClosed = errors.New("keto: 客戶端已關閉")
+
+// lifecycle 跟蹤客戶端的進行中請求，用於 Close 時等待請求完成
+// 租戶客戶端與原客戶端共用同一個 lifecycle；nil 表示不跟蹤
+type lifecycle struct {
+	mu       sync.Mutex
+	closed   bool
+	inFlight int
+	drained  chan struct{}
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// newLifecycle 創建 lifecycle
+func newLifecycle() *lifecycle {
+	return &lifecycle{drained: make(chan struct{})}
+}
+
+// acquire 開始一個請求，客戶端已關閉時返回 ErrClientClosed
+func (l *lifecycle) acquire() error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return ErrClientClosed
+	}
+	l.inFlight++
+	return nil
+}
+
+// release 結束一個請求
+func (l *lifecycle) release() {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inFlight--
+	if l.closed && l.inFlight == 0 {
+		close(l.drained)
+	}
+}
+
+// shutdown 停止接受新請求，返回所有進行中請求結束時關閉的 channel
+func (l *lifecycle) shutdown() <-chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.closed {
+		l.closed = true
+		if l.inFlight == 0 {
+			close(l.drained)
+		}
+	}
+	return l.drained
+}
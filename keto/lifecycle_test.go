@@ -0,0 +1,118 @@
+package keto
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClose_WithoutConnections(t *testing.T) {
+	client := &Client{}
+
+	assert.NoError(t, client.Close(context.Background()))
+	assert.NoError(t, client.Close(context.Background()))
+}
+
+func TestClose_Idempotent(t *testing.T) {
+	client, err := NewClient("127.0.0.1:4467", "127.0.0.1:4466")
+	require.NoError(t, err)
+
+	assert.NoError(t, client.Close(context.Background()))
+	assert.NoError(t, client.Close(context.Background()))
+
+	// 關閉後不再接受新的調用
+	_, err = client.CheckPermission(context.Background(), "Photo", "photo1", "reference", "event1")
+	assert.True(t, errors.Is(err, ErrClientClosed))
+	err = client.CreatePhotoEventReference(context.Background(), "photo1", "event1")
+	assert.True(t, errors.Is(err, ErrClientClosed))
+	_, err = client.GetPhotoEvents(context.Background(), "photo1")
+	assert.True(t, errors.Is(err, ErrClientClosed))
+}
+
+func TestClose_DrainsInFlight(t *testing.T) {
+	release := make(chan struct{})
+	checkClient := &scriptedCheckClient{calls: []func(context.Context) (*rts.CheckResponse, error){
+		func(ctx context.Context) (*rts.CheckResponse, error) {
+			<-release
+			return &rts.CheckResponse{Allowed: true}, nil
+		},
+	}}
+	client := &Client{checkClient: checkClient, lifecycle: newLifecycle()}
+
+	type result struct {
+		allowed bool
+		err     error
+	}
+	results := make(chan result, 1)
+	go func() {
+		allowed, err := client.CheckPermission(context.Background(), "Photo", "photo1", "reference", "event1")
+		results <- result{allowed, err}
+	}()
+	require.Eventually(t, func() bool { return checkClient.n.Load() == 1 }, time.Second, time.Millisecond)
+
+	closed := make(chan error, 1)
+	go func() { closed <- client.Close(context.Background()) }()
+
+	// 等待進行中的調用時，新的調用被拒絕
+	require.Eventually(t, func() bool {
+		_, err := client.CheckPermission(context.Background(), "Photo", "photo1", "reference", "event1")
+		return errors.Is(err, ErrClientClosed)
+	}, time.Second, time.Millisecond)
+
+	select {
+	case <-closed:
+		t.Fatal("Close 沒有等待進行中的調用")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	r := <-results
+	assert.NoError(t, r.err)
+	assert.True(t, r.allowed)
+	assert.NoError(t, <-closed)
+}
+
+func TestClose_ContextExpires(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	checkClient := &scriptedCheckClient{calls: []func(context.Context) (*rts.CheckResponse, error){
+		func(ctx context.Context) (*rts.CheckResponse, error) {
+			<-release
+			return &rts.CheckResponse{}, nil
+		},
+	}}
+	client := &Client{checkClient: checkClient, lifecycle: newLifecycle()}
+
+	go client.CheckPermission(context.Background(), "Photo", "photo1", "reference", "event1")
+	require.Eventually(t, func() bool { return checkClient.n.Load() == 1 }, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := client.Close(ctx)
+
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "err = %v", err)
+	// 之後的調用返回相同的結果
+	assert.Equal(t, err, client.Close(context.Background()))
+}
+
+func TestClose_TenantClient(t *testing.T) {
+	client, err := NewClient("127.0.0.1:4467", "127.0.0.1:4466")
+	require.NoError(t, err)
+	tenantClient, err := client.ForTenant("acme")
+	require.NoError(t, err)
+
+	// 關閉租戶客戶端不影響原客戶端
+	assert.NoError(t, tenantClient.Close(context.Background()))
+	assert.NoError(t, client.lifecycle.acquire())
+	client.lifecycle.release()
+
+	// 關閉原客戶端後租戶客戶端也不再接受調用
+	require.NoError(t, client.Close(context.Background()))
+	_, err = tenantClient.CheckPermission(context.Background(), "Photo", "photo1", "reference", "event1")
+	assert.True(t, errors.Is(err, ErrClientClosed))
+}
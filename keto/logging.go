@@ -0,0 +1,91 @@
+package keto
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+)
+
+// WithLogger 設置客戶端使用的結構化日誌
+// 每個 Keto 調用都會以 debug 級別記錄元組、耗時和結果，默認使用 slog.Default()
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// requestIDKey context 中請求 ID 的鍵
+type requestIDKey struct{}
+
+// ContextWithRequestID 返回帶有請求 ID 的 context
+// 客戶端的日誌會包含此請求 ID，方便與上游請求關聯
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext 返回 context 中的請求 ID，沒有時返回空字符串
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// log 返回客戶端的日誌
+func (k *Client) log() *slog.Logger {
+	if k.logger != nil {
+		return k.logger
+	}
+	return slog.Default()
+}
+
+// logCall 以 debug 級別記錄一次 Keto 調用
+func (k *Client) logCall(ctx context.Context, op string, start time.Time, err error, attrs ...slog.Attr) {
+	logger := k.log()
+	if !logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+
+	attrs = append(attrs,
+		slog.String("op", op),
+		slog.Duration("duration", time.Since(start)),
+	)
+	if id := RequestIDFromContext(ctx); id != "" {
+		attrs = append(attrs, slog.String("request_id", id))
+	}
+	if k.tenant != "" {
+		attrs = append(attrs, slog.String("tenant", string(k.tenant)))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("outcome", "error"), slog.String("error", err.Error()))
+	} else {
+		attrs = append(attrs, slog.String("outcome", "ok"))
+	}
+	logger.LogAttrs(ctx, slog.LevelDebug, "keto 調用", attrs...)
+}
+
+// tupleStrings 將元組轉換為字符串格式，用於日誌
+func tupleStrings(tuples []RelationTuple) []string {
+	strs := make([]string, len(tuples))
+	for i, tuple := range tuples {
+		strs[i] = tuple.String()
+	}
+	return strs
+}
+
+// queryString 將查詢條件轉換為元組字符串格式，未指定的部分以 * 表示
+// 例如 Photo:*#reference@(Event:event1#)
+func queryString(query *rts.RelationQuery) string {
+	orAny := func(s *string) string {
+		if s == nil {
+			return "*"
+		}
+		return *s
+	}
+
+	subject := "*"
+	if sub, err := SubjectFromProto(query.GetSubject()); err == nil {
+		subject = sub.String()
+	}
+	return orAny(query.Namespace) + ":" + orAny(query.Object) + "#" + orAny(query.Relation) + "@" + subject
+}
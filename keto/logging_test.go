@@ -0,0 +1,99 @@
+package keto
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// decodeLogs 解析 JSON 日誌的每一行
+func decodeLogs(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestClient_LogsCalls(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	mockWriteClient := new(MockWriteServiceClient)
+	mockReadClient := new(MockReadServiceClient)
+	mockCheckClient := new(MockCheckServiceClient)
+	client := &Client{
+		writeClient: mockWriteClient,
+		readClient:  mockReadClient,
+		checkClient: mockCheckClient,
+		logger:      logger,
+	}
+
+	mockWriteClient.On("TransactRelationTuples", mock.Anything, mock.Anything).Return(&rts.TransactRelationTuplesResponse{}, nil)
+	mockReadClient.On("ListRelationTuples", mock.Anything, mock.Anything).Return((*rts.ListRelationTuplesResponse)(nil), errors.New("unavailable"))
+	mockCheckClient.On("Check", mock.Anything, mock.Anything).Return(&rts.CheckResponse{Allowed: true}, nil)
+
+	ctx := ContextWithRequestID(context.Background(), "req-1")
+	require.NoError(t, client.CreatePhotoEventReference(ctx, "photo1", "event1"))
+	_, err := client.GetEventReferencePhotos(ctx, "event1")
+	require.Error(t, err)
+	_, err = client.CheckPermission(ctx, NamespacePhoto, "photo1", RelationReference, "event1")
+	require.NoError(t, err)
+
+	records := decodeLogs(t, &buf)
+	require.Len(t, records, 3)
+
+	assert.Equal(t, "DEBUG", records[0]["level"])
+	assert.Equal(t, "transact", records[0]["op"])
+	assert.Equal(t, "ACTION_INSERT", records[0]["action"])
+	assert.Equal(t, []any{"Photo:photo1#reference@(Event:event1#)"}, records[0]["tuples"])
+	assert.Equal(t, "ok", records[0]["outcome"])
+	assert.Equal(t, "req-1", records[0]["request_id"])
+	assert.Contains(t, records[0], "duration")
+
+	assert.Equal(t, "list", records[1]["op"])
+	assert.Equal(t, "error", records[1]["outcome"])
+	assert.Equal(t, "unavailable", records[1]["error"])
+
+	assert.Equal(t, "check", records[2]["op"])
+	assert.Equal(t, "Photo:photo1#reference@(Event:event1#)", records[2]["tuple"])
+	assert.Equal(t, true, records[2]["allowed"])
+}
+
+func TestClient_LogsNothingAboveDebug(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	mockCheckClient := new(MockCheckServiceClient)
+	client := &Client{checkClient: mockCheckClient, logger: logger}
+	mockCheckClient.On("Check", mock.Anything, mock.Anything).Return(&rts.CheckResponse{Allowed: true}, nil)
+
+	_, err := client.CheckPermission(context.Background(), NamespacePhoto, "photo1", RelationReference, "event1")
+	require.NoError(t, err)
+	assert.Empty(t, buf.String())
+}
+
+func TestQueryString(t *testing.T) {
+	ns, rel := NamespacePhoto, RelationReference
+	query := &rts.RelationQuery{
+		Namespace: &ns,
+		Relation:  &rel,
+		Subject:   rts.NewSubjectID("event1"),
+	}
+	assert.Equal(t, "Photo:*#reference@event1", queryString(query))
+	assert.Equal(t, "*:*#*@*", queryString(&rts.RelationQuery{}))
+}
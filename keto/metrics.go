@@ -0,0 +1,127 @@
+package keto
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// WithMetrics 將客戶端的 Prometheus 指標註冊到 reg
+// 多個客戶端可以使用同一個 reg，指標會合併統計
+func WithMetrics(reg prometheus.Registerer) Option {
+	return func(o *options) {
+		o.metricsRegisterer = reg
+	}
+}
+
+// clientMetrics 客戶端的 Prometheus 指標
+type clientMetrics struct {
+	duration *prometheus.HistogramVec // 每個 gRPC 方法的延遲，按狀態碼分類
+	errors   *prometheus.CounterVec   // 每個 gRPC 方法的錯誤數，按狀態碼分類
+
+	hedges    prometheus.Counter // 發出的對沖檢查數
+	hedgeWins prometheus.Counter // 對沖檢查先於原請求成功返回的次數
+}
+
+// newClientMetrics 創建客戶端指標，調用 register 後才會註冊
+func newClientMetrics() *clientMetrics {
+	return &clientMetrics{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "keto",
+			Subsystem: "client",
+			Name:      "request_duration_seconds",
+			Help:      "Keto gRPC 調用的延遲 (秒)",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "code"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "keto",
+			Subsystem: "client",
+			Name:      "errors_total",
+			Help:      "Keto gRPC 調用失敗的次數",
+		}, []string{"method", "code"}),
+		hedges: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "keto",
+			Subsystem: "client",
+			Name:      "check_hedges_total",
+			Help:      "發出的對沖權限檢查數",
+		}),
+		hedgeWins: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "keto",
+			Subsystem: "client",
+			Name:      "check_hedge_wins_total",
+			Help:      "對沖權限檢查先於原請求返回的次數",
+		}),
+	}
+}
+
+// register 將指標註冊到 reg
+// 指標已被其他客戶端註冊時沿用已註冊的指標；失敗時撤銷本次註冊的指標
+func (m *clientMetrics) register(reg prometheus.Registerer) error {
+	var registered []prometheus.Collector
+	fail := func(err error) error {
+		for _, c := range registered {
+			reg.Unregister(c)
+		}
+		return err
+	}
+
+	var err error
+	if m.duration, err = registerCollector(reg, m.duration, &registered); err != nil {
+		return fail(err)
+	}
+	if m.errors, err = registerCollector(reg, m.errors, &registered); err != nil {
+		return fail(err)
+	}
+	if m.hedges, err = registerCollector(reg, m.hedges, &registered); err != nil {
+		return fail(err)
+	}
+	if m.hedgeWins, err = registerCollector(reg, m.hedgeWins, &registered); err != nil {
+		return fail(err)
+	}
+	return nil
+}
+
+// registerCollector 註冊指標，已註冊時返回已存在的指標
+// 新註冊的指標會加入 registered
+func registerCollector[T prometheus.Collector](reg prometheus.Registerer, c T, registered *[]prometheus.Collector) (T, error) {
+	if err := reg.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			if existing, ok := are.ExistingCollector.(T); ok {
+				return existing, nil
+			}
+		}
+		return c, err
+	}
+	*registered = append(*registered, c)
+	return c, nil
+}
+
+// unaryInterceptor 記錄每個 gRPC 調用的延遲和錯誤
+func (m *clientMetrics) unaryInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+
+	name := shortMethod(method)
+	code := status.Code(err).String()
+	m.duration.WithLabelValues(name, code).Observe(time.Since(start).Seconds())
+	if err != nil {
+		m.errors.WithLabelValues(name, code).Inc()
+	}
+	return err
+}
+
+// shortMethod 去除 gRPC 方法名稱的包名
+// 例如 /ory.keto.relation_tuples.v1alpha2.CheckService/Check 轉換為 CheckService/Check
+func shortMethod(method string) string {
+	method = strings.TrimPrefix(method, "/")
+	if i := strings.LastIndex(method, "."); i >= 0 {
+		return method[i+1:]
+	}
+	return method
+}
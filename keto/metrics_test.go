@@ -0,0 +1,97 @@
+package keto
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const checkMethod = "/ory.keto.relation_tuples.v1alpha2.CheckService/Check"
+
+func TestShortMethod(t *testing.T) {
+	assert.Equal(t, "CheckService/Check", shortMethod(checkMethod))
+	assert.Equal(t, "WriteService/TransactRelationTuples", shortMethod("/ory.keto.relation_tuples.v1alpha2.WriteService/TransactRelationTuples"))
+	assert.Equal(t, "Service/Method", shortMethod("/Service/Method"))
+}
+
+func TestClientMetrics_UnaryInterceptor(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := newClientMetrics()
+	require.NoError(t, m.register(reg))
+
+	ok := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return nil
+	}
+	unavailable := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return status.Error(codes.Unavailable, "keto down")
+	}
+
+	assert.NoError(t, m.unaryInterceptor(context.Background(), checkMethod, nil, nil, nil, ok))
+	assert.NoError(t, m.unaryInterceptor(context.Background(), checkMethod, nil, nil, nil, ok))
+	err := m.unaryInterceptor(context.Background(), checkMethod, nil, nil, nil, unavailable)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	assert.Equal(t, 2, testutil.CollectAndCount(m.duration))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.errors.WithLabelValues("CheckService/Check", "Unavailable")))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.errors))
+}
+
+func TestClientMetrics_SharedRegistry(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	first, second := newClientMetrics(), newClientMetrics()
+	require.NoError(t, first.register(reg))
+	require.NoError(t, second.register(reg))
+
+	// 第二個客戶端沿用已註冊的指標
+	assert.Same(t, first.duration, second.duration)
+	assert.Same(t, first.errors, second.errors)
+}
+
+func TestNewClient_WithMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	client, err := NewClient("127.0.0.1:4467", "127.0.0.1:4466", WithMetrics(reg))
+	require.NoError(t, err)
+	defer client.Close(context.Background())
+
+	// 與其他名稱衝突的指標註冊失敗時返回錯誤
+	conflict := prometheus.NewRegistry()
+	conflict.MustRegister(prometheus.NewCounter(prometheus.CounterOpts{Name: "keto_client_request_duration_seconds", Help: "conflict"}))
+	_, err = NewClient("127.0.0.1:4467", "127.0.0.1:4466", WithMetrics(conflict))
+	assert.Error(t, err)
+}
+
+// 測試註冊失敗時撤銷已註冊的指標
+func TestClientMetrics_RegisterRollback(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(prometheus.NewCounter(prometheus.CounterOpts{Name: "keto_client_check_hedges_total", Help: "conflict"}))
+
+	assert.Error(t, newClientMetrics().register(reg))
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 1)
+	assert.Equal(t, "keto_client_check_hedges_total", families[0].GetName())
+}
+
+// 測試連接失敗時指標不會留在調用方的 reg 中
+func TestNewClient_WithMetricsDialFailure(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	_, err := NewClient("ftp://keto", "ftp://keto", WithTransport(TransportREST), WithMetrics(reg))
+	require.Error(t, err)
+	_, err = NewClient("127.0.0.1:1", "127.0.0.1:1", WithMetrics(reg), WithEagerConnect(50*time.Millisecond))
+	require.Error(t, err)
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	assert.Empty(t, families)
+}
@@ -0,0 +1,64 @@
+package keto
+
+import (
+	"context"
+	"errors"
+
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+)
+
+// MigrateEventSubjects 將舊版本以主體 ID 寫入的照片-事件元組 (Photo:photo1#reference@event1)
+// 改寫為事件主體集合 (Photo:photo1#reference@(Event:event1#))
+// 所有舊格式的元組在一個事務中刪除並以新格式寫入，失敗時不會改變任何元組；重複執行是安全的。
+// 使用 ContextWithDryRun 的 context 可以只查看將要執行的變更。
+// 需要使用未限定租戶的客戶端，租戶前綴會原樣保留在改寫後的元組中。
+//
+// 參數:
+//   - ctx: 請求的 context，用於取消、截止時間和追蹤
+//
+// 返回:
+//   - int: 改寫的元組數量
+//   - error: 如客戶端已限定在租戶內或操作失敗則返回錯誤
+func (k *Client) MigrateEventSubjects(ctx context.Context) (int, error) {
+	if k.tenant != "" {
+		return 0, errors.New("keto: 遷移需要使用未限定租戶的客戶端")
+	}
+
+	var legacy, migrated []RelationTuple
+	for _, relation := range []string{RelationReference, RelationPolaroid} {
+		query := &rts.RelationQuery{
+			Namespace: strPtr(NamespacePhoto),
+			Relation:  strPtr(relation),
+		}
+		pageToken := ""
+		for {
+			tuples, next, err := k.listRelationTuplesPage(ctx, query, pageToken)
+			if err != nil {
+				return 0, err
+			}
+			for _, tuple := range tuples {
+				if tuple.Subject.Set != nil {
+					continue
+				}
+				legacy = append(legacy, tuple)
+				tuple.Subject = eventSubject(tuple.Subject.ID)
+				migrated = append(migrated, tuple)
+			}
+			if pageToken = next; pageToken == "" {
+				break
+			}
+		}
+	}
+	if len(legacy) == 0 {
+		return 0, nil
+	}
+
+	err := k.transactDeltas(ctx,
+		tupleDeltas{action: rts.RelationTupleDelta_ACTION_DELETE, tuples: legacy},
+		tupleDeltas{action: rts.RelationTupleDelta_ACTION_INSERT, tuples: migrated},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return len(legacy), nil
+}
@@ -0,0 +1,83 @@
+package keto
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/AidChen0509/oosa_ketosdk/ketotest"
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 測試舊格式的元組被改寫為事件主體集合
+func TestMigrateEventSubjects(t *testing.T) {
+	ctx := context.Background()
+	srv := ketotest.NewServer(ketotest.WithSchema(Schema()))
+	defer srv.Close()
+	client, err := NewClient(srv.Addr(), srv.Addr(), WithDialOptions(srv.DialOptions()...))
+	require.NoError(t, err)
+	defer client.Close(ctx)
+
+	// 超過一頁的新格式元組，舊格式的元組在最後一頁
+	for i := range ketotest.DefaultPageSize + 5 {
+		require.NoError(t, client.CreatePhotoEventReference(ctx, fmt.Sprintf("new%d", i), "event0"))
+	}
+	srv.Insert(
+		&rts.RelationTuple{Namespace: NamespacePhoto, Object: "photo1", Relation: RelationReference, Subject: rts.NewSubjectID("event1")},
+		&rts.RelationTuple{Namespace: NamespacePhoto, Object: "photo2", Relation: RelationPolaroid, Subject: rts.NewSubjectID("event1")},
+		&rts.RelationTuple{Namespace: NamespaceEvent, Object: "event1", Relation: "members", Subject: rts.NewSubjectID("alice")},
+	)
+
+	photos, err := client.GetEventReferencePhotos(ctx, "event1")
+	require.NoError(t, err)
+	assert.Empty(t, photos, "舊格式的元組不會被讀取")
+
+	// 試運行不改變元組
+	dryCtx, plan := ContextWithDryRun(ctx)
+	n, err := client.MigrateEventSubjects(dryCtx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Len(t, plan.Deltas(), 4)
+	photos, err = client.GetEventReferencePhotos(ctx, "event1")
+	require.NoError(t, err)
+	assert.Empty(t, photos)
+
+	n, err = client.MigrateEventSubjects(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	photos, err = client.GetEventReferencePhotos(ctx, "event1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"photo1"}, photos)
+	polaroids, err := client.GetEventPolaroidPhotos(ctx, "event1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"photo2"}, polaroids)
+
+	allowed, err := client.CheckPermission(ctx, NamespacePhoto, "photo2", "view", "alice")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	// 改寫後可以通過 SDK 刪除
+	require.NoError(t, client.DeletePhotoEventRelation(ctx, "photo1", "event1", RelationReference))
+	photos, err = client.GetEventReferencePhotos(ctx, "event1")
+	require.NoError(t, err)
+	assert.Empty(t, photos)
+
+	// 沒有舊格式的元組時不發送請求
+	n, err = client.MigrateEventSubjects(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func TestMigrateEventSubjects_Tenant(t *testing.T) {
+	client, err := NewClient("localhost:4467", "localhost:4466")
+	require.NoError(t, err)
+	defer client.Close(context.Background())
+
+	scoped, err := client.ForTenant("acme")
+	require.NoError(t, err)
+	_, err = scoped.MigrateEventSubjects(context.Background())
+	assert.Error(t, err)
+}
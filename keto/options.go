@@ -0,0 +1,102 @@
+package keto
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Option 配置 Client 的選項
+type Option func(*options)
+
+// options NewClient 使用的配置
+type options struct {
+	ids            IDValidator
+	logger         *slog.Logger
+	auditSinks     []AuditSink
+	rateLimits     [limitClassCount]*RateLimit
+	tracerProvider trace.TracerProvider
+	propagator     propagation.TextMapPropagator
+
+	metricsRegisterer prometheus.Registerer
+	metrics           *clientMetrics
+
+	readReplicas     []string
+	balancePolicy    BalancePolicy
+	ejectionFailures int
+	ejectionCooldown time.Duration
+
+	hedgeDelay time.Duration
+
+	eagerConnect   bool
+	connectTimeout time.Duration
+	waitForReady   *bool
+
+	timeouts [timeoutClassCount]time.Duration
+
+	dryRun bool
+
+	faults   *FaultInjector
+	recorder *Recorder
+	replayer *Replayer
+
+	transport  Transport
+	httpClient *http.Client
+
+	extraDialOptions []grpc.DialOption
+}
+
+// dialOptions 返回建立 gRPC 連接使用的選項
+func (o *options) dialOptions() []grpc.DialOption {
+	dialOptions := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		tracingDialOption(o),
+	}
+	if o.waitForReady != nil {
+		dialOptions = append(dialOptions, grpc.WithDefaultCallOptions(grpc.WaitForReady(*o.waitForReady)))
+	}
+	if interceptors := o.unaryInterceptors(); len(interceptors) > 0 {
+		dialOptions = append(dialOptions, grpc.WithChainUnaryInterceptor(interceptors...))
+	}
+	return append(dialOptions, o.extraDialOptions...)
+}
+
+// unaryInterceptors 返回兩個連接共用的 gRPC 攔截器，按順序執行
+func (o *options) unaryInterceptors() []grpc.UnaryClientInterceptor {
+	interceptors := []grpc.UnaryClientInterceptor{waitForReadyInterceptor}
+	if o.metrics != nil {
+		interceptors = append(interceptors, o.metrics.unaryInterceptor)
+	}
+	if o.faults != nil {
+		interceptors = append(interceptors, o.faults.unaryInterceptor)
+	}
+	if o.recorder != nil {
+		interceptors = append(interceptors, o.recorder.unaryInterceptor(o.logger))
+	}
+	if o.replayer != nil {
+		interceptors = append(interceptors, o.replayer.unaryInterceptor)
+	}
+	return interceptors
+}
+
+// WithIDValidator 設置寫入和查詢前使用的 ID 驗證規則
+// 默認使用 IDValidator 的零值
+func WithIDValidator(v IDValidator) Option {
+	return func(o *options) {
+		o.ids = v
+	}
+}
+
+// WithDialOptions 添加建立 gRPC 連接時使用的選項，例如 TLS 憑證或自定義撥號函數
+// 這些選項在默認選項之後套用，可以覆蓋默認設置
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(o *options) {
+		o.extraDialOptions = append(o.extraDialOptions, opts...)
+	}
+}
@@ -0,0 +1,125 @@
+package keto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"golang.org/x/time/rate"
+)
+
+// ErrRateLimited 請求超出客戶端的速率限制
+var ErrRateLimited = errors.New("keto: 超出請求速率限制")
+
+// RateLimit 令牌桶限流配置
+type RateLimit struct {
+	Rate     float64 // 每秒補充的令牌數，即允許的平均請求速率
+	Burst    int     // 令牌桶容量，即允許的突發請求數，0 表示與 Rate 相同 (至少為 1)
+	FailFast bool    // true 時超出限制立即返回 ErrRateLimited，否則等待令牌或直到 context 結束
+}
+
+// limitClass 限流的請求類別
+type limitClass int
+
+const (
+	limitRead  limitClass = iota // 查詢元組
+	limitCheck                   // 權限檢查
+	limitWrite                   // 寫入元組 (包括批量寫入)
+	limitClassCount
+)
+
+// String 返回限流類別的名稱
+func (c limitClass) String() string {
+	switch c {
+	case limitRead:
+		return "read"
+	case limitCheck:
+		return "check"
+	case limitWrite:
+		return "write"
+	}
+	return "unknown"
+}
+
+// WithReadRateLimit 限制查詢元組的請求速率
+func WithReadRateLimit(limit RateLimit) Option {
+	return func(o *options) {
+		o.rateLimits[limitRead] = &limit
+	}
+}
+
+// WithCheckRateLimit 限制權限檢查的請求速率
+func WithCheckRateLimit(limit RateLimit) Option {
+	return func(o *options) {
+		o.rateLimits[limitCheck] = &limit
+	}
+}
+
+// WithWriteRateLimit 限制寫入元組的請求速率
+// 批量寫入只發送一個請求，因此只消耗一個令牌
+func WithWriteRateLimit(limit RateLimit) Option {
+	return func(o *options) {
+		o.rateLimits[limitWrite] = &limit
+	}
+}
+
+// rateLimiter 按請求類別限流
+// nil 的 rateLimiter 和未配置的類別不限流
+type rateLimiter struct {
+	limiters [limitClassCount]*rate.Limiter
+	failFast [limitClassCount]bool
+}
+
+// newRateLimiter 按配置創建 rateLimiter，沒有任何配置時返回 nil
+func newRateLimiter(limits [limitClassCount]*RateLimit) *rateLimiter {
+	var l *rateLimiter
+	for class, limit := range limits {
+		if limit == nil || limit.Rate <= 0 {
+			continue
+		}
+		if l == nil {
+			l = &rateLimiter{}
+		}
+
+		burst := limit.Burst
+		if burst <= 0 {
+			burst = max(int(limit.Rate), 1)
+		}
+		l.limiters[class] = rate.NewLimiter(rate.Limit(limit.Rate), burst)
+		l.failFast[class] = limit.FailFast
+	}
+	return l
+}
+
+// allow 不等待地取得指定類別的令牌，沒有可用的令牌時返回 false
+func (l *rateLimiter) allow(class limitClass) bool {
+	if l == nil || l.limiters[class] == nil {
+		return true
+	}
+	return l.limiters[class].Allow()
+}
+
+// wait 為指定類別的請求取得令牌
+// 等待期間 context 結束時返回 context 的錯誤
+func (l *rateLimiter) wait(ctx context.Context, class limitClass) error {
+	if l == nil || l.limiters[class] == nil {
+		return nil
+	}
+
+	limiter := l.limiters[class]
+	if l.failFast[class] {
+		if !limiter.Allow() {
+			return fmt.Errorf("%w (%s)", ErrRateLimited, class)
+		}
+		return nil
+	}
+
+	if err := limiter.Wait(ctx); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		// 等待時間會超過 context 的截止時間
+		return fmt.Errorf("%w (%s): %v", ErrRateLimited, class, err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package keto
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewRateLimiter_Unconfigured(t *testing.T) {
+	var limits [limitClassCount]*RateLimit
+	assert.Nil(t, newRateLimiter(limits))
+
+	// nil 的 rateLimiter 不限流
+	var l *rateLimiter
+	assert.NoError(t, l.wait(context.Background(), limitWrite))
+}
+
+func TestRateLimiter_FailFast(t *testing.T) {
+	var limits [limitClassCount]*RateLimit
+	limits[limitWrite] = &RateLimit{Rate: 1, Burst: 2, FailFast: true}
+	l := newRateLimiter(limits)
+
+	ctx := context.Background()
+	assert.NoError(t, l.wait(ctx, limitWrite))
+	assert.NoError(t, l.wait(ctx, limitWrite))
+
+	err := l.wait(ctx, limitWrite)
+	assert.True(t, errors.Is(err, ErrRateLimited), "err = %v", err)
+	assert.Contains(t, err.Error(), "write")
+
+	// 其他類別不受影響
+	assert.NoError(t, l.wait(ctx, limitCheck))
+	assert.NoError(t, l.wait(ctx, limitRead))
+}
+
+func TestRateLimiter_Blocking(t *testing.T) {
+	var limits [limitClassCount]*RateLimit
+	limits[limitCheck] = &RateLimit{Rate: 50, Burst: 1}
+	l := newRateLimiter(limits)
+
+	ctx := context.Background()
+	assert.NoError(t, l.wait(ctx, limitCheck))
+
+	// 第二個請求需要等待約 20ms
+	start := time.Now()
+	assert.NoError(t, l.wait(ctx, limitCheck))
+	assert.GreaterOrEqual(t, time.Since(start), 10*time.Millisecond)
+}
+
+func TestRateLimiter_BlockingRespectsContext(t *testing.T) {
+	var limits [limitClassCount]*RateLimit
+	limits[limitRead] = &RateLimit{Rate: 0.1, Burst: 1}
+	l := newRateLimiter(limits)
+
+	assert.NoError(t, l.wait(context.Background(), limitRead))
+
+	// 已取消的 context 返回 context 的錯誤
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, l.wait(ctx, limitRead))
+
+	// 截止時間早於下一個令牌時立即返回，不會一直等待
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := l.wait(ctx, limitRead)
+	assert.True(t, errors.Is(err, ErrRateLimited), "err = %v", err)
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestClient_RateLimited(t *testing.T) {
+	mockWriteClient := new(MockWriteServiceClient)
+	mockCheckClient := new(MockCheckServiceClient)
+
+	var limits [limitClassCount]*RateLimit
+	limits[limitWrite] = &RateLimit{Rate: 1, Burst: 1, FailFast: true}
+	client := &Client{
+		writeClient: mockWriteClient,
+		checkClient: mockCheckClient,
+		limiter:     newRateLimiter(limits),
+	}
+
+	mockWriteClient.On("TransactRelationTuples", mock.Anything, mock.Anything).Return(&rts.TransactRelationTuplesResponse{}, nil).Once()
+	mockCheckClient.On("Check", mock.Anything, mock.Anything).Return(&rts.CheckResponse{Allowed: true}, nil)
+
+	assert.NoError(t, client.CreatePhotoEventReference(context.Background(), "photo1", "event1"))
+
+	err := client.BatchCreatePhotoEventReferences(context.Background(), []PhotoEventRelation{{PhotoID: "photo2", EventID: "event1"}})
+	assert.True(t, errors.Is(err, ErrRateLimited))
+
+	// 權限檢查沒有限流
+	allowed, err := client.CheckPermission(context.Background(), "Photo", "photo1", "reference", "event1")
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+
+	mockWriteClient.AssertExpectations(t)
+}
+
+func TestRateLimitOptions(t *testing.T) {
+	var o options
+	for _, opt := range []Option{
+		WithReadRateLimit(RateLimit{Rate: 100}),
+		WithCheckRateLimit(RateLimit{Rate: 200, Burst: 10}),
+		WithWriteRateLimit(RateLimit{Rate: 5, FailFast: true}),
+	} {
+		opt(&o)
+	}
+
+	l := newRateLimiter(o.rateLimits)
+	assert.Equal(t, 100, l.limiters[limitRead].Burst())
+	assert.Equal(t, 10, l.limiters[limitCheck].Burst())
+	assert.Equal(t, 5, l.limiters[limitWrite].Burst())
+	assert.True(t, l.failFast[limitWrite])
+	assert.False(t, l.failFast[limitCheck])
+}
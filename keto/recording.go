@@ -0,0 +1,216 @@
+package keto
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrNoRecording 回放時找不到與請求匹配的記錄
+var ErrNoRecording = errors.New("keto: 沒有匹配的記錄")
+
+// Interaction 一次記錄下來的 gRPC 調用
+type Interaction struct {
+	Method   string          `json:"method"`
+	Request  json.RawMessage `json:"request"`
+	Response json.RawMessage `json:"response,omitempty"`
+	Code     codes.Code      `json:"code,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// WithRecorder 將客戶端發出的每個 gRPC 請求和響應記錄到 r
+func WithRecorder(r *Recorder) Option {
+	return func(o *options) {
+		o.recorder = r
+	}
+}
+
+// WithReplay 使用 r 中的記錄回應請求，不會連接 Keto
+// 回放時不能使用 WithEagerConnect；使用 WithCheckHedging 時對沖請求也需要有對應的記錄
+func WithReplay(r *Replayer) Option {
+	return func(o *options) {
+		o.replayer = r
+	}
+}
+
+// Recorder 以 JSON Lines 格式將 gRPC 調用寫入文件
+type Recorder struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+// NewRecorder 創建 (或覆蓋) path 並返回 Recorder，使用完畢後需調用 Close
+func NewRecorder(path string) (*Recorder, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("keto: 無法創建記錄文件: %w", err)
+	}
+	return &Recorder{file: f, enc: json.NewEncoder(f)}, nil
+}
+
+// Close 關閉記錄文件
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+// record 寫入一次調用
+func (r *Recorder) record(method string, req, reply any, callErr error) error {
+	interaction := Interaction{Method: method}
+
+	var err error
+	if interaction.Request, err = marshalMessage(req); err != nil {
+		return err
+	}
+	if callErr != nil {
+		st := status.Convert(callErr)
+		interaction.Code = st.Code()
+		interaction.Error = st.Message()
+	} else if interaction.Response, err = marshalMessage(reply); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.enc.Encode(interaction)
+}
+
+// unaryInterceptor 返回調用 Keto 並記錄結果的攔截器，記錄失敗只寫入日誌，不影響調用
+func (r *Recorder) unaryInterceptor(logger *slog.Logger) grpc.UnaryClientInterceptor {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if recErr := r.record(method, req, reply, err); recErr != nil {
+			logger.WarnContext(ctx, "gRPC 調用記錄失敗",
+				slog.String("method", method),
+				slog.String("error", recErr.Error()),
+			)
+		}
+		return err
+	}
+}
+
+// Replayer 按記錄回應 gRPC 請求
+//
+// 請求按方法和內容匹配記錄；相同的請求按記錄的順序依次返回，
+// 每條記錄只使用一次，因此回放的結果是確定的。
+type Replayer struct {
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewReplayer 讀取 Recorder 寫入的文件並返回 Replayer
+func NewReplayer(path string) (*Replayer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("keto: 無法打開記錄文件: %w", err)
+	}
+	defer f.Close()
+
+	var interactions []Interaction
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 16<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var interaction Interaction
+		if err := json.Unmarshal(scanner.Bytes(), &interaction); err != nil {
+			return nil, fmt.Errorf("keto: 記錄文件第 %d 行無效: %w", line, err)
+		}
+		interactions = append(interactions, interaction)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("keto: 無法讀取記錄文件: %w", err)
+	}
+	return NewReplayerFromInteractions(interactions), nil
+}
+
+// NewReplayerFromInteractions 使用內存中的記錄創建 Replayer
+func NewReplayerFromInteractions(interactions []Interaction) *Replayer {
+	return &Replayer{
+		interactions: interactions,
+		used:         make([]bool, len(interactions)),
+	}
+}
+
+// Remaining 返回尚未使用的記錄，可用於檢查回放是否完整
+func (r *Replayer) Remaining() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var remaining []Interaction
+	for i, interaction := range r.interactions {
+		if !r.used[i] {
+			remaining = append(remaining, interaction)
+		}
+	}
+	return remaining
+}
+
+// unaryInterceptor 返回第一條匹配且未使用的記錄，不調用 Keto
+func (r *Replayer) unaryInterceptor(ctx context.Context, method string, req, reply any, _ *grpc.ClientConn, _ grpc.UnaryInvoker, _ ...grpc.CallOption) error {
+	if err := ctx.Err(); err != nil {
+		return status.FromContextError(err).Err()
+	}
+
+	reqMsg, ok := req.(proto.Message)
+	if !ok {
+		return fmt.Errorf("keto: 無法回放 %s: 請求不是 protobuf 消息", method)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.interactions {
+		if r.used[i] || interaction.Method != method {
+			continue
+		}
+		recorded := reqMsg.ProtoReflect().New().Interface()
+		if err := protojson.Unmarshal(interaction.Request, recorded); err != nil {
+			return fmt.Errorf("keto: 無法解析 %s 的記錄: %w", method, err)
+		}
+		if !proto.Equal(recorded, reqMsg) {
+			continue
+		}
+
+		r.used[i] = true
+		if interaction.Code != codes.OK {
+			return status.Error(interaction.Code, interaction.Error)
+		}
+		replyMsg, ok := reply.(proto.Message)
+		if !ok {
+			return fmt.Errorf("keto: 無法回放 %s: 響應不是 protobuf 消息", method)
+		}
+		if err := protojson.Unmarshal(interaction.Response, replyMsg); err != nil {
+			return fmt.Errorf("keto: 無法解析 %s 的記錄: %w", method, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrNoRecording, method)
+}
+
+// marshalMessage 將 protobuf 消息轉換為 JSON
+func marshalMessage(m any) (json.RawMessage, error) {
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("keto: %T 不是 protobuf 消息", m)
+	}
+	return protojson.Marshal(msg)
+}
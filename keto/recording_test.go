@@ -0,0 +1,125 @@
+package keto
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AidChen0509/oosa_ketosdk/ketotest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// session 記錄和回放時執行的同一組操作
+func session(ctx context.Context, client *Client) (before, after []string, allowed bool, checkErr error, err error) {
+	if before, err = client.GetEventReferencePhotos(ctx, "event1"); err != nil {
+		return
+	}
+	if err = client.CreatePhotoEventReference(ctx, "photo1", "event1"); err != nil {
+		return
+	}
+	if after, err = client.GetEventReferencePhotos(ctx, "event1"); err != nil {
+		return
+	}
+	if allowed, err = client.CheckPermission(ctx, NamespacePhoto, "photo1", RelationReference, "event1"); err != nil {
+		return
+	}
+	_, checkErr = client.CheckPermission(ctx, "Unknown", "photo1", RelationReference, "event1")
+	return
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "keto.jsonl")
+
+	// 記錄
+	srv := ketotest.NewServer(ketotest.WithNamespaces(NamespacePhoto, NamespaceEvent))
+	defer srv.Close()
+
+	recorder, err := NewRecorder(path)
+	require.NoError(t, err)
+	client, err := NewClient(srv.Addr(), srv.Addr(), WithDialOptions(srv.DialOptions()...), WithRecorder(recorder))
+	require.NoError(t, err)
+
+	before, after, allowed, checkErr, err := session(ctx, client)
+	require.NoError(t, err)
+	assert.Empty(t, before)
+	assert.Equal(t, []string{"photo1"}, after)
+	assert.True(t, allowed)
+	assert.Equal(t, codes.NotFound, status.Code(checkErr))
+
+	require.NoError(t, client.Close(ctx))
+	require.NoError(t, recorder.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 5, countLines(data))
+
+	// 回放時 Keto 不存在
+	replayer, err := NewReplayer(path)
+	require.NoError(t, err)
+	addr := unusedAddress(t)
+	replay, err := NewClient(addr, addr, WithReplay(replayer))
+	require.NoError(t, err)
+	defer replay.Close(ctx)
+
+	replayBefore, replayAfter, replayAllowed, replayCheckErr, err := session(ctx, replay)
+	require.NoError(t, err)
+	assert.Equal(t, before, replayBefore)
+	assert.Equal(t, after, replayAfter)
+	assert.Equal(t, allowed, replayAllowed)
+	assert.Equal(t, status.Convert(checkErr).Proto(), status.Convert(replayCheckErr).Proto())
+	assert.Empty(t, replayer.Remaining())
+
+	// 記錄用完後返回 ErrNoRecording
+	_, err = replay.GetEventReferencePhotos(ctx, "event1")
+	assert.ErrorIs(t, err, ErrNoRecording)
+}
+
+func TestReplay_MatchesRequestContent(t *testing.T) {
+	ctx := context.Background()
+
+	srv := ketotest.NewServer()
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "keto.jsonl")
+	recorder, err := NewRecorder(path)
+	require.NoError(t, err)
+	client, err := NewClient(srv.Addr(), srv.Addr(), WithDialOptions(srv.DialOptions()...), WithRecorder(recorder))
+	require.NoError(t, err)
+
+	require.NoError(t, client.CreatePhotoEventReference(ctx, "photo1", "event1"))
+	require.NoError(t, client.CreatePhotoEventReference(ctx, "photo2", "event2"))
+	require.NoError(t, client.Close(ctx))
+	require.NoError(t, recorder.Close())
+
+	replayer, err := NewReplayer(path)
+	require.NoError(t, err)
+	addr := unusedAddress(t)
+	replay, err := NewClient(addr, addr, WithReplay(replayer))
+	require.NoError(t, err)
+	defer replay.Close(ctx)
+
+	// 不同順序的請求也能匹配
+	require.NoError(t, replay.CreatePhotoEventReference(ctx, "photo2", "event2"))
+	assert.Len(t, replayer.Remaining(), 1)
+
+	// 沒有記錄過的請求
+	assert.ErrorIs(t, replay.CreatePhotoEventReference(ctx, "photo3", "event3"), ErrNoRecording)
+
+	require.NoError(t, replay.CreatePhotoEventReference(ctx, "photo1", "event1"))
+	assert.Empty(t, replayer.Remaining())
+}
+
+func TestNewReplayer_InvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keto.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte("{\"method\":\"a\",\"request\":{}}\nnot json\n"), 0o600))
+
+	_, err := NewReplayer(path)
+	assert.ErrorContains(t, err, "第 2 行")
+
+	_, err = NewReplayer(filepath.Join(t.TempDir(), "missing.jsonl"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,226 @@
+package keto
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/balancer/leastrequest"
+	"google.golang.org/grpc/balancer/roundrobin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// BalancePolicy 讀取副本之間的負載均衡策略
+type BalancePolicy int
+
+const (
+	// BalanceRoundRobin 依次使用每個副本
+	BalanceRoundRobin BalancePolicy = iota
+	// BalanceLeastLoaded 使用進行中請求最少的副本
+	BalanceLeastLoaded
+)
+
+// 副本剔除的默認設置
+const (
+	DefaultEjectionFailures = 3
+	DefaultEjectionCooldown = 30 * time.Second
+)
+
+// grpcPolicy 返回對應的 gRPC 負載均衡策略名稱
+func (p BalancePolicy) grpcPolicy() string {
+	if p == BalanceLeastLoaded {
+		return leastrequest.Name
+	}
+	return roundrobin.Name
+}
+
+// WithReadReplicas 添加額外的 Keto 讀取地址
+// 讀取、檢查等請求會在 NewClient 的 readAddress 和這些地址之間負載均衡
+func WithReadReplicas(addresses ...string) Option {
+	return func(o *options) {
+		o.readReplicas = append(o.readReplicas, addresses...)
+	}
+}
+
+// WithBalancePolicy 設置讀取副本之間的負載均衡策略，默認為 BalanceRoundRobin
+// 讀取地址為解析到多個 IP 的 DNS 名稱時，同一策略也用於這些 IP 之間
+func WithBalancePolicy(policy BalancePolicy) Option {
+	return func(o *options) {
+		o.balancePolicy = policy
+	}
+}
+
+// WithReplicaEjection 設置副本的剔除規則
+// 副本連續 failures 次返回 Unavailable 或 DeadlineExceeded 後，在 cooldown 內不再使用
+// 默認為 DefaultEjectionFailures 和 DefaultEjectionCooldown
+func WithReplicaEjection(failures int, cooldown time.Duration) Option {
+	return func(o *options) {
+		o.ejectionFailures = failures
+		o.ejectionCooldown = cooldown
+	}
+}
+
+// readDialOptions 返回讀取連接額外使用的選項
+// 讀取地址解析到多個 IP 時，gRPC 在這些 IP 之間負載均衡並跳過不可用的連接
+func (o *options) readDialOptions() []grpc.DialOption {
+	config := fmt.Sprintf(`{"loadBalancingConfig": [{%q: {}}]}`, o.balancePolicy.grpcPolicy())
+	return append(o.dialOptions(), grpc.WithDefaultServiceConfig(config))
+}
+
+// ReplicaStatus 讀取副本的狀態
+type ReplicaStatus struct {
+	Address  string // 副本地址
+	InFlight int64  // 進行中的請求數
+	Failures int    // 連續失敗次數
+	Ejected  bool   // 是否已被剔除
+}
+
+// replica 一個讀取副本
+type replica struct {
+	address  string
+	conn     grpc.ClientConnInterface
+	inFlight atomic.Int64
+
+	// 以下字段由 replicaPool.mu 保護
+	failures     int
+	ejectedUntil time.Time
+}
+
+// replicaPool 在多個讀取副本之間分配請求
+// 實現 grpc.ClientConnInterface，可直接用於創建各個讀取服務的客戶端
+type replicaPool struct {
+	policy   BalancePolicy
+	failures int
+	cooldown time.Duration
+	now      func() time.Time
+
+	mu       sync.Mutex
+	replicas []*replica
+	next     int
+}
+
+// newReplicaPool 創建副本池
+func newReplicaPool(replicas []*replica, o *options) *replicaPool {
+	p := &replicaPool{
+		policy:   o.balancePolicy,
+		failures: o.ejectionFailures,
+		cooldown: o.ejectionCooldown,
+		now:      time.Now,
+		replicas: replicas,
+	}
+	if p.failures <= 0 {
+		p.failures = DefaultEjectionFailures
+	}
+	if p.cooldown <= 0 {
+		p.cooldown = DefaultEjectionCooldown
+	}
+	return p
+}
+
+// pick 選擇一個副本
+// 所有副本都被剔除時，仍然從全部副本中選擇，避免完全不可用
+func (p *replicaPool) pick() *replica {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	candidates := make([]*replica, 0, len(p.replicas))
+	for _, r := range p.replicas {
+		if !now.Before(r.ejectedUntil) {
+			candidates = append(candidates, r)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = p.replicas
+	}
+
+	start := p.next % len(candidates)
+	p.next++
+	picked := candidates[start]
+	if p.policy == BalanceLeastLoaded {
+		// 從輪詢位置開始比較，負載相同時仍然輪流使用
+		for i := 1; i < len(candidates); i++ {
+			r := candidates[(start+i)%len(candidates)]
+			if r.inFlight.Load() < picked.inFlight.Load() {
+				picked = r
+			}
+		}
+	}
+	return picked
+}
+
+// report 記錄副本的請求結果，連續失敗達到上限時剔除副本
+func (p *replicaPool) report(r *replica, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !isReplicaFailure(err) {
+		r.failures = 0
+		return
+	}
+	r.failures++
+	if r.failures >= p.failures {
+		r.failures = 0
+		r.ejectedUntil = p.now().Add(p.cooldown)
+	}
+}
+
+// isReplicaFailure 判斷錯誤是否表示副本本身不健康
+func isReplicaFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+// Invoke 在選中的副本上執行一元調用
+func (p *replicaPool) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	r := p.pick()
+	r.inFlight.Add(1)
+	err := r.conn.Invoke(ctx, method, args, reply, opts...)
+	r.inFlight.Add(-1)
+
+	// 調用方取消或超時不代表副本不健康
+	if ctx.Err() == nil {
+		p.report(r, err)
+	}
+	return err
+}
+
+// NewStream 在選中的副本上創建流
+func (p *replicaPool) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return p.pick().conn.NewStream(ctx, desc, method, opts...)
+}
+
+// status 返回所有副本的狀態
+func (p *replicaPool) status() []ReplicaStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	statuses := make([]ReplicaStatus, len(p.replicas))
+	for i, r := range p.replicas {
+		statuses[i] = ReplicaStatus{
+			Address:  r.address,
+			InFlight: r.inFlight.Load(),
+			Failures: r.failures,
+			Ejected:  now.Before(r.ejectedUntil),
+		}
+	}
+	return statuses
+}
+
+// ReadReplicas 返回讀取副本的狀態
+// 未使用 WithReadReplicas 時返回 nil
+func (k *Client) ReadReplicas() []ReplicaStatus {
+	if k.replicas == nil {
+		return nil
+	}
+	return k.replicas.status()
+}
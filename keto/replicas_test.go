@@ -0,0 +1,191 @@
+package keto
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeConn 記錄調用次數並返回指定錯誤的連接
+type fakeConn struct {
+	grpc.ClientConnInterface
+	calls atomic.Int64
+	err   error
+	block chan struct{}
+}
+
+func (c *fakeConn) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	c.calls.Add(1)
+	if c.block != nil {
+		<-c.block
+	}
+	return c.err
+}
+
+// newTestPool 創建使用 fakeConn 的副本池
+func newTestPool(o *options, conns ...*fakeConn) *replicaPool {
+	replicas := make([]*replica, len(conns))
+	for i, conn := range conns {
+		replicas[i] = &replica{address: string(rune('a' + i)), conn: conn}
+	}
+	return newReplicaPool(replicas, o)
+}
+
+func TestReplicaPool_RoundRobin(t *testing.T) {
+	a, b, c := &fakeConn{}, &fakeConn{}, &fakeConn{}
+	pool := newTestPool(&options{}, a, b, c)
+
+	for i := 0; i < 9; i++ {
+		require.NoError(t, pool.Invoke(context.Background(), "/m", nil, nil))
+	}
+	assert.Equal(t, int64(3), a.calls.Load())
+	assert.Equal(t, int64(3), b.calls.Load())
+	assert.Equal(t, int64(3), c.calls.Load())
+}
+
+func TestReplicaPool_LeastLoaded(t *testing.T) {
+	slow := &fakeConn{block: make(chan struct{})}
+	fast := &fakeConn{}
+	pool := newTestPool(&options{balancePolicy: BalanceLeastLoaded}, slow, fast)
+
+	// 第一個請求卡在 slow 上
+	done := make(chan struct{})
+	go func() {
+		pool.Invoke(context.Background(), "/m", nil, nil)
+		close(done)
+	}()
+	require.Eventually(t, func() bool { return slow.calls.Load() == 1 }, time.Second, time.Millisecond)
+
+	// 之後的請求都使用進行中請求更少的 fast
+	for i := 0; i < 4; i++ {
+		require.NoError(t, pool.Invoke(context.Background(), "/m", nil, nil))
+	}
+	assert.Equal(t, int64(4), fast.calls.Load())
+	assert.Equal(t, int64(1), pool.status()[0].InFlight)
+
+	close(slow.block)
+	<-done
+}
+
+func TestReplicaPool_Ejection(t *testing.T) {
+	bad := &fakeConn{err: status.Error(codes.Unavailable, "down")}
+	good := &fakeConn{}
+	pool := newTestPool(&options{ejectionFailures: 2, ejectionCooldown: time.Minute}, bad, good)
+
+	now := time.Now()
+	pool.now = func() time.Time { return now }
+
+	// bad 連續失敗兩次後被剔除
+	for i := 0; i < 4; i++ {
+		pool.Invoke(context.Background(), "/m", nil, nil)
+	}
+	assert.Equal(t, int64(2), bad.calls.Load())
+	assert.True(t, pool.status()[0].Ejected)
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, pool.Invoke(context.Background(), "/m", nil, nil))
+	}
+	assert.Equal(t, int64(2), bad.calls.Load())
+
+	// 冷卻期過後重新使用
+	now = now.Add(time.Minute)
+	assert.False(t, pool.status()[0].Ejected)
+	for i := 0; i < 2; i++ {
+		pool.Invoke(context.Background(), "/m", nil, nil)
+	}
+	assert.Equal(t, int64(3), bad.calls.Load())
+}
+
+func TestReplicaPool_IgnoresCallerErrors(t *testing.T) {
+	notFound := &fakeConn{err: status.Error(codes.NotFound, "missing")}
+	pool := newTestPool(&options{ejectionFailures: 1}, notFound)
+
+	pool.Invoke(context.Background(), "/m", nil, nil)
+	assert.False(t, pool.status()[0].Ejected)
+
+	// 調用方取消的請求不計入失敗
+	unavailable := &fakeConn{err: status.Error(codes.Unavailable, "down")}
+	pool = newTestPool(&options{ejectionFailures: 1}, unavailable)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	pool.Invoke(ctx, "/m", nil, nil)
+	assert.False(t, pool.status()[0].Ejected)
+}
+
+func TestReplicaPool_AllEjected(t *testing.T) {
+	a := &fakeConn{err: status.Error(codes.Unavailable, "down")}
+	b := &fakeConn{err: status.Error(codes.Unavailable, "down")}
+	pool := newTestPool(&options{ejectionFailures: 1}, a, b)
+
+	pool.Invoke(context.Background(), "/m", nil, nil)
+	pool.Invoke(context.Background(), "/m", nil, nil)
+	assert.True(t, pool.status()[0].Ejected)
+	assert.True(t, pool.status()[1].Ejected)
+
+	// 所有副本都被剔除時仍然發送請求
+	pool.Invoke(context.Background(), "/m", nil, nil)
+	assert.Equal(t, int64(3), a.calls.Load()+b.calls.Load())
+}
+
+// countingCheckServer 記錄收到的請求數的 CheckService
+type countingCheckServer struct {
+	rts.UnimplementedCheckServiceServer
+	calls atomic.Int64
+}
+
+func (s *countingCheckServer) Check(context.Context, *rts.CheckRequest) (*rts.CheckResponse, error) {
+	s.calls.Add(1)
+	return &rts.CheckResponse{Allowed: true}, nil
+}
+
+// startCheckServer 在本地端口上啟動 CheckService
+func startCheckServer(t *testing.T) (*countingCheckServer, string) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := &countingCheckServer{}
+	grpcServer := grpc.NewServer()
+	rts.RegisterCheckServiceServer(grpcServer, server)
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+	return server, lis.Addr().String()
+}
+
+func TestClient_ReadReplicas(t *testing.T) {
+	primary, primaryAddr := startCheckServer(t)
+	replica, replicaAddr := startCheckServer(t)
+
+	client, err := NewClient(primaryAddr, primaryAddr, WithReadReplicas(replicaAddr))
+	require.NoError(t, err)
+	defer client.Close(context.Background())
+
+	for i := 0; i < 4; i++ {
+		allowed, err := client.CheckPermission(context.Background(), "Photo", "photo1", "reference", "event1")
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+	assert.Equal(t, int64(2), primary.calls.Load())
+	assert.Equal(t, int64(2), replica.calls.Load())
+
+	statuses := client.ReadReplicas()
+	require.Len(t, statuses, 2)
+	assert.Equal(t, primaryAddr, statuses[0].Address)
+	assert.Equal(t, replicaAddr, statuses[1].Address)
+}
+
+func TestClient_SingleReadAddress(t *testing.T) {
+	client, err := NewClient("127.0.0.1:4467", "127.0.0.1:4466")
+	require.NoError(t, err)
+	defer client.Close(context.Background())
+
+	assert.Nil(t, client.ReadReplicas())
+}
@@ -0,0 +1,461 @@
+package keto
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+	"go.opentelemetry.io/otel"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Keto REST API 的路徑
+const (
+	restPathTuples      = "/relation-tuples"
+	restPathAdminTuples = "/admin/relation-tuples"
+	restPathCheck       = "/relation-tuples/check"
+	restPathExpand      = "/relation-tuples/expand"
+	restPathNamespaces  = "/namespaces"
+	restPathVersion     = "/version"
+	restPathReady       = "/health/ready"
+)
+
+// tracerName REST 傳輸使用的 tracer 名稱
+const tracerName = "github.com/AidChen0509/oosa_ketosdk/keto"
+
+// restConn 通過 Keto 的 REST API 實現 gRPC 調用
+// 請求按 gRPC 方法轉換為對應的 HTTP 請求，HTTP 錯誤轉換為 gRPC 狀態，
+// 因此客戶端的其他部分不需要區分傳輸方式
+type restConn struct {
+	base         *url.URL
+	http         *http.Client
+	interceptors []grpc.UnaryClientInterceptor
+	tracer       trace.Tracer
+	propagator   propagation.TextMapPropagator
+	closeIdle    func()
+}
+
+// newRESTConn 創建連接到 address 的 restConn
+func newRESTConn(address string, httpClient *http.Client, o *options) (*restConn, error) {
+	if !strings.Contains(address, "://") {
+		address = "http://" + address
+	}
+	base, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("keto: 無效的 REST 地址 %q: %w", address, err)
+	}
+	if base.Scheme != "http" && base.Scheme != "https" || base.Host == "" {
+		return nil, fmt.Errorf("keto: 無效的 REST 地址 %q", address)
+	}
+	base.Path = strings.TrimSuffix(base.Path, "/")
+
+	tp := o.tracerProvider
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	propagator := o.propagator
+	if propagator == nil {
+		propagator = propagation.TraceContext{}
+	}
+
+	return &restConn{
+		base:         base,
+		http:         httpClient,
+		interceptors: o.unaryInterceptors(),
+		tracer:       tp.Tracer(tracerName),
+		propagator:   propagator,
+	}, nil
+}
+
+// Invoke 依次執行攔截器，然後發送 HTTP 請求
+// 攔截器收到的 *grpc.ClientConn 為 nil
+func (c *restConn) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	return c.invoke(0, ctx, method, args, reply, opts)
+}
+
+// invoke 執行第 i 個攔截器
+func (c *restConn) invoke(i int, ctx context.Context, method string, args, reply any, opts []grpc.CallOption) error {
+	if i == len(c.interceptors) {
+		return c.do(ctx, method, args, reply)
+	}
+	next := func(ctx context.Context, method string, args, reply any, _ *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return c.invoke(i+1, ctx, method, args, reply, opts)
+	}
+	return c.interceptors[i](ctx, method, args, reply, nil, next, opts...)
+}
+
+// NewStream Keto 的 API 沒有流式調用
+func (c *restConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, status.Errorf(codes.Unimplemented, "keto: REST 傳輸不支持流式調用 %s", method)
+}
+
+// close 關閉空閒的 HTTP 連接
+func (c *restConn) close() {
+	if c.closeIdle != nil {
+		c.closeIdle()
+	}
+}
+
+// ready 檢查 Keto 是否就緒
+func (c *restConn) ready(ctx context.Context) error {
+	return c.send(ctx, http.MethodGet, restPathReady, nil, nil, nil)
+}
+
+// do 將 gRPC 調用轉換為 REST 請求
+func (c *restConn) do(ctx context.Context, method string, args, reply any) (err error) {
+	ctx, span := c.tracer.Start(ctx, strings.TrimPrefix(method, "/"), trace.WithSpanKind(trace.SpanKindClient))
+	defer func() {
+		if err != nil {
+			span.SetStatus(otelcodes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	switch method {
+	case rts.WriteService_TransactRelationTuples_FullMethodName:
+		req := args.(*rts.TransactRelationTuplesRequest)
+		deltas := make([]restDelta, 0, len(req.RelationTupleDeltas))
+		for _, d := range req.RelationTupleDeltas {
+			deltas = append(deltas, restDelta{Action: restAction(d.Action), RelationTuple: restTupleFromProto(d.RelationTuple)})
+		}
+		return c.send(ctx, http.MethodPatch, restPathAdminTuples, nil, deltas, nil)
+
+	case rts.WriteService_DeleteRelationTuples_FullMethodName:
+		req := args.(*rts.DeleteRelationTuplesRequest)
+		return c.send(ctx, http.MethodDelete, restPathAdminTuples, queryValues(req.RelationQuery), nil, nil)
+
+	case rts.ReadService_ListRelationTuples_FullMethodName:
+		req := args.(*rts.ListRelationTuplesRequest)
+		params := queryValues(req.RelationQuery)
+		if req.PageToken != "" {
+			params.Set("page_token", req.PageToken)
+		}
+		if req.PageSize > 0 {
+			params.Set("page_size", strconv.Itoa(int(req.PageSize)))
+		}
+		var resp restListResponse
+		if err := c.send(ctx, http.MethodGet, restPathTuples, params, nil, &resp); err != nil {
+			return err
+		}
+		out := reply.(*rts.ListRelationTuplesResponse)
+		out.NextPageToken = resp.NextPageToken
+		for _, t := range resp.RelationTuples {
+			out.RelationTuples = append(out.RelationTuples, t.toProto())
+		}
+		return nil
+
+	case rts.CheckService_Check_FullMethodName:
+		req := args.(*rts.CheckRequest)
+		tuple := req.Tuple
+		if tuple == nil {
+			tuple = &rts.RelationTuple{Namespace: req.Namespace, Object: req.Object, Relation: req.Relation, Subject: req.Subject}
+		}
+		params := url.Values{}
+		if req.MaxDepth > 0 {
+			params.Set("max-depth", strconv.Itoa(int(req.MaxDepth)))
+		}
+		var resp restCheckResponse
+		if err := c.send(ctx, http.MethodPost, restPathCheck, params, restTupleFromProto(tuple), &resp); err != nil {
+			return err
+		}
+		reply.(*rts.CheckResponse).Allowed = resp.Allowed
+		return nil
+
+	case rts.ExpandService_Expand_FullMethodName:
+		req := args.(*rts.ExpandRequest)
+		set := req.GetSubject().GetSet()
+		if set == nil {
+			return status.Error(codes.InvalidArgument, "keto: REST 傳輸只能展開主體集合")
+		}
+		params := url.Values{}
+		params.Set("namespace", set.Namespace)
+		params.Set("object", set.Object)
+		params.Set("relation", set.Relation)
+		if req.MaxDepth > 0 {
+			params.Set("max-depth", strconv.Itoa(int(req.MaxDepth)))
+		}
+		var resp restTree
+		if err := c.send(ctx, http.MethodGet, restPathExpand, params, nil, &resp); err != nil {
+			return err
+		}
+		reply.(*rts.ExpandResponse).Tree = resp.toProto()
+		return nil
+
+	case rts.NamespacesService_ListNamespaces_FullMethodName:
+		var resp restNamespacesResponse
+		if err := c.send(ctx, http.MethodGet, restPathNamespaces, nil, nil, &resp); err != nil {
+			return err
+		}
+		out := reply.(*rts.ListNamespacesResponse)
+		for _, ns := range resp.Namespaces {
+			out.Namespaces = append(out.Namespaces, &rts.Namespace{Name: ns.Name})
+		}
+		return nil
+
+	case rts.VersionService_GetVersion_FullMethodName:
+		var resp restVersionResponse
+		if err := c.send(ctx, http.MethodGet, restPathVersion, nil, nil, &resp); err != nil {
+			return err
+		}
+		reply.(*rts.GetVersionResponse).Version = resp.Version
+		return nil
+
+	default:
+		return status.Errorf(codes.Unimplemented, "keto: REST 傳輸不支持 %s", method)
+	}
+}
+
+// send 發送 HTTP 請求並解析 JSON 響應，錯誤轉換為 gRPC 狀態
+// Keto 的 check 端點在沒有權限時返回 403 和正常的響應，此時同樣解析響應
+func (c *restConn) send(ctx context.Context, method, path string, params url.Values, body, out any) error {
+	u := *c.base
+	u.Path += path
+	u.RawQuery = params.Encode()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return status.Errorf(codes.Internal, "keto: 無法編碼請求: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reader)
+	if err != nil {
+		return status.Errorf(codes.Internal, "keto: 無法創建請求: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	c.propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return status.FromContextError(ctxErr).Err()
+		}
+		return status.Errorf(codes.Unavailable, "keto: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return status.Errorf(codes.Unavailable, "keto: 無法讀取響應: %v", err)
+	}
+
+	denied := resp.StatusCode == http.StatusForbidden && path == restPathCheck
+	if resp.StatusCode >= 300 && !denied {
+		return restError(resp.StatusCode, data)
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return status.Errorf(codes.Internal, "keto: 無法解析響應: %v", err)
+	}
+	return nil
+}
+
+// restErrorResponse Keto 的錯誤響應
+type restErrorResponse struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Reason  string `json:"reason"`
+	} `json:"error"`
+}
+
+// restError 將 HTTP 錯誤響應轉換為 gRPC 狀態
+func restError(statusCode int, body []byte) error {
+	message := strings.TrimSpace(string(body))
+	var resp restErrorResponse
+	if json.Unmarshal(body, &resp) == nil && resp.Error.Message != "" {
+		message = resp.Error.Message
+		if resp.Error.Reason != "" {
+			message += ": " + resp.Error.Reason
+		}
+	}
+	if message == "" {
+		message = http.StatusText(statusCode)
+	}
+	return status.Error(httpStatusCode(statusCode), message)
+}
+
+// httpStatusCode 將 HTTP 狀態碼轉換為 gRPC 狀態碼
+func httpStatusCode(statusCode int) codes.Code {
+	switch statusCode {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	case http.StatusInternalServerError:
+		return codes.Internal
+	default:
+		return codes.Unknown
+	}
+}
+
+// restSubjectSet REST API 中的主體集合
+type restSubjectSet struct {
+	Namespace string `json:"namespace"`
+	Object    string `json:"object"`
+	Relation  string `json:"relation"`
+}
+
+// restTuple REST API 中的元組
+type restTuple struct {
+	Namespace  string          `json:"namespace"`
+	Object     string          `json:"object"`
+	Relation   string          `json:"relation"`
+	SubjectID  *string         `json:"subject_id,omitempty"`
+	SubjectSet *restSubjectSet `json:"subject_set,omitempty"`
+}
+
+// restDelta PATCH /admin/relation-tuples 的一個變更
+type restDelta struct {
+	Action        string    `json:"action"`
+	RelationTuple restTuple `json:"relation_tuple"`
+}
+
+type restListResponse struct {
+	RelationTuples []restTuple `json:"relation_tuples"`
+	NextPageToken  string      `json:"next_page_token"`
+}
+
+type restCheckResponse struct {
+	Allowed bool `json:"allowed"`
+}
+
+type restNamespacesResponse struct {
+	Namespaces []struct {
+		Name string `json:"name"`
+	} `json:"namespaces"`
+}
+
+type restVersionResponse struct {
+	Version string `json:"version"`
+}
+
+// restTree GET /relation-tuples/expand 返回的樹
+type restTree struct {
+	Type     string     `json:"type"`
+	Tuple    *restTuple `json:"tuple,omitempty"`
+	Children []restTree `json:"children,omitempty"`
+}
+
+// restAction 將變更操作轉換為 REST API 的名稱
+func restAction(action rts.RelationTupleDelta_Action) string {
+	switch action {
+	case rts.RelationTupleDelta_ACTION_INSERT:
+		return "insert"
+	case rts.RelationTupleDelta_ACTION_DELETE:
+		return "delete"
+	default:
+		return action.String()
+	}
+}
+
+// restTupleFromProto 轉換 protobuf 元組
+func restTupleFromProto(t *rts.RelationTuple) restTuple {
+	tuple := restTuple{Namespace: t.GetNamespace(), Object: t.GetObject(), Relation: t.GetRelation()}
+	if set := t.GetSubject().GetSet(); set != nil {
+		tuple.SubjectSet = &restSubjectSet{Namespace: set.Namespace, Object: set.Object, Relation: set.Relation}
+	} else if t.GetSubject() != nil {
+		id := t.GetSubject().GetId()
+		tuple.SubjectID = &id
+	}
+	return tuple
+}
+
+// toProto 轉換為 protobuf 元組
+func (t restTuple) toProto() *rts.RelationTuple {
+	tuple := &rts.RelationTuple{Namespace: t.Namespace, Object: t.Object, Relation: t.Relation}
+	switch {
+	case t.SubjectSet != nil:
+		tuple.Subject = rts.NewSubjectSet(t.SubjectSet.Namespace, t.SubjectSet.Object, t.SubjectSet.Relation)
+	case t.SubjectID != nil:
+		tuple.Subject = rts.NewSubjectID(*t.SubjectID)
+	}
+	return tuple
+}
+
+// toProto 轉換為 protobuf 的樹
+func (t restTree) toProto() *rts.SubjectTree {
+	tree := &rts.SubjectTree{NodeType: restNodeType(t.Type)}
+	if t.Tuple != nil {
+		tree.Tuple = t.Tuple.toProto()
+	}
+	for _, child := range t.Children {
+		tree.Children = append(tree.Children, child.toProto())
+	}
+	return tree
+}
+
+// restNodeType 將 REST API 的節點類型轉換為 protobuf 枚舉
+func restNodeType(name string) rts.NodeType {
+	switch name {
+	case "union":
+		return rts.NodeType_NODE_TYPE_UNION
+	case "exclusion":
+		return rts.NodeType_NODE_TYPE_EXCLUSION
+	case "intersection":
+		return rts.NodeType_NODE_TYPE_INTERSECTION
+	case "leaf":
+		return rts.NodeType_NODE_TYPE_LEAF
+	default:
+		return rts.NodeType_NODE_TYPE_UNSPECIFIED
+	}
+}
+
+// queryValues 將查詢條件轉換為 URL 參數
+func queryValues(query *rts.RelationQuery) url.Values {
+	params := url.Values{}
+	if query == nil {
+		return params
+	}
+	if query.Namespace != nil {
+		params.Set("namespace", *query.Namespace)
+	}
+	if query.Object != nil {
+		params.Set("object", *query.Object)
+	}
+	if query.Relation != nil {
+		params.Set("relation", *query.Relation)
+	}
+	if set := query.GetSubject().GetSet(); set != nil {
+		params.Set("subject_set.namespace", set.Namespace)
+		params.Set("subject_set.object", set.Object)
+		params.Set("subject_set.relation", set.Relation)
+	} else if query.Subject != nil {
+		params.Set("subject_id", query.GetSubject().GetId())
+	}
+	return params
+}
@@ -0,0 +1,251 @@
+package keto
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// restRequest 測試服務器收到的請求
+type restRequest struct {
+	Method string
+	Path   string
+	Query  string
+	Body   string
+	Header http.Header
+}
+
+// newRESTClient 創建連接到 handler 的 REST 客戶端，並返回收到的請求
+func newRESTClient(t *testing.T, handler http.HandlerFunc, opts ...Option) (*Client, <-chan restRequest) {
+	requests := make(chan restRequest, 10)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- restRequest{Method: r.Method, Path: r.URL.Path, Query: r.URL.RawQuery, Body: string(body), Header: r.Header}
+		handler(w, r)
+	}))
+	t.Cleanup(ts.Close)
+
+	client, err := NewClient(ts.URL, ts.URL, append([]Option{WithTransport(TransportREST)}, opts...)...)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close(context.Background()) })
+	return client, requests
+}
+
+// respond 返回固定的狀態碼和內容
+func respond(code int, body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		io.WriteString(w, body)
+	}
+}
+
+func TestREST_Transact(t *testing.T) {
+	client, requests := newRESTClient(t, respond(http.StatusNoContent, ""))
+
+	require.NoError(t, client.CreatePhotoEventReference(context.Background(), "photo1", "event1"))
+
+	req := <-requests
+	assert.Equal(t, http.MethodPatch, req.Method)
+	assert.Equal(t, "/admin/relation-tuples", req.Path)
+	assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+	assert.JSONEq(t, `[{"action":"insert","relation_tuple":{"namespace":"Photo","object":"photo1","relation":"reference","subject_set":{"namespace":"Event","object":"event1","relation":""}}}]`, req.Body)
+}
+
+func TestREST_List(t *testing.T) {
+	client, requests := newRESTClient(t, respond(http.StatusOK,
+		`{"relation_tuples":[{"namespace":"Photo","object":"photo1","relation":"reference","subject_set":{"namespace":"Event","object":"event1","relation":""}}],"next_page_token":""}`))
+
+	photos, err := client.GetEventReferencePhotos(context.Background(), "event1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"photo1"}, photos)
+
+	req := <-requests
+	assert.Equal(t, http.MethodGet, req.Method)
+	assert.Equal(t, "/relation-tuples", req.Path)
+	assert.Equal(t, "namespace=Photo&relation=reference&subject_set.namespace=Event&subject_set.object=event1&subject_set.relation=", req.Query)
+}
+
+func TestREST_Check(t *testing.T) {
+	t.Run("Allowed", func(t *testing.T) {
+		client, requests := newRESTClient(t, respond(http.StatusOK, `{"allowed":true}`))
+
+		allowed, err := client.CheckPermission(context.Background(), "Photo", "photo1", "reference", "event1")
+		require.NoError(t, err)
+		assert.True(t, allowed)
+
+		req := <-requests
+		assert.Equal(t, http.MethodPost, req.Method)
+		assert.Equal(t, "/relation-tuples/check", req.Path)
+		assert.JSONEq(t, `{"namespace":"Photo","object":"photo1","relation":"reference","subject_set":{"namespace":"Event","object":"event1","relation":""}}`, req.Body)
+	})
+
+	// Keto 沒有權限時返回 403
+	t.Run("Denied", func(t *testing.T) {
+		client, _ := newRESTClient(t, respond(http.StatusForbidden, `{"allowed":false}`))
+
+		allowed, err := client.CheckPermission(context.Background(), "Photo", "photo1", "reference", "event1")
+		require.NoError(t, err)
+		assert.False(t, allowed)
+	})
+}
+
+func TestREST_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    int
+		body    string
+		want    codes.Code
+		message string
+	}{
+		{"KetoError", http.StatusNotFound, `{"error":{"code":404,"status":"Not Found","message":"Unknown namespace","reason":"Photo"}}`, codes.NotFound, "Unknown namespace: Photo"},
+		{"BadRequest", http.StatusBadRequest, `{"error":{"code":400,"message":"invalid tuple"}}`, codes.InvalidArgument, "invalid tuple"},
+		{"PlainText", http.StatusBadGateway, "upstream unavailable", codes.Unavailable, "upstream unavailable"},
+		{"EmptyBody", http.StatusInternalServerError, "", codes.Internal, "Internal Server Error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := newRESTClient(t, respond(tt.code, tt.body))
+
+			_, err := client.GetEventReferencePhotos(context.Background(), "event1")
+			st := status.Convert(err)
+			assert.Equal(t, tt.want, st.Code())
+			assert.Equal(t, tt.message, st.Message())
+		})
+	}
+
+	t.Run("ConnectionRefused", func(t *testing.T) {
+		addr := unusedAddress(t)
+		client, err := NewClient(addr, addr, WithTransport(TransportREST))
+		require.NoError(t, err)
+		defer client.Close(context.Background())
+
+		_, err = client.GetEventReferencePhotos(context.Background(), "event1")
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		client, _ := newRESTClient(t, func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		}, WithListTimeout(20*time.Millisecond))
+
+		_, err := client.GetEventReferencePhotos(context.Background(), "event1")
+		assert.ErrorIs(t, err, ErrDeadlineExceeded)
+	})
+}
+
+func TestREST_PropagatesTraceContext(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	client, requests := newRESTClient(t, respond(http.StatusOK, `{"allowed":true}`), WithTracerProvider(tp))
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "parent")
+	_, err := client.CheckPermission(ctx, "Photo", "photo1", "reference", "event1")
+	parent.End()
+	require.NoError(t, err)
+
+	req := <-requests
+	assert.Contains(t, req.Header.Get("traceparent"), parent.SpanContext().TraceID().String())
+
+	var rpcSpan sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.SpanKind() == trace.SpanKindClient {
+			rpcSpan = span
+		}
+	}
+	require.NotNil(t, rpcSpan)
+	assert.Equal(t, "ory.keto.relation_tuples.v1alpha2.CheckService/Check", rpcSpan.Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), rpcSpan.Parent().SpanID())
+}
+
+func TestREST_ServiceMethods(t *testing.T) {
+	tree := `{"type":"union","tuple":{"namespace":"Event","object":"event1","relation":"members","subject_set":{"namespace":"Event","object":"event1","relation":"members"}},
+		"children":[{"type":"leaf","tuple":{"namespace":"Event","object":"event1","relation":"members","subject_id":"alice"}}]}`
+	client, requests := newRESTClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/relation-tuples/expand":
+			respond(http.StatusOK, tree)(w, r)
+		case "/namespaces":
+			respond(http.StatusOK, `{"namespaces":[{"name":"Photo"},{"name":"Event"}]}`)(w, r)
+		case "/version":
+			respond(http.StatusOK, `{"version":"v0.14.0"}`)(w, r)
+		default:
+			respond(http.StatusNoContent, "")(w, r)
+		}
+	})
+	ctx := context.Background()
+
+	expanded, err := client.expandClient.Expand(ctx, &rts.ExpandRequest{Subject: rts.NewSubjectSet("Event", "event1", "members"), MaxDepth: 3})
+	require.NoError(t, err)
+	assert.Equal(t, rts.NodeType_NODE_TYPE_UNION, expanded.Tree.NodeType)
+	require.Len(t, expanded.Tree.Children, 1)
+	assert.Equal(t, "alice", expanded.Tree.Children[0].Tuple.Subject.GetId())
+	assert.Equal(t, "max-depth=3&namespace=Event&object=event1&relation=members", (<-requests).Query)
+
+	namespaces, err := client.namespacesClient.ListNamespaces(ctx, &rts.ListNamespacesRequest{})
+	require.NoError(t, err)
+	assert.Len(t, namespaces.Namespaces, 2)
+	<-requests
+
+	version, err := client.versionClient.GetVersion(ctx, &rts.GetVersionRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "v0.14.0", version.Version)
+	<-requests
+
+	_, err = client.writeClient.DeleteRelationTuples(ctx, &rts.DeleteRelationTuplesRequest{
+		RelationQuery: &rts.RelationQuery{Namespace: strPtr("Photo"), Subject: rts.NewSubjectSet("Event", "event1", "")},
+	})
+	require.NoError(t, err)
+	req := <-requests
+	assert.Equal(t, http.MethodDelete, req.Method)
+	assert.Equal(t, "namespace=Photo&subject_set.namespace=Event&subject_set.object=event1&subject_set.relation=", req.Query)
+}
+
+func TestREST_EagerConnect(t *testing.T) {
+	t.Run("Ready", func(t *testing.T) {
+		_, requests := newRESTClient(t, respond(http.StatusOK, `{"status":"ok"}`), WithEagerConnect(time.Second))
+		assert.Equal(t, "/health/ready", (<-requests).Path)
+	})
+
+	t.Run("NotReady", func(t *testing.T) {
+		ts := httptest.NewServer(respond(http.StatusServiceUnavailable, `{"errors":{"database":"down"}}`))
+		defer ts.Close()
+
+		_, err := NewClient(ts.URL, ts.URL, WithTransport(TransportREST), WithEagerConnect(time.Second))
+		assert.ErrorIs(t, err, ErrNotReady)
+	})
+}
+
+func TestREST_InvalidConfig(t *testing.T) {
+	_, err := NewClient("ftp://keto", "ftp://keto", WithTransport(TransportREST))
+	assert.ErrorContains(t, err, "無效的 REST 地址")
+
+	_, err = NewClient("keto:4467", "keto:4466", WithTransport("soap"))
+	assert.ErrorContains(t, err, "未知的傳輸方式")
+}
+
+// 測試攔截器在 REST 傳輸上同樣生效
+func TestREST_Interceptors(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	replayer := NewReplayerFromInteractions(nil)
+	client, requests := newRESTClient(t, respond(http.StatusOK, `{"allowed":true}`), WithMetrics(registry), WithReplay(replayer))
+
+	_, err := client.CheckPermission(context.Background(), "Photo", "photo1", "reference", "event1")
+	assert.ErrorIs(t, err, ErrNoRecording)
+	assert.Empty(t, requests, "回放時不應發送請求")
+	assert.Equal(t, float64(1), testutil.ToFloat64(client.metrics.errors.WithLabelValues("CheckService/Check", "Unknown")))
+}
@@ -0,0 +1,97 @@
+package keto
+
+import (
+	"sync"
+
+	"github.com/AidChen0509/oosa_ketosdk/opl"
+)
+
+//go:generate go run ../cmd/oplgen -o ../config/namespaces.keto.ts
+
+// 命名空間名稱
+const (
+	NamespacePhoto = "Photo"
+	NamespaceEvent = "Event"
+	NamespaceUser  = "User"
+)
+
+// 關係名稱
+const (
+	RelationReference = "reference" // 照片被事件引用
+	RelationPolaroid  = "polaroid"  // 照片是事件的拍立得
+	RelationMembers   = "members"   // 事件的成員
+)
+
+// 權限名稱
+const (
+	PermitView = "view" // 查看權限
+)
+
+// Schema 返回 SDK 使用的命名空間定義
+// config/namespaces.keto.ts 由此定義生成，修改後請執行 go generate ./keto
+//
+// 返回:
+//   - []opl.Namespace: 命名空間定義
+func Schema() []opl.Namespace {
+	return []opl.Namespace{
+		{Name: NamespaceUser},
+		{
+			Name: NamespaceEvent,
+			Relations: []opl.Relation{
+				{
+					Name: RelationMembers,
+					Subjects: []opl.SubjectType{
+						{Namespace: NamespaceUser},
+						{Namespace: NamespaceEvent, Relation: RelationMembers},
+					},
+				},
+			},
+			Permits: []opl.Permit{
+				{Name: PermitView, Rule: opl.Includes{Relation: RelationMembers}},
+			},
+		},
+		{
+			Name: NamespacePhoto,
+			Relations: []opl.Relation{
+				{Name: RelationReference, Subjects: []opl.SubjectType{{Namespace: NamespaceEvent}}},
+				{Name: RelationPolaroid, Subjects: []opl.SubjectType{{Namespace: NamespaceEvent}}},
+			},
+			Permits: []opl.Permit{
+				{
+					// 事件的成員可以查看該事件引用的照片和拍立得
+					Name: PermitView,
+					Rule: opl.Or{
+						opl.Traverse{Relation: RelationReference, Permit: PermitView},
+						opl.Traverse{Relation: RelationPolaroid, Permit: PermitView},
+					},
+				},
+			},
+		},
+	}
+}
+
+// schema Schema() 的緩存，用於確定主體的格式
+var schema = sync.OnceValue(Schema)
+
+// eventSubject 返回事件作為主體的格式 Event:eventID，與 Schema 中 Photo#reference 和 Photo#polaroid 的類型一致
+func eventSubject(eventID string) Subject {
+	return SubjectSetOf(NamespaceEvent, eventID, "")
+}
+
+// subjectFor 返回檢查 namespace#relation 時 id 對應的主體
+// 關係在 Schema 中只接受一種不帶關係的對象 (例如 Photo#reference 只接受 Event) 時，
+// id 是該命名空間的對象，使用主體集合 (Event:id)；其他情況 (包括權限和 User) 使用主體 ID
+func subjectFor(namespace, relation, id string) Subject {
+	ns, ok := opl.Lookup(schema(), namespace)
+	if !ok {
+		return SubjectID(id)
+	}
+	rel, ok := ns.Relation(relation)
+	if !ok || len(rel.Subjects) != 1 {
+		return SubjectID(id)
+	}
+	if t := rel.Subjects[0]; t.Relation == "" && t.Namespace != NamespaceUser {
+		return SubjectSetOf(t.Namespace, id, "")
+	}
+	return SubjectID(id)
+}
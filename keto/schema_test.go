@@ -0,0 +1,30 @@
+package keto
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/AidChen0509/oosa_ketosdk/opl"
+	"github.com/stretchr/testify/assert"
+)
+
+// 確保 config/namespaces.keto.ts 與 SDK 的命名空間定義保持一致
+func TestSchema_MatchesGeneratedConfig(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, opl.Generate(&buf, Schema()))
+
+	generated, err := os.ReadFile("../config/namespaces.keto.ts")
+	assert.NoError(t, err)
+	assert.Equal(t, buf.String(), string(generated), "config/namespaces.keto.ts 已過期，請執行 go generate ./keto")
+}
+
+func TestSchema_CoversClientRelations(t *testing.T) {
+	photo, ok := opl.Lookup(Schema(), NamespacePhoto)
+	assert.True(t, ok)
+
+	for _, relation := range []string{RelationReference, RelationPolaroid} {
+		_, ok := photo.Relation(relation)
+		assert.True(t, ok, relation)
+	}
+}
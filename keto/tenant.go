@@ -0,0 +1,139 @@
+package keto
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+)
+
+// TenantSeparator 租戶前綴與 ID 之間的分隔符，租戶 ID 不能包含此字符
+const TenantSeparator = "/"
+
+// ErrCrossTenant Keto 返回了不屬於當前租戶的元組
+var ErrCrossTenant = errors.New("keto: 跨租戶的元組")
+
+// tenant 租戶 ID，空字符串表示不使用租戶隔離
+type tenant string
+
+// ForTenant 返回一個限定在指定租戶內的客戶端
+//
+// 租戶客戶端在寫入時為對象和主體加上 "<tenantID>/" 前綴，讀取時去除前綴，
+// 因此不同租戶可以使用相同的照片和事件 ID 而互不干擾。
+// 讀取到不屬於該租戶的對象、主體或主體集合時返回 ErrCrossTenant。
+// 租戶客戶端與原客戶端共用連接。
+//
+// 參數:
+//   - tenantID: 租戶的唯一標識符，不能包含 TenantSeparator
+//
+// 返回:
+//   - *Client: 限定在租戶內的客戶端
+//   - error: 如租戶 ID 無效 (ErrInvalidID) 或客戶端已經是租戶客戶端則返回錯誤
+func (k *Client) ForTenant(tenantID string) (*Client, error) {
+	if k.tenant != "" {
+		return nil, fmt.Errorf("keto: 客戶端已限定在租戶 %s 內", k.tenant)
+	}
+
+	tenantID, err := IDValidator{}.Normalize("tenant_id", tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(tenantID, TenantSeparator) {
+		return nil, fmt.Errorf("%w: tenant_id 不能包含 %q", ErrInvalidID, TenantSeparator)
+	}
+
+	scoped := *k
+	scoped.tenant = tenant(tenantID)
+	return &scoped, nil
+}
+
+// Tenant 返回客戶端所屬的租戶 ID，未限定租戶時返回空字符串
+func (k *Client) Tenant() string {
+	return string(k.tenant)
+}
+
+// prefix 返回租戶前綴
+func (t tenant) prefix() string {
+	return string(t) + TenantSeparator
+}
+
+// scopeID 為 ID 加上租戶前綴
+func (t tenant) scopeID(id string) string {
+	if t == "" {
+		return id
+	}
+	return t.prefix() + id
+}
+
+// unscopeID 去除 ID 的租戶前綴
+func (t tenant) unscopeID(id string) (string, error) {
+	if t == "" {
+		return id, nil
+	}
+	unscoped, ok := strings.CutPrefix(id, t.prefix())
+	if !ok {
+		return "", fmt.Errorf("%w: %q 不屬於租戶 %s", ErrCrossTenant, id, string(t))
+	}
+	return unscoped, nil
+}
+
+// scope 為元組的對象和主體加上租戶前綴
+func (t tenant) scope(tuple RelationTuple) RelationTuple {
+	if t == "" {
+		return tuple
+	}
+
+	tuple.Object = t.scopeID(tuple.Object)
+	if tuple.Subject.Set != nil {
+		set := *tuple.Subject.Set
+		set.Object = t.scopeID(set.Object)
+		tuple.Subject.Set = &set
+	} else {
+		tuple.Subject.ID = t.scopeID(tuple.Subject.ID)
+	}
+	return tuple
+}
+
+// unscope 去除元組的對象和主體的租戶前綴
+// 任何部分不屬於該租戶時返回 ErrCrossTenant
+func (t tenant) unscope(tuple RelationTuple) (RelationTuple, error) {
+	if t == "" {
+		return tuple, nil
+	}
+
+	var err error
+	if tuple.Object, err = t.unscopeID(tuple.Object); err != nil {
+		return RelationTuple{}, err
+	}
+	if tuple.Subject.Set != nil {
+		set := *tuple.Subject.Set
+		if set.Object, err = t.unscopeID(set.Object); err != nil {
+			return RelationTuple{}, fmt.Errorf("主體集合 %s: %w", tuple.Subject.Set, err)
+		}
+		tuple.Subject.Set = &set
+	} else if tuple.Subject.ID, err = t.unscopeID(tuple.Subject.ID); err != nil {
+		return RelationTuple{}, err
+	}
+	return tuple, nil
+}
+
+// scopeQuery 為查詢條件中的對象和主體加上租戶前綴
+func (t tenant) scopeQuery(query *rts.RelationQuery) *rts.RelationQuery {
+	if t == "" || query == nil {
+		return query
+	}
+
+	scoped := &rts.RelationQuery{
+		Namespace: query.Namespace,
+		Relation:  query.Relation,
+		Subject:   query.Subject,
+	}
+	if query.Object != nil {
+		scoped.Object = strPtr(t.scopeID(query.GetObject()))
+	}
+	if sub, err := SubjectFromProto(query.Subject); err == nil {
+		scoped.Subject = t.scope(RelationTuple{Subject: sub}).Subject.ToProto()
+	}
+	return scoped
+}
@@ -0,0 +1,140 @@
+package keto
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestForTenant(t *testing.T) {
+	client := &Client{}
+
+	tenantClient, err := client.ForTenant("acme")
+	assert.NoError(t, err)
+	assert.Equal(t, "acme", tenantClient.Tenant())
+	assert.Equal(t, "", client.Tenant())
+
+	// 租戶 ID 不能包含分隔符或保留字符
+	_, err = client.ForTenant("a/b")
+	assert.True(t, errors.Is(err, ErrInvalidID))
+	_, err = client.ForTenant("a:b")
+	assert.True(t, errors.Is(err, ErrInvalidID))
+	_, err = client.ForTenant("")
+	assert.True(t, errors.Is(err, ErrInvalidID))
+
+	// 不能嵌套租戶
+	_, err = tenantClient.ForTenant("other")
+	assert.Error(t, err)
+}
+
+func TestTenantClient_PrefixesWrites(t *testing.T) {
+	mockWriteClient := new(MockWriteServiceClient)
+	client, _ := (&Client{writeClient: mockWriteClient}).ForTenant("acme")
+
+	mockWriteClient.On("TransactRelationTuples", mock.Anything, mock.MatchedBy(func(req *rts.TransactRelationTuplesRequest) bool {
+		tuple := req.RelationTupleDeltas[0].RelationTuple
+		return tuple.Namespace == "Photo" &&
+			tuple.Object == "acme/photo1" &&
+			tuple.Relation == "reference" &&
+			tuple.Subject.GetSet().GetObject() == "acme/event1"
+	})).Return(&rts.TransactRelationTuplesResponse{}, nil)
+
+	err := client.CreatePhotoEventReference(context.Background(), "photo1", "event1")
+
+	assert.NoError(t, err)
+	mockWriteClient.AssertExpectations(t)
+}
+
+func TestTenantClient_PrefixesChecks(t *testing.T) {
+	mockCheckClient := new(MockCheckServiceClient)
+	client, _ := (&Client{checkClient: mockCheckClient}).ForTenant("acme")
+
+	mockCheckClient.On("Check", mock.Anything, mock.MatchedBy(func(req *rts.CheckRequest) bool {
+		return req.Namespace == "Photo" &&
+			req.Object == "acme/photo1" &&
+			req.Subject.GetSet().GetObject() == "acme/event1"
+	})).Return(&rts.CheckResponse{Allowed: true}, nil)
+
+	allowed, err := client.CheckPermission(context.Background(), "Photo", "photo1", "reference", "event1")
+
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+	mockCheckClient.AssertExpectations(t)
+}
+
+func TestTenantClient_StripsPrefixOnRead(t *testing.T) {
+	mockReadClient := new(MockReadServiceClient)
+	client, _ := (&Client{readClient: mockReadClient}).ForTenant("acme")
+
+	mockReadClient.On("ListRelationTuples", mock.Anything, mock.MatchedBy(func(req *rts.ListRelationTuplesRequest) bool {
+		return req.RelationQuery.GetSubject().GetSet().GetObject() == "acme/event1"
+	})).Return(&rts.ListRelationTuplesResponse{
+		RelationTuples: []*rts.RelationTuple{
+			{Namespace: "Photo", Object: "acme/photo1", Relation: "reference", Subject: rts.NewSubjectSet("Event", "acme/event1", "")},
+			{Namespace: "Photo", Object: "acme/photo2", Relation: "reference", Subject: rts.NewSubjectSet("Event", "acme/event1", "")},
+		},
+	}, nil)
+
+	photos, err := client.GetEventReferencePhotos(context.Background(), "event1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"photo1", "photo2"}, photos)
+	mockReadClient.AssertExpectations(t)
+}
+
+func TestTenantClient_RejectsCrossTenantTuples(t *testing.T) {
+	tests := []struct {
+		name  string
+		tuple *rts.RelationTuple
+	}{
+		{
+			name:  "Subject",
+			tuple: &rts.RelationTuple{Namespace: "Photo", Object: "acme/photo1", Relation: "reference", Subject: rts.NewSubjectID("other/event1")},
+		},
+		{
+			name:  "SubjectSet",
+			tuple: &rts.RelationTuple{Namespace: "Photo", Object: "acme/photo1", Relation: "reference", Subject: rts.NewSubjectSet("Event", "other/event1", "members")},
+		},
+		{
+			name:  "UnscopedSubject",
+			tuple: &rts.RelationTuple{Namespace: "Photo", Object: "acme/photo1", Relation: "reference", Subject: rts.NewSubjectID("event1")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockReadClient := new(MockReadServiceClient)
+			client, _ := (&Client{readClient: mockReadClient}).ForTenant("acme")
+
+			mockReadClient.On("ListRelationTuples", mock.Anything, mock.MatchedBy(func(req *rts.ListRelationTuplesRequest) bool {
+				return req.RelationQuery.GetObject() == "acme/photo1"
+			})).Return(&rts.ListRelationTuplesResponse{RelationTuples: []*rts.RelationTuple{tt.tuple}}, nil)
+
+			events, err := client.GetPhotoEvents(context.Background(), "photo1")
+
+			assert.True(t, errors.Is(err, ErrCrossTenant), "err = %v", err)
+			assert.Nil(t, events)
+		})
+	}
+}
+
+func TestTenant_ScopeRoundTrip(t *testing.T) {
+	tn := tenant("acme")
+	tuples := []RelationTuple{
+		{Namespace: "Photo", Object: "photo1", Relation: "reference", Subject: SubjectID("event1")},
+		{Namespace: "Photo", Object: "p1", Relation: "view", Subject: SubjectSetOf("Event", "e1", "members")},
+	}
+
+	for _, tuple := range tuples {
+		scoped := tn.scope(tuple)
+		assert.NotEqual(t, tuple, scoped)
+
+		unscoped, err := tn.unscope(scoped)
+		assert.NoError(t, err)
+		assert.Equal(t, tuple, unscoped)
+	}
+}
@@ -0,0 +1,124 @@
+package keto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrDeadlineExceeded 調用超過截止時間
+// 所有超時錯誤都是 *TimeoutError，可以用 errors.Is(err, ErrDeadlineExceeded) 判斷
+var ErrDeadlineExceeded = errors.New("keto: 調用超時")
+
+// TimeoutError 調用超過截止時間時返回的錯誤
+type TimeoutError struct {
+	Op      string        // 調用類別: check、list、write 或 batch_write
+	Timeout time.Duration // 使用的默認超時，截止時間來自調用方的 context 時為 0
+	Err     error         // 原始錯誤
+}
+
+// Error 實現 error 接口
+func (e *TimeoutError) Error() string {
+	if e.Timeout > 0 {
+		return fmt.Sprintf("keto: %s 調用超過默認超時 %s: %v", e.Op, e.Timeout, e.Err)
+	}
+	return fmt.Sprintf("keto: %s 調用超過截止時間: %v", e.Op, e.Err)
+}
+
+// Unwrap 返回原始錯誤
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// Is 使 errors.Is(err, ErrDeadlineExceeded) 成立
+func (e *TimeoutError) Is(target error) bool {
+	return target == ErrDeadlineExceeded
+}
+
+// timeoutClass 默認超時的調用類別
+type timeoutClass int
+
+const (
+	timeoutCheck      timeoutClass = iota // 權限檢查
+	timeoutList                           // 查詢元組
+	timeoutWrite                          // 寫入或刪除單個元組
+	timeoutBatchWrite                     // 一次寫入多個元組
+	timeoutClassCount
+)
+
+// String 返回調用類別的名稱
+func (c timeoutClass) String() string {
+	switch c {
+	case timeoutCheck:
+		return "check"
+	case timeoutList:
+		return "list"
+	case timeoutWrite:
+		return "write"
+	case timeoutBatchWrite:
+		return "batch_write"
+	default:
+		return "unknown"
+	}
+}
+
+// WithCheckTimeout 設置 CheckPermission 的默認超時
+// 所有默認超時只在調用方的 context 沒有截止時間時使用；d <= 0 表示不限制 (默認)
+func WithCheckTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeouts[timeoutCheck] = d
+	}
+}
+
+// WithListTimeout 設置查詢元組 (GetEventReferencePhotos 等) 的默認超時
+func WithListTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeouts[timeoutList] = d
+	}
+}
+
+// WithWriteTimeout 設置寫入或刪除單個元組的默認超時
+func WithWriteTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeouts[timeoutWrite] = d
+	}
+}
+
+// WithBatchWriteTimeout 設置一次寫入多個元組 (BatchCreatePhotoEventReferences 等) 的默認超時
+func WithBatchWriteTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeouts[timeoutBatchWrite] = d
+	}
+}
+
+// callTimeout 一次調用使用的超時
+type callTimeout struct {
+	class   timeoutClass
+	timeout time.Duration
+}
+
+// withTimeout 在 ctx 沒有截止時間時加上該類別的默認超時
+func (k *Client) withTimeout(ctx context.Context, class timeoutClass) (context.Context, callTimeout, context.CancelFunc) {
+	t := callTimeout{class: class}
+	if _, ok := ctx.Deadline(); ok || k.timeouts[class] <= 0 {
+		return ctx, t, func() {}
+	}
+	t.timeout = k.timeouts[class]
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	return ctx, t, cancel
+}
+
+// wrap 將超時錯誤轉換為 *TimeoutError，其他錯誤原樣返回
+func (t callTimeout) wrap(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, context.DeadlineExceeded) || status.Code(err) == codes.DeadlineExceeded {
+		return &TimeoutError{Op: t.class.String(), Timeout: t.timeout, Err: err}
+	}
+	return err
+}
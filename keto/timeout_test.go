@@ -0,0 +1,118 @@
+package keto
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// hasDeadline 匹配帶有截止時間的 context
+func hasDeadline(within time.Duration) interface{} {
+	return mock.MatchedBy(func(ctx context.Context) bool {
+		deadline, ok := ctx.Deadline()
+		return ok && time.Until(deadline) <= within
+	})
+}
+
+func TestCheckTimeout(t *testing.T) {
+	checkClient := &scriptedCheckClient{calls: []func(context.Context) (*rts.CheckResponse, error){
+		respondAfter(time.Minute, true, nil),
+	}}
+	client := &Client{checkClient: checkClient, timeouts: [timeoutClassCount]time.Duration{timeoutCheck: 20 * time.Millisecond}}
+
+	start := time.Now()
+	_, err := client.CheckPermission(context.Background(), "Photo", "photo1", "reference", "event1")
+
+	assert.Less(t, time.Since(start), time.Second)
+	assert.True(t, errors.Is(err, ErrDeadlineExceeded))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	var timeoutErr *TimeoutError
+	require.True(t, errors.As(err, &timeoutErr))
+	assert.Equal(t, "check", timeoutErr.Op)
+	assert.Equal(t, 20*time.Millisecond, timeoutErr.Timeout)
+}
+
+func TestTimeout_CallerDeadlineWins(t *testing.T) {
+	checkClient := &scriptedCheckClient{calls: []func(context.Context) (*rts.CheckResponse, error){
+		respondAfter(time.Minute, true, nil),
+	}}
+	client := &Client{checkClient: checkClient, timeouts: [timeoutClassCount]time.Duration{timeoutCheck: time.Minute}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := client.CheckPermission(ctx, "Photo", "photo1", "reference", "event1")
+
+	var timeoutErr *TimeoutError
+	require.True(t, errors.As(err, &timeoutErr))
+	// 截止時間來自調用方，沒有使用默認超時
+	assert.Equal(t, time.Duration(0), timeoutErr.Timeout)
+}
+
+func TestWriteTimeouts(t *testing.T) {
+	mockWriteClient := new(MockWriteServiceClient)
+	client := &Client{writeClient: mockWriteClient, timeouts: [timeoutClassCount]time.Duration{
+		timeoutWrite:      time.Second,
+		timeoutBatchWrite: time.Minute,
+	}}
+
+	deadlineErr := status.Error(codes.DeadlineExceeded, "deadline exceeded")
+	mockWriteClient.On("TransactRelationTuples", hasDeadline(time.Second), mock.MatchedBy(func(req *rts.TransactRelationTuplesRequest) bool {
+		return len(req.RelationTupleDeltas) == 1
+	})).Return((*rts.TransactRelationTuplesResponse)(nil), deadlineErr)
+	mockWriteClient.On("TransactRelationTuples", hasDeadline(time.Minute), mock.MatchedBy(func(req *rts.TransactRelationTuplesRequest) bool {
+		return len(req.RelationTupleDeltas) == 2
+	})).Return((*rts.TransactRelationTuplesResponse)(nil), deadlineErr)
+
+	err := client.CreatePhotoEventReference(context.Background(), "photo1", "event1")
+	var timeoutErr *TimeoutError
+	require.True(t, errors.As(err, &timeoutErr))
+	assert.Equal(t, "write", timeoutErr.Op)
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+
+	err = client.BatchCreatePhotoEventPolaroids(context.Background(), []PhotoEventRelation{
+		{PhotoID: "photo1", EventID: "event1"},
+		{PhotoID: "photo2", EventID: "event1"},
+	})
+	require.True(t, errors.As(err, &timeoutErr))
+	assert.Equal(t, "batch_write", timeoutErr.Op)
+	assert.Equal(t, time.Minute, timeoutErr.Timeout)
+	mockWriteClient.AssertExpectations(t)
+}
+
+func TestListTimeout(t *testing.T) {
+	mockReadClient := new(MockReadServiceClient)
+	client := &Client{readClient: mockReadClient, timeouts: [timeoutClassCount]time.Duration{timeoutList: time.Second}}
+
+	mockReadClient.On("ListRelationTuples", hasDeadline(time.Second), mock.Anything).Return((*rts.ListRelationTuplesResponse)(nil), errors.New("unavailable"))
+
+	_, err := client.GetPhotoEvents(context.Background(), "photo1")
+
+	// 非超時錯誤原樣返回
+	assert.EqualError(t, err, "unavailable")
+	assert.False(t, errors.Is(err, ErrDeadlineExceeded))
+	mockReadClient.AssertExpectations(t)
+}
+
+func TestNoDefaultTimeout(t *testing.T) {
+	mockReadClient := new(MockReadServiceClient)
+	client := &Client{readClient: mockReadClient}
+
+	mockReadClient.On("ListRelationTuples", mock.MatchedBy(func(ctx context.Context) bool {
+		_, ok := ctx.Deadline()
+		return !ok
+	}), mock.Anything).Return(&rts.ListRelationTuplesResponse{}, nil)
+
+	_, err := client.GetEventReferencePhotos(context.Background(), "event1")
+
+	assert.NoError(t, err)
+	mockReadClient.AssertExpectations(t)
+}
@@ -0,0 +1,39 @@
+package keto
+
+import (
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+// WithTracerProvider 設置 gRPC 調用使用的 OpenTelemetry TracerProvider
+// 默認使用全局的 otel.GetTracerProvider()
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(o *options) {
+		o.tracerProvider = tp
+	}
+}
+
+// WithPropagator 設置傳遞到 Keto 的追蹤上下文格式
+// 默認使用 W3C Trace Context (traceparent/tracestate)
+func WithPropagator(p propagation.TextMapPropagator) Option {
+	return func(o *options) {
+		o.propagator = p
+	}
+}
+
+// tracingDialOption 為連接添加 OpenTelemetry 追蹤
+// 每個 RPC 會產生一個客戶端 span，並通過 gRPC metadata 將追蹤上下文傳遞到 Keto
+func tracingDialOption(o *options) grpc.DialOption {
+	propagator := o.propagator
+	if propagator == nil {
+		propagator = propagation.TraceContext{}
+	}
+
+	handlerOpts := []otelgrpc.Option{otelgrpc.WithPropagators(propagator)}
+	if o.tracerProvider != nil {
+		handlerOpts = append(handlerOpts, otelgrpc.WithTracerProvider(o.tracerProvider))
+	}
+	return grpc.WithStatsHandler(otelgrpc.NewClientHandler(handlerOpts...))
+}
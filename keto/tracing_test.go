@@ -0,0 +1,68 @@
+package keto
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// traceCheckServer 記錄收到的 traceparent 的 CheckService
+type traceCheckServer struct {
+	rts.UnimplementedCheckServiceServer
+	traceparent chan string
+}
+
+func (s *traceCheckServer) Check(ctx context.Context, _ *rts.CheckRequest) (*rts.CheckResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.traceparent <- md.Get("traceparent")[0]
+	return &rts.CheckResponse{Allowed: true}, nil
+}
+
+func TestClient_PropagatesTraceContext(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	checkServer := &traceCheckServer{traceparent: make(chan string, 1)}
+	grpcServer := grpc.NewServer()
+	rts.RegisterCheckServiceServer(grpcServer, checkServer)
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	client, err := NewClient(lis.Addr().String(), lis.Addr().String(), WithTracerProvider(tp))
+	require.NoError(t, err)
+	defer client.Close(context.Background())
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "parent")
+	allowed, err := client.CheckPermission(ctx, "Photo", "photo1", "reference", "event1")
+	parent.End()
+
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+
+	// Keto 收到的 traceparent 屬於同一個追蹤
+	traceparent := <-checkServer.traceparent
+	assert.Contains(t, traceparent, parent.SpanContext().TraceID().String())
+
+	// 客戶端 span 是父 span 的子 span
+	var rpcSpan sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.SpanKind() == trace.SpanKindClient {
+			rpcSpan = span
+		}
+	}
+	require.NotNil(t, rpcSpan)
+	assert.Equal(t, "ory.keto.relation_tuples.v1alpha2.CheckService/Check", rpcSpan.Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), rpcSpan.Parent().SpanID())
+}
@@ -0,0 +1,117 @@
+package keto
+
+import (
+	"fmt"
+	"net/http"
+
+	"google.golang.org/grpc"
+)
+
+// Transport 客戶端與 Keto 通信的方式
+type Transport string
+
+// 可用的傳輸方式
+const (
+	TransportGRPC Transport = "grpc" // Keto 的 gRPC API (默認)
+	TransportREST Transport = "rest" // Keto 的 HTTP REST API
+)
+
+// WithTransport 設置與 Keto 通信的方式，默認為 TransportGRPC
+//
+// 使用 TransportREST 時，NewClient 的地址為 Keto 的 HTTP 地址 (例如 "http://127.0.0.1:4467")，
+// 沒有協議時默認為 http。兩種傳輸方式的語意相同，攔截器、讀取副本、對沖請求和記錄回放都可以使用；
+// gRPC 專有的選項 (WithDialOptions、WithWaitForReady、負載均衡策略) 對 REST 無效。
+func WithTransport(t Transport) Option {
+	return func(o *options) {
+		o.transport = t
+	}
+}
+
+// WithHTTPClient 設置 REST 傳輸使用的 HTTP 客戶端，可用於配置 TLS 和代理
+// 默認使用獨立的連接池，調用 Close 時關閉空閒連接
+func WithHTTPClient(c *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = c
+	}
+}
+
+// transportConns 一種傳輸方式建立的寫入和讀取連接
+type transportConns struct {
+	write     grpc.ClientConnInterface
+	reads     []grpc.ClientConnInterface // 與 readAddress 和 WithReadReplicas 的地址一一對應
+	grpcWrite *grpc.ClientConn
+	grpcReads []*grpc.ClientConn
+	rest      []*restConn
+}
+
+// dial 按照傳輸方式建立寫入和讀取連接
+func (o *options) dial(writeAddress string, readAddresses []string) (*transportConns, error) {
+	switch o.transport {
+	case "", TransportGRPC:
+		return o.dialGRPC(writeAddress, readAddresses)
+	case TransportREST:
+		return o.dialREST(writeAddress, readAddresses)
+	default:
+		return nil, fmt.Errorf("keto: 未知的傳輸方式 %q", o.transport)
+	}
+}
+
+// dialGRPC 建立 gRPC 連接
+func (o *options) dialGRPC(writeAddress string, readAddresses []string) (*transportConns, error) {
+	writeConn, err := grpc.NewClient(writeAddress, o.dialOptions()...)
+	if err != nil {
+		return nil, err
+	}
+	conns := &transportConns{write: writeConn, grpcWrite: writeConn}
+
+	readDialOptions := o.readDialOptions()
+	for _, address := range readAddresses {
+		conn, err := grpc.NewClient(address, readDialOptions...)
+		if err != nil {
+			conns.close()
+			return nil, err
+		}
+		conns.reads = append(conns.reads, conn)
+		conns.grpcReads = append(conns.grpcReads, conn)
+	}
+	return conns, nil
+}
+
+// dialREST 建立 REST 連接
+func (o *options) dialREST(writeAddress string, readAddresses []string) (*transportConns, error) {
+	httpClient := o.httpClient
+	ownsClient := httpClient == nil
+	if ownsClient {
+		httpClient = &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()}
+	}
+
+	writeConn, err := newRESTConn(writeAddress, httpClient, o)
+	if err != nil {
+		return nil, err
+	}
+	conns := &transportConns{write: writeConn, rest: []*restConn{writeConn}}
+
+	for _, address := range readAddresses {
+		conn, err := newRESTConn(address, httpClient, o)
+		if err != nil {
+			return nil, err
+		}
+		conns.reads = append(conns.reads, conn)
+		conns.rest = append(conns.rest, conn)
+	}
+
+	if ownsClient {
+		writeConn.closeIdle = httpClient.CloseIdleConnections
+	}
+	return conns, nil
+}
+
+// close 關閉所有 gRPC 連接
+func (c *transportConns) close() {
+	if c.grpcWrite != nil {
+		c.grpcWrite.Close()
+	}
+	for _, conn := range c.grpcReads {
+		conn.Close()
+	}
+}
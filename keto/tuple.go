@@ -0,0 +1,221 @@
+package keto
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+)
+
+// ErrMalformedTuple 元組字符串格式錯誤
+var ErrMalformedTuple = errors.New("keto: 元組格式錯誤")
+
+// SubjectSet 主體集合，例如 Event:e1#members
+type SubjectSet struct {
+	Namespace string
+	Object    string
+	Relation  string
+}
+
+// Subject 關係元組的主體
+// ID 和 Set 只會設置其中一個
+type Subject struct {
+	ID  string      // 主體標識符
+	Set *SubjectSet // 主體集合
+}
+
+// RelationTuple 關係元組
+//
+// 字符串格式與 Keto 的工具一致:
+//   - Event:e1#members@alice
+//   - Photo:photo1#reference@(Event:event1#)
+//   - Photo:p1#view@(Event:e1#members)
+//
+// 當命名空間不含 ':'、對象不含 '#'、關係不含 '@'、主體 ID 不含 '#' 時，
+// ParseRelationTuple(t.String()) 保證返回與 t 相同的元組
+type RelationTuple struct {
+	Namespace string
+	Object    string
+	Relation  string
+	Subject   Subject
+}
+
+// SubjectID 創建一個以 ID 為主體的 Subject
+func SubjectID(id string) Subject {
+	return Subject{ID: id}
+}
+
+// SubjectSetOf 創建一個以主體集合為主體的 Subject
+func SubjectSetOf(namespace, object, relation string) Subject {
+	return Subject{Set: &SubjectSet{Namespace: namespace, Object: object, Relation: relation}}
+}
+
+// String 返回主體集合的字符串格式，例如 Event:e1#members
+func (s SubjectSet) String() string {
+	return s.Namespace + ":" + s.Object + "#" + s.Relation
+}
+
+// String 返回主體的字符串格式，主體集合會加上括號
+func (s Subject) String() string {
+	if s.Set != nil {
+		return "(" + s.Set.String() + ")"
+	}
+	return s.ID
+}
+
+// String 返回元組的字符串格式，例如 Event:e1#members@alice
+func (t RelationTuple) String() string {
+	return t.Namespace + ":" + t.Object + "#" + t.Relation + "@" + t.Subject.String()
+}
+
+// MarshalText 實現 encoding.TextMarshaler
+func (t RelationTuple) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText 實現 encoding.TextUnmarshaler
+func (t *RelationTuple) UnmarshalText(text []byte) error {
+	parsed, err := ParseRelationTuple(string(text))
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// ParseSubjectSet 解析主體集合字符串
+//
+// 參數:
+//   - s: 主體集合字符串 (例如: "Event:e1#members")，可以帶括號
+//
+// 返回:
+//   - SubjectSet: 解析後的主體集合
+//   - error: 如格式錯誤則返回 ErrMalformedTuple
+func ParseSubjectSet(s string) (SubjectSet, error) {
+	if strings.HasPrefix(s, "(") || strings.HasSuffix(s, ")") {
+		if !strings.HasPrefix(s, "(") || !strings.HasSuffix(s, ")") {
+			return SubjectSet{}, fmt.Errorf("%w: 主體集合 %q 括號不匹配", ErrMalformedTuple, s)
+		}
+		s = s[1 : len(s)-1]
+	}
+
+	namespace, rest, ok := strings.Cut(s, ":")
+	if !ok {
+		return SubjectSet{}, fmt.Errorf("%w: 主體集合 %q 缺少 ':'", ErrMalformedTuple, s)
+	}
+	object, relation, ok := strings.Cut(rest, "#")
+	if !ok {
+		return SubjectSet{}, fmt.Errorf("%w: 主體集合 %q 缺少 '#'", ErrMalformedTuple, s)
+	}
+	if namespace == "" || object == "" {
+		return SubjectSet{}, fmt.Errorf("%w: 主體集合 %q 的命名空間和對象不能為空", ErrMalformedTuple, s)
+	}
+
+	return SubjectSet{Namespace: namespace, Object: object, Relation: relation}, nil
+}
+
+// ParseSubject 解析主體字符串
+// 包含 '#' 的字符串視為主體集合，否則視為主體 ID
+//
+// 參數:
+//   - s: 主體字符串 (例如: "event1" 或 "(Event:e1#members)")
+//
+// 返回:
+//   - Subject: 解析後的主體
+//   - error: 如格式錯誤則返回 ErrMalformedTuple
+func ParseSubject(s string) (Subject, error) {
+	if s == "" {
+		return Subject{}, fmt.Errorf("%w: 主體不能為空", ErrMalformedTuple)
+	}
+	if !strings.Contains(s, "#") {
+		return Subject{ID: s}, nil
+	}
+
+	set, err := ParseSubjectSet(s)
+	if err != nil {
+		return Subject{}, err
+	}
+	return Subject{Set: &set}, nil
+}
+
+// ParseRelationTuple 解析元組字符串
+//
+// 參數:
+//   - s: 元組字符串 (例如: "Photo:photo1#reference@(Event:event1#)")
+//
+// 返回:
+//   - RelationTuple: 解析後的元組
+//   - error: 如格式錯誤則返回 ErrMalformedTuple
+func ParseRelationTuple(s string) (RelationTuple, error) {
+	namespace, rest, ok := strings.Cut(s, ":")
+	if !ok {
+		return RelationTuple{}, fmt.Errorf("%w: %q 缺少 ':'", ErrMalformedTuple, s)
+	}
+	object, rest, ok := strings.Cut(rest, "#")
+	if !ok {
+		return RelationTuple{}, fmt.Errorf("%w: %q 缺少 '#'", ErrMalformedTuple, s)
+	}
+	relation, subject, ok := strings.Cut(rest, "@")
+	if !ok {
+		return RelationTuple{}, fmt.Errorf("%w: %q 缺少 '@'", ErrMalformedTuple, s)
+	}
+	if namespace == "" || object == "" || relation == "" {
+		return RelationTuple{}, fmt.Errorf("%w: %q 的命名空間、對象和關係不能為空", ErrMalformedTuple, s)
+	}
+
+	sub, err := ParseSubject(subject)
+	if err != nil {
+		return RelationTuple{}, err
+	}
+
+	return RelationTuple{
+		Namespace: namespace,
+		Object:    object,
+		Relation:  relation,
+		Subject:   sub,
+	}, nil
+}
+
+// ToProto 轉換為 Keto 的 protobuf 主體
+func (s Subject) ToProto() *rts.Subject {
+	if s.Set != nil {
+		return rts.NewSubjectSet(s.Set.Namespace, s.Set.Object, s.Set.Relation)
+	}
+	return rts.NewSubjectID(s.ID)
+}
+
+// ToProto 轉換為 Keto 的 protobuf 元組
+func (t RelationTuple) ToProto() *rts.RelationTuple {
+	return &rts.RelationTuple{
+		Namespace: t.Namespace,
+		Object:    t.Object,
+		Relation:  t.Relation,
+		Subject:   t.Subject.ToProto(),
+	}
+}
+
+// SubjectFromProto 從 Keto 的 protobuf 主體轉換
+func SubjectFromProto(s *rts.Subject) (Subject, error) {
+	switch ref := s.GetRef().(type) {
+	case *rts.Subject_Id:
+		return Subject{ID: ref.Id}, nil
+	case *rts.Subject_Set:
+		return SubjectSetOf(ref.Set.GetNamespace(), ref.Set.GetObject(), ref.Set.GetRelation()), nil
+	}
+	return Subject{}, fmt.Errorf("%w: 缺少主體", ErrMalformedTuple)
+}
+
+// RelationTupleFromProto 從 Keto 的 protobuf 元組轉換
+func RelationTupleFromProto(t *rts.RelationTuple) (RelationTuple, error) {
+	sub, err := SubjectFromProto(t.GetSubject())
+	if err != nil {
+		return RelationTuple{}, err
+	}
+	return RelationTuple{
+		Namespace: t.GetNamespace(),
+		Object:    t.GetObject(),
+		Relation:  t.GetRelation(),
+		Subject:   sub,
+	}, nil
+}
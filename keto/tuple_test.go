@@ -0,0 +1,155 @@
+package keto
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRelationTuple(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected RelationTuple
+	}{
+		{
+			input: "Photo:photo1#reference@event1",
+			expected: RelationTuple{
+				Namespace: "Photo", Object: "photo1", Relation: "reference",
+				Subject: SubjectID("event1"),
+			},
+		},
+		{
+			input: "Photo:p1#view@(Event:e1#members)",
+			expected: RelationTuple{
+				Namespace: "Photo", Object: "p1", Relation: "view",
+				Subject: SubjectSetOf("Event", "e1", "members"),
+			},
+		},
+		{
+			// 主體集合也可以不帶括號
+			input: "Photo:p1#view@Event:e1#members",
+			expected: RelationTuple{
+				Namespace: "Photo", Object: "p1", Relation: "view",
+				Subject: SubjectSetOf("Event", "e1", "members"),
+			},
+		},
+		{
+			// 主體集合的關係可以為空
+			input: "Photo:p1#reference@(Event:e1#)",
+			expected: RelationTuple{
+				Namespace: "Photo", Object: "p1", Relation: "reference",
+				Subject: SubjectSetOf("Event", "e1", ""),
+			},
+		},
+		{
+			// 不含 '#' 的主體視為 ID
+			input: "Photo:p1#reference@user:alice@example.com",
+			expected: RelationTuple{
+				Namespace: "Photo", Object: "p1", Relation: "reference",
+				Subject: SubjectID("user:alice@example.com"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			tuple, err := ParseRelationTuple(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, tuple)
+		})
+	}
+}
+
+func TestParseRelationTuple_Errors(t *testing.T) {
+	inputs := []string{
+		"",
+		"Photo",
+		"Photo:photo1",
+		"Photo:photo1#reference",
+		"Photo:photo1#reference@",
+		":photo1#reference@event1",
+		"Photo:#reference@event1",
+		"Photo:photo1#@event1",
+		"Photo:p1#view@(Event:e1#members",
+		"Photo:p1#view@Event:e1#members)",
+		"Photo:p1#view@(Event#members)",
+		"Photo:p1#view@(:e1#members)",
+		"Photo:p1#view@(Event:#members)",
+	}
+
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			_, err := ParseRelationTuple(input)
+			assert.True(t, errors.Is(err, ErrMalformedTuple), "err = %v", err)
+		})
+	}
+}
+
+func TestRelationTuple_String(t *testing.T) {
+	assert.Equal(t, "Photo:photo1#reference@event1", RelationTuple{
+		Namespace: "Photo", Object: "photo1", Relation: "reference",
+		Subject: SubjectID("event1"),
+	}.String())
+
+	assert.Equal(t, "Photo:p1#view@(Event:e1#members)", RelationTuple{
+		Namespace: "Photo", Object: "p1", Relation: "view",
+		Subject: SubjectSetOf("Event", "e1", "members"),
+	}.String())
+}
+
+func TestRelationTuple_RoundTrip(t *testing.T) {
+	tuples := []RelationTuple{
+		{Namespace: "Photo", Object: "photo1", Relation: "reference", Subject: SubjectID("event1")},
+		{Namespace: "Photo", Object: "p1", Relation: "view", Subject: SubjectSetOf("Event", "e1", "members")},
+		{Namespace: "Photo", Object: "p1", Relation: "reference", Subject: SubjectSetOf("Event", "e1", "")},
+		{Namespace: "Photo", Object: "a:b", Relation: "polaroid", Subject: SubjectID("x@y:z")},
+		{Namespace: "Photo", Object: "照片1", Relation: "reference", Subject: SubjectID("(活動)")},
+		{Namespace: "Photo", Object: "p1", Relation: "view", Subject: SubjectSetOf("Event", "e:1", "a#b")},
+	}
+
+	for _, tuple := range tuples {
+		t.Run(tuple.String(), func(t *testing.T) {
+			parsed, err := ParseRelationTuple(tuple.String())
+			assert.NoError(t, err)
+			assert.Equal(t, tuple, parsed)
+			assert.Equal(t, tuple.String(), parsed.String())
+		})
+	}
+}
+
+func TestRelationTuple_TextMarshaling(t *testing.T) {
+	tuples := []RelationTuple{
+		{Namespace: "Photo", Object: "photo1", Relation: "reference", Subject: SubjectID("event1")},
+		{Namespace: "Photo", Object: "p1", Relation: "view", Subject: SubjectSetOf("Event", "e1", "members")},
+	}
+
+	data, err := json.Marshal(tuples)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `["Photo:photo1#reference@event1", "Photo:p1#view@(Event:e1#members)"]`, string(data))
+
+	var decoded []RelationTuple
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, tuples, decoded)
+
+	var invalid RelationTuple
+	assert.Error(t, json.Unmarshal([]byte(`"Photo:photo1"`), &invalid))
+}
+
+func TestRelationTuple_Proto(t *testing.T) {
+	tuples := []RelationTuple{
+		{Namespace: "Photo", Object: "photo1", Relation: "reference", Subject: SubjectID("event1")},
+		{Namespace: "Photo", Object: "p1", Relation: "view", Subject: SubjectSetOf("Event", "e1", "members")},
+	}
+
+	for _, tuple := range tuples {
+		converted, err := RelationTupleFromProto(tuple.ToProto())
+		assert.NoError(t, err)
+		assert.Equal(t, tuple, converted)
+	}
+
+	_, err := RelationTupleFromProto(&rts.RelationTuple{Namespace: "Photo", Object: "p1", Relation: "view"})
+	assert.True(t, errors.Is(err, ErrMalformedTuple))
+}
@@ -0,0 +1,83 @@
+package keto
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// DefaultMaxIDLength ID 的默認最大長度 (字元數)
+const DefaultMaxIDLength = 128
+
+// ReservedIDCharacters Keto 元組字符串格式中的保留字符，不能出現在 ID 中
+const ReservedIDCharacters = ":#@"
+
+// ErrInvalidID ID 不符合驗證規則
+var ErrInvalidID = errors.New("keto: 無效的 ID")
+
+// UUIDPattern 匹配 UUID 格式的 ID，可用於 IDValidator.Pattern
+var UUIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// IDValidator ID 驗證和規範化規則
+// 零值即為默認規則：長度不超過 DefaultMaxIDLength，不含保留字符、空白和控制字符
+type IDValidator struct {
+	MaxLength int            // 最大長度 (字元數)，0 表示使用 DefaultMaxIDLength
+	Pattern   *regexp.Regexp // 可選的 ID 格式 (例如: UUIDPattern)，在規範化後匹配
+}
+
+// Normalize 驗證並規範化 ID
+// ID 會先轉換為 Unicode NFC 形式，以確保相同的 ID 總是對應相同的元組
+//
+// 參數:
+//   - field: 字段名稱，用於錯誤信息 (例如: "photo_id")
+//   - id: 要驗證的 ID
+//
+// 返回:
+//   - string: 規範化後的 ID
+//   - error: 如 ID 無效則返回包裝了 ErrInvalidID 的錯誤
+func (v IDValidator) Normalize(field, id string) (string, error) {
+	if id == "" {
+		return "", fmt.Errorf("%w: %s 不能為空", ErrInvalidID, field)
+	}
+	if !utf8.ValidString(id) {
+		return "", fmt.Errorf("%w: %s 不是有效的 UTF-8 字符串", ErrInvalidID, field)
+	}
+
+	id = norm.NFC.String(id)
+
+	maxLength := v.MaxLength
+	if maxLength <= 0 {
+		maxLength = DefaultMaxIDLength
+	}
+	if n := utf8.RuneCountInString(id); n > maxLength {
+		return "", fmt.Errorf("%w: %s 長度為 %d，超過上限 %d", ErrInvalidID, field, n, maxLength)
+	}
+
+	for _, r := range id {
+		switch {
+		case strings.ContainsRune(ReservedIDCharacters, r):
+			return "", fmt.Errorf("%w: %s 不能包含保留字符 %q", ErrInvalidID, field, r)
+		case unicode.IsSpace(r):
+			return "", fmt.Errorf("%w: %s 不能包含空白字符", ErrInvalidID, field)
+		case unicode.IsControl(r) || !unicode.IsPrint(r):
+			return "", fmt.Errorf("%w: %s 不能包含控制字符 %U", ErrInvalidID, field, r)
+		}
+	}
+
+	if v.Pattern != nil && !v.Pattern.MatchString(id) {
+		return "", fmt.Errorf("%w: %s 不符合格式 %s", ErrInvalidID, field, v.Pattern)
+	}
+
+	return id, nil
+}
+
+// Validate 驗證 ID，不返回規範化結果
+func (v IDValidator) Validate(field, id string) error {
+	_, err := v.Normalize(field, id)
+	return err
+}
@@ -0,0 +1,126 @@
+package keto
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestIDValidator_Normalize(t *testing.T) {
+	// "é" 的分解形式 (e + U+0301) 會被規範化為組合形式 (U+00E9)
+	id, err := IDValidator{}.Normalize("photo_id", "cafe\u0301")
+	assert.NoError(t, err)
+	assert.Equal(t, "caf\u00e9", id)
+
+	id, err = IDValidator{}.Normalize("photo_id", "照片-1_a.b")
+	assert.NoError(t, err)
+	assert.Equal(t, "照片-1_a.b", id)
+}
+
+func TestIDValidator_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		validator IDValidator
+		id        string
+		message   string
+	}{
+		{name: "Empty", id: "", message: "photo_id 不能為空"},
+		{name: "Colon", id: "a:b", message: "保留字符 ':'"},
+		{name: "Hash", id: "a#b", message: "保留字符 '#'"},
+		{name: "At", id: "a@b", message: "保留字符 '@'"},
+		{name: "Space", id: "a b", message: "空白字符"},
+		{name: "Tab", id: "a\tb", message: "空白字符"},
+		{name: "Control", id: "a\x00b", message: "控制字符"},
+		{name: "InvalidUTF8", id: "a\xffb", message: "UTF-8"},
+		{name: "TooLong", id: strings.Repeat("a", DefaultMaxIDLength+1), message: "超過上限"},
+		{name: "CustomMaxLength", validator: IDValidator{MaxLength: 3}, id: "abcd", message: "超過上限 3"},
+		{name: "Pattern", validator: IDValidator{Pattern: UUIDPattern}, id: "photo1", message: "不符合格式"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.validator.Normalize("photo_id", tt.id)
+			assert.True(t, errors.Is(err, ErrInvalidID), "err = %v", err)
+			assert.Contains(t, err.Error(), tt.message)
+		})
+	}
+}
+
+func TestIDValidator_Pattern(t *testing.T) {
+	v := IDValidator{Pattern: UUIDPattern}
+	assert.NoError(t, v.Validate("photo_id", "123e4567-e89b-12d3-a456-426614174000"))
+
+	v = IDValidator{Pattern: regexp.MustCompile(`^evt_[0-9]+$`)}
+	assert.NoError(t, v.Validate("event_id", "evt_42"))
+	assert.Error(t, v.Validate("event_id", "event42"))
+}
+
+func TestClient_RejectsInvalidIDs(t *testing.T) {
+	mockWriteClient := new(MockWriteServiceClient)
+	mockReadClient := new(MockReadServiceClient)
+	mockCheckClient := new(MockCheckServiceClient)
+
+	client := &Client{
+		writeClient: mockWriteClient,
+		readClient:  mockReadClient,
+		checkClient: mockCheckClient,
+	}
+
+	err := client.CreatePhotoEventReference(context.Background(), "photo:1", "event1")
+	assert.True(t, errors.Is(err, ErrInvalidID))
+
+	err = client.CreatePhotoEventPolaroid(context.Background(), "photo1", "event 1")
+	assert.True(t, errors.Is(err, ErrInvalidID))
+
+	err = client.BatchCreatePhotoEventReferences(context.Background(), []PhotoEventRelation{
+		{PhotoID: "photo1", EventID: "event1"},
+		{PhotoID: "photo#2", EventID: "event1"},
+	})
+	assert.True(t, errors.Is(err, ErrInvalidID))
+	assert.Contains(t, err.Error(), "relations[1]")
+
+	_, err = client.CheckPermission(context.Background(), "Photo", "photo1", "reference", "event@1")
+	assert.True(t, errors.Is(err, ErrInvalidID))
+
+	_, err = client.GetEventReferencePhotos(context.Background(), "")
+	assert.True(t, errors.Is(err, ErrInvalidID))
+
+	_, err = client.GetPhotoEvents(context.Background(), "photo\n1")
+	assert.True(t, errors.Is(err, ErrInvalidID))
+
+	err = client.DeletePhotoEventRelation(context.Background(), "photo1", "", RelationReference)
+	assert.True(t, errors.Is(err, ErrInvalidID))
+
+	// 無效的 ID 不會發送到 Keto
+	mockWriteClient.AssertNotCalled(t, "TransactRelationTuples", mock.Anything, mock.Anything)
+	mockReadClient.AssertNotCalled(t, "ListRelationTuples", mock.Anything, mock.Anything)
+	mockCheckClient.AssertNotCalled(t, "Check", mock.Anything, mock.Anything)
+}
+
+func TestClient_NormalizesIDs(t *testing.T) {
+	mockWriteClient := new(MockWriteServiceClient)
+	client := &Client{writeClient: mockWriteClient}
+
+	mockWriteClient.On("TransactRelationTuples", mock.Anything, mock.MatchedBy(func(req *rts.TransactRelationTuplesRequest) bool {
+		return req.RelationTupleDeltas[0].RelationTuple.Object == "caf\u00e9"
+	})).Return(&rts.TransactRelationTuplesResponse{}, nil)
+
+	err := client.CreatePhotoEventReference(context.Background(), "cafe\u0301", "event1")
+
+	assert.NoError(t, err)
+	mockWriteClient.AssertExpectations(t)
+}
+
+func TestClient_CustomIDValidator(t *testing.T) {
+	client := &Client{ids: IDValidator{Pattern: UUIDPattern}}
+
+	err := client.CreatePhotoEventReference(context.Background(), "photo1", "event1")
+	assert.True(t, errors.Is(err, ErrInvalidID))
+	assert.Contains(t, err.Error(), "photo_id")
+}
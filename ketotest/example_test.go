@@ -0,0 +1,30 @@
+package ketotest_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/AidChen0509/oosa_ketosdk/keto"
+	"github.com/AidChen0509/oosa_ketosdk/ketotest"
+)
+
+// 使用測試服務器端到端地測試 keto.Client
+func Example() {
+	srv := ketotest.NewServer()
+	defer srv.Close()
+
+	client, err := keto.NewClient(srv.Addr(), srv.Addr(), keto.WithDialOptions(srv.DialOptions()...))
+	if err != nil {
+		panic(err)
+	}
+	defer client.Close(context.Background())
+
+	ctx := context.Background()
+	_ = client.CreatePhotoEventReference(ctx, "photo1", "event1")
+	_ = client.CreatePhotoEventPolaroid(ctx, "photo2", "event1")
+
+	photos, _ := client.GetEventReferencePhotos(ctx, "event1")
+	allowed, _ := client.CheckPermission(ctx, keto.NamespacePhoto, "photo2", keto.RelationPolaroid, "event1")
+	fmt.Println(photos, allowed)
+	// Output: [photo1] true
+}
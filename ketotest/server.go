@@ -0,0 +1,80 @@
+// Package ketotest 提供在內存中實現 Keto gRPC 服務的測試服務器
+//
+// 服務器通過 bufconn 提供 Read、Write、Check、Expand、Namespaces 和 Version 服務，
+// 不需要外部的 Keto 即可端到端地測試 keto.Client：
+//
+//	srv := ketotest.NewServer()
+//	defer srv.Close()
+//
+//	client, err := keto.NewClient(srv.Addr(), srv.Addr(), keto.WithDialOptions(srv.DialOptions()...))
+//
+// HTTPHandler 以 Keto 的 REST API 提供同一個存儲，用於測試 REST 傳輸。
+// 服務器由 keto/embedded 實現，ketotest 只添加測試使用的輔助方法；
+// 需要在正式程序中內嵌存儲或保存到文件時直接使用 keto/embedded。
+package ketotest
+
+import (
+	"fmt"
+
+	"github.com/AidChen0509/oosa_ketosdk/keto/embedded"
+	"github.com/AidChen0509/oosa_ketosdk/opl"
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+	"google.golang.org/grpc"
+)
+
+// 服務器的默認設置
+const (
+	DefaultVersion  = "ketotest"
+	DefaultPageSize = embedded.DefaultPageSize
+	DefaultMaxDepth = embedded.DefaultMaxDepth
+)
+
+// Option 配置 Server 的選項
+type Option = embedded.Option
+
+// WithNamespaces 設置已知的命名空間
+// 設置後寫入或查詢未知的命名空間會返回 NotFound；默認接受任何命名空間
+func WithNamespaces(names ...string) Option {
+	return embedded.WithNamespaces(names...)
+}
+
+// WithSchema 設置命名空間的定義，例如 keto.Schema()
+// 命名空間會成為已知的命名空間；檢查權限時按 OPL 規則計算，寫入未定義的關係返回 InvalidArgument
+func WithSchema(namespaces []opl.Namespace) Option {
+	return embedded.WithSchema(namespaces)
+}
+
+// WithVersion 設置 Version 服務返回的版本，默認為 DefaultVersion
+func WithVersion(version string) Option {
+	return embedded.WithVersion(version)
+}
+
+// WithServerOptions 設置 gRPC 服務器的選項，例如攔截器
+func WithServerOptions(opts ...grpc.ServerOption) Option {
+	return embedded.WithServerOptions(opts...)
+}
+
+// Server 在內存中實現 Keto gRPC 服務的測試服務器
+type Server struct {
+	*embedded.Server
+}
+
+// NewServer 創建並啟動測試服務器，使用完畢後需調用 Close
+func NewServer(opts ...Option) *Server {
+	opts = append([]Option{embedded.WithVersion(DefaultVersion)}, opts...)
+	return &Server{Server: embedded.NewServer(opts...)}
+}
+
+// Insert 直接寫入元組，用於準備測試數據
+func (s *Server) Insert(tuples ...*rts.RelationTuple) {
+	if err := s.Server.Insert(tuples...); err != nil {
+		panic(fmt.Sprintf("ketotest: %v", err))
+	}
+}
+
+// Reset 刪除所有元組
+func (s *Server) Reset() {
+	if err := s.Server.Reset(); err != nil {
+		panic(fmt.Sprintf("ketotest: %v", err))
+	}
+}
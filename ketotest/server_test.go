@@ -0,0 +1,48 @@
+package ketotest
+
+import (
+	"context"
+	"testing"
+
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestServer(t *testing.T) {
+	s := NewServer(WithNamespaces("Photo"))
+	defer s.Close()
+
+	conn, err := grpc.NewClient(s.Addr(), append(s.DialOptions(), grpc.WithTransportCredentials(insecure.NewCredentials()))...)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	version, err := rts.NewVersionServiceClient(conn).GetVersion(context.Background(), &rts.GetVersionRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultVersion, version.Version)
+
+	s.Insert(&rts.RelationTuple{Namespace: "Photo", Object: "photo1", Relation: "reference", Subject: rts.NewSubjectID("event1")})
+	check, err := rts.NewCheckServiceClient(conn).Check(context.Background(), &rts.CheckRequest{
+		Namespace: "Photo", Object: "photo1", Relation: "reference", Subject: rts.NewSubjectID("event1"),
+	})
+	require.NoError(t, err)
+	assert.True(t, check.Allowed)
+
+	s.Reset()
+	assert.Empty(t, s.Tuples())
+}
+
+func TestWithVersion(t *testing.T) {
+	s := NewServer(WithVersion("v0.14.0"))
+	defer s.Close()
+
+	conn, err := grpc.NewClient(s.Addr(), append(s.DialOptions(), grpc.WithTransportCredentials(insecure.NewCredentials()))...)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	version, err := rts.NewVersionServiceClient(conn).GetVersion(context.Background(), &rts.GetVersionRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "v0.14.0", version.Version)
+}
@@ -0,0 +1,198 @@
+package opl
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// header 生成文件的開頭
+const header = `// 此文件由 cmd/oplgen 根據 SDK 的命名空間定義生成，請勿手動修改。
+import { Namespace, SubjectSet, Context } from "@ory/keto-namespace-types"
+`
+
+// Validate 檢查命名空間定義是否完整
+// 所有引用的命名空間、關係和權限都必須存在
+func Validate(namespaces []Namespace) error {
+	seen := make(map[string]bool, len(namespaces))
+	for _, ns := range namespaces {
+		if ns.Name == "" {
+			return fmt.Errorf("opl: 命名空間名稱不能為空")
+		}
+		if seen[ns.Name] {
+			return fmt.Errorf("opl: 命名空間 %s 重複定義", ns.Name)
+		}
+		seen[ns.Name] = true
+	}
+
+	for _, ns := range namespaces {
+		for _, rel := range ns.Relations {
+			if len(rel.Subjects) == 0 {
+				return fmt.Errorf("opl: %s.%s 沒有定義主體類型", ns.Name, rel.Name)
+			}
+			for _, st := range rel.Subjects {
+				target, ok := Lookup(namespaces, st.Namespace)
+				if !ok {
+					return fmt.Errorf("opl: %s.%s 引用了未定義的命名空間 %s", ns.Name, rel.Name, st.Namespace)
+				}
+				if st.Relation == "" {
+					continue
+				}
+				if _, ok := target.Relation(st.Relation); !ok {
+					return fmt.Errorf("opl: %s.%s 引用了未定義的關係 %s.%s", ns.Name, rel.Name, st.Namespace, st.Relation)
+				}
+			}
+		}
+		for _, p := range ns.Permits {
+			if err := validateRule(namespaces, ns, p.Rule); err != nil {
+				return fmt.Errorf("opl: %s.%s: %w", ns.Name, p.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateRule 檢查單一規則引用的關係和權限
+func validateRule(namespaces []Namespace, ns Namespace, r Rule) error {
+	switch r := r.(type) {
+	case Includes:
+		if _, ok := ns.Relation(r.Relation); !ok {
+			return fmt.Errorf("未定義的關係 %s", r.Relation)
+		}
+	case Traverse:
+		rel, ok := ns.Relation(r.Relation)
+		if !ok {
+			return fmt.Errorf("未定義的關係 %s", r.Relation)
+		}
+		for _, st := range rel.Subjects {
+			target, _ := Lookup(namespaces, st.Namespace)
+			if _, ok := target.Permit(r.Permit); !ok {
+				return fmt.Errorf("命名空間 %s 沒有權限 %s", st.Namespace, r.Permit)
+			}
+		}
+	case Invoke:
+		if _, ok := ns.Permit(r.Permit); !ok {
+			return fmt.Errorf("未定義的權限 %s", r.Permit)
+		}
+	case Or:
+		for _, sub := range r {
+			if err := validateRule(namespaces, ns, sub); err != nil {
+				return err
+			}
+		}
+	case And:
+		for _, sub := range r {
+			if err := validateRule(namespaces, ns, sub); err != nil {
+				return err
+			}
+		}
+	case Not:
+		return validateRule(namespaces, ns, r.Rule)
+	case nil:
+		return fmt.Errorf("規則不能為空")
+	default:
+		return fmt.Errorf("不支援的規則類型 %T", r)
+	}
+	return nil
+}
+
+// Generate 將命名空間定義輸出為 OPL (namespaces.keto.ts)
+//
+// 參數:
+//   - w: 輸出目標
+//   - namespaces: 命名空間定義
+//
+// 返回:
+//   - error: 如定義不完整或寫入失敗則返回錯誤
+func Generate(w io.Writer, namespaces []Namespace) error {
+	if err := Validate(namespaces); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(header)
+
+	for _, ns := range namespaces {
+		buf.WriteString("\n")
+		if len(ns.Relations) == 0 && len(ns.Permits) == 0 {
+			fmt.Fprintf(&buf, "class %s implements Namespace {}\n", ns.Name)
+			continue
+		}
+
+		fmt.Fprintf(&buf, "class %s implements Namespace {\n", ns.Name)
+		if len(ns.Relations) > 0 {
+			buf.WriteString("  related: {\n")
+			for _, rel := range ns.Relations {
+				fmt.Fprintf(&buf, "    %s: %s\n", rel.Name, formatSubjectTypes(rel.Subjects))
+			}
+			buf.WriteString("  }\n")
+		}
+		if len(ns.Relations) > 0 && len(ns.Permits) > 0 {
+			buf.WriteString("\n")
+		}
+		if len(ns.Permits) > 0 {
+			buf.WriteString("  permits = {\n")
+			for _, p := range ns.Permits {
+				fmt.Fprintf(&buf, "    %s: (ctx: Context): boolean =>\n      %s,\n", p.Name, formatRule(p.Rule, false))
+			}
+			buf.WriteString("  }\n")
+		}
+		buf.WriteString("}\n")
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// formatSubjectTypes 輸出關係的主體類型，例如 (User | SubjectSet<Event, "members">)[]
+func formatSubjectTypes(subjects []SubjectType) string {
+	types := make([]string, len(subjects))
+	for i, st := range subjects {
+		if st.Relation == "" {
+			types[i] = st.Namespace
+		} else {
+			types[i] = fmt.Sprintf("SubjectSet<%s, %q>", st.Namespace, st.Relation)
+		}
+	}
+	if len(types) == 1 && subjects[0].Relation == "" {
+		return types[0] + "[]"
+	}
+	return "(" + strings.Join(types, " | ") + ")[]"
+}
+
+// formatRule 輸出規則的 OPL 表達式
+// nested 為 true 時，複合表達式會加上括號
+func formatRule(r Rule, nested bool) string {
+	switch r := r.(type) {
+	case Includes:
+		return fmt.Sprintf("this.related.%s.includes(ctx.subject)", r.Relation)
+	case Traverse:
+		return fmt.Sprintf("this.related.%s.traverse((s) => s.permits.%s(ctx))", r.Relation, r.Permit)
+	case Invoke:
+		return fmt.Sprintf("this.permits.%s(ctx)", r.Permit)
+	case Or:
+		return joinRules([]Rule(r), " ||\n      ", nested)
+	case And:
+		return joinRules([]Rule(r), " &&\n      ", nested)
+	case Not:
+		return "!" + formatRule(r.Rule, true)
+	}
+	return ""
+}
+
+// joinRules 以運算符連接多個規則
+func joinRules(rules []Rule, sep string, nested bool) string {
+	if len(rules) == 1 {
+		return formatRule(rules[0], nested)
+	}
+	parts := make([]string, len(rules))
+	for i, sub := range rules {
+		parts[i] = formatRule(sub, true)
+	}
+	s := strings.Join(parts, sep)
+	if nested {
+		return "(" + s + ")"
+	}
+	return s
+}
@@ -0,0 +1,158 @@
+package opl
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testNamespaces() []Namespace {
+	return []Namespace{
+		{Name: "User"},
+		{
+			Name: "Folder",
+			Relations: []Relation{
+				{Name: "viewers", Subjects: []SubjectType{{Namespace: "User"}}},
+			},
+			Permits: []Permit{
+				{Name: "view", Rule: Includes{Relation: "viewers"}},
+			},
+		},
+		{
+			Name: "File",
+			Relations: []Relation{
+				{Name: "parents", Subjects: []SubjectType{{Namespace: "Folder"}}},
+				{Name: "owners", Subjects: []SubjectType{{Namespace: "User"}, {Namespace: "Folder", Relation: "viewers"}}},
+				{Name: "banned", Subjects: []SubjectType{{Namespace: "User"}}},
+			},
+			Permits: []Permit{
+				{Name: "edit", Rule: Includes{Relation: "owners"}},
+				{
+					Name: "view",
+					Rule: And{
+						Or{Invoke{Permit: "edit"}, Traverse{Relation: "parents", Permit: "view"}},
+						Not{Rule: Includes{Relation: "banned"}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	var buf bytes.Buffer
+	err := Generate(&buf, testNamespaces())
+
+	assert.NoError(t, err)
+	assert.Equal(t, header+`
+class User implements Namespace {}
+
+class Folder implements Namespace {
+  related: {
+    viewers: User[]
+  }
+
+  permits = {
+    view: (ctx: Context): boolean =>
+      this.related.viewers.includes(ctx.subject),
+  }
+}
+
+class File implements Namespace {
+  related: {
+    parents: Folder[]
+    owners: (User | SubjectSet<Folder, "viewers">)[]
+    banned: User[]
+  }
+
+  permits = {
+    edit: (ctx: Context): boolean =>
+      this.related.owners.includes(ctx.subject),
+    view: (ctx: Context): boolean =>
+      (this.permits.edit(ctx) ||
+      this.related.parents.traverse((s) => s.permits.view(ctx))) &&
+      !this.related.banned.includes(ctx.subject),
+  }
+}
+`, buf.String())
+}
+
+func TestValidate_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		namespaces []Namespace
+	}{
+		{
+			name:       "EmptyName",
+			namespaces: []Namespace{{}},
+		},
+		{
+			name:       "DuplicateNamespace",
+			namespaces: []Namespace{{Name: "User"}, {Name: "User"}},
+		},
+		{
+			name: "UnknownSubjectNamespace",
+			namespaces: []Namespace{{
+				Name:      "Photo",
+				Relations: []Relation{{Name: "owners", Subjects: []SubjectType{{Namespace: "User"}}}},
+			}},
+		},
+		{
+			name: "NoSubjectTypes",
+			namespaces: []Namespace{{
+				Name:      "Photo",
+				Relations: []Relation{{Name: "owners"}},
+			}},
+		},
+		{
+			name: "UnknownSubjectSetRelation",
+			namespaces: []Namespace{{
+				Name:      "Photo",
+				Relations: []Relation{{Name: "owners", Subjects: []SubjectType{{Namespace: "Photo", Relation: "viewers"}}}},
+			}},
+		},
+		{
+			name: "UnknownIncludesRelation",
+			namespaces: []Namespace{{
+				Name:    "Photo",
+				Permits: []Permit{{Name: "view", Rule: Includes{Relation: "viewers"}}},
+			}},
+		},
+		{
+			name: "UnknownTraversePermit",
+			namespaces: []Namespace{
+				{Name: "Event"},
+				{
+					Name:      "Photo",
+					Relations: []Relation{{Name: "parents", Subjects: []SubjectType{{Namespace: "Event"}}}},
+					Permits:   []Permit{{Name: "view", Rule: Traverse{Relation: "parents", Permit: "view"}}},
+				},
+			},
+		},
+		{
+			name: "UnknownInvokePermit",
+			namespaces: []Namespace{{
+				Name:    "Photo",
+				Permits: []Permit{{Name: "view", Rule: Not{Rule: Invoke{Permit: "edit"}}}},
+			}},
+		},
+		{
+			name: "NilRule",
+			namespaces: []Namespace{{
+				Name:    "Photo",
+				Permits: []Permit{{Name: "view"}},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Error(t, Validate(tt.namespaces))
+
+			var buf bytes.Buffer
+			assert.Error(t, Generate(&buf, tt.namespaces))
+			assert.Empty(t, buf.String())
+		})
+	}
+}
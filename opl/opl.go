@@ -0,0 +1,103 @@
+// Package opl 描述 Keto 命名空間的結構，並將其輸出為 Ory Permission Language (OPL)
+//
+// OPL 是 Keto 用來定義關係語意的 TypeScript 子集，例如
+// 「事件的成員可以查看該事件的照片」這類規則只能在 OPL 中表達。
+package opl
+
+// Namespace 命名空間定義
+type Namespace struct {
+	Name      string     // 命名空間名稱 (例如: "Photo")
+	Relations []Relation // 可直接寫入元組的關係
+	Permits   []Permit   // 由規則計算得出的權限
+}
+
+// Relation 關係定義
+type Relation struct {
+	Name     string        // 關係名稱 (例如: "reference")
+	Subjects []SubjectType // 允許作為主體的類型
+}
+
+// SubjectType 關係允許的主體類型
+type SubjectType struct {
+	Namespace string // 主體所在的命名空間
+	Relation  string // 非空時表示主體集合 SubjectSet<Namespace, Relation>
+}
+
+// Permit 權限定義
+type Permit struct {
+	Name string // 權限名稱 (例如: "view")
+	Rule Rule   // 權限規則
+}
+
+// Rule 權限規則
+// 可用的規則為 Includes、Traverse、Invoke、Or、And 和 Not
+type Rule interface {
+	rule()
+}
+
+// Includes 主體直接出現在關係中
+// 對應 OPL: this.related.<Relation>.includes(ctx.subject)
+type Includes struct {
+	Relation string
+}
+
+// Traverse 沿關係前往主體所在的對象，並檢查其權限
+// 對應 OPL: this.related.<Relation>.traverse((s) => s.permits.<Permit>(ctx))
+type Traverse struct {
+	Relation string
+	Permit   string
+}
+
+// Invoke 檢查同一對象上的另一個權限
+// 對應 OPL: this.permits.<Permit>(ctx)
+type Invoke struct {
+	Permit string
+}
+
+// Or 任一規則成立即可 (聯集)
+type Or []Rule
+
+// And 所有規則都必須成立 (交集)
+type And []Rule
+
+// Not 規則不成立 (排除)
+type Not struct {
+	Rule Rule
+}
+
+func (Includes) rule() {}
+func (Traverse) rule() {}
+func (Invoke) rule()   {}
+func (Or) rule()       {}
+func (And) rule()      {}
+func (Not) rule()      {}
+
+// Lookup 按名稱查找命名空間
+func Lookup(namespaces []Namespace, name string) (Namespace, bool) {
+	for _, ns := range namespaces {
+		if ns.Name == name {
+			return ns, true
+		}
+	}
+	return Namespace{}, false
+}
+
+// Relation 按名稱查找關係
+func (ns Namespace) Relation(name string) (Relation, bool) {
+	for _, rel := range ns.Relations {
+		if rel.Name == name {
+			return rel, true
+		}
+	}
+	return Relation{}, false
+}
+
+// Permit 按名稱查找權限
+func (ns Namespace) Permit(name string) (Permit, bool) {
+	for _, p := range ns.Permits {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Permit{}, false
+}
@@ -0,0 +1,118 @@
+// Package telemetry 配置 OpenTelemetry 追蹤的導出
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// 支援的追蹤導出器
+const (
+	ExporterNone   = "none"   // 不導出
+	ExporterStdout = "stdout" // 以 JSON 輸出到標準輸出
+	ExporterFile   = "file"   // 以 JSON 輸出到文件
+	ExporterOTLP   = "otlp"   // 以 OTLP/gRPC 發送到 collector
+)
+
+// TracingConfig 追蹤配置
+type TracingConfig struct {
+	ServiceName  string // 服務名稱
+	Exporter     string // 導出器: none、stdout、file 或 otlp，空字符串等同 none
+	File         string // Exporter 為 file 時的輸出路徑
+	OTLPEndpoint string // Exporter 為 otlp 時的 collector 地址 (例如: "127.0.0.1:4317")
+	OTLPInsecure bool   // 連接 collector 時不使用 TLS
+}
+
+// SetupTracing 按配置創建 TracerProvider，並設置為全局的 TracerProvider 和 W3C 追蹤上下文傳播格式
+//
+// 參數:
+//   - ctx: 用於創建導出器的 context
+//   - cfg: 追蹤配置
+//
+// 返回:
+//   - func(context.Context) error: 關閉函數，會導出剩餘的 span 並釋放資源
+//   - error: 如配置無效或導出器創建失敗則返回錯誤
+func SetupTracing(ctx context.Context, cfg TracingConfig) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	exporter, closer, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if exporter == nil {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tp)
+
+	return func(ctx context.Context) error {
+		err := tp.Shutdown(ctx)
+		if closer != nil {
+			err = errors.Join(err, closer.Close())
+		}
+		return err
+	}, nil
+}
+
+// newExporter 按配置創建導出器
+// 導出器寫入文件時同時返回需要關閉的文件
+func newExporter(ctx context.Context, cfg TracingConfig) (sdktrace.SpanExporter, io.Closer, error) {
+	switch cfg.Exporter {
+	case "", ExporterNone:
+		return nil, nil, nil
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		return exporter, nil, err
+	case ExporterFile:
+		if cfg.File == "" {
+			return nil, nil, fmt.Errorf("telemetry: file 導出器需要指定文件路徑")
+		}
+		f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, err
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+		return exporter, f, nil
+	case ExporterOTLP:
+		opts := []otlptracegrpc.Option{}
+		if cfg.OTLPEndpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(cfg.OTLPEndpoint))
+		}
+		if cfg.OTLPInsecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		exporter, err := otlptracegrpc.New(ctx, opts...)
+		return exporter, nil, err
+	}
+	return nil, nil, fmt.Errorf("telemetry: 不支援的追蹤導出器 %q", cfg.Exporter)
+}
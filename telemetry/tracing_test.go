@@ -0,0 +1,48 @@
+package telemetry
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+)
+
+func TestSetupTracing_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+
+	shutdown, err := SetupTracing(context.Background(), TracingConfig{
+		ServiceName: "test-service",
+		Exporter:    ExporterFile,
+		File:        path,
+	})
+	require.NoError(t, err)
+
+	_, span := otel.Tracer("test").Start(context.Background(), "test-span")
+	span.End()
+	require.NoError(t, shutdown(context.Background()))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"Name":"test-span"`)
+	assert.Contains(t, string(data), "test-service")
+}
+
+func TestSetupTracing_None(t *testing.T) {
+	for _, exporter := range []string{"", ExporterNone} {
+		shutdown, err := SetupTracing(context.Background(), TracingConfig{Exporter: exporter})
+		require.NoError(t, err)
+		assert.NoError(t, shutdown(context.Background()))
+	}
+}
+
+func TestSetupTracing_Errors(t *testing.T) {
+	_, err := SetupTracing(context.Background(), TracingConfig{Exporter: "zipkin"})
+	assert.Error(t, err)
+
+	_, err = SetupTracing(context.Background(), TracingConfig{Exporter: ExporterFile})
+	assert.Error(t, err)
+}